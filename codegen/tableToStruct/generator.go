@@ -221,10 +221,13 @@ func (g *generator) runTable() {
 func (g *generator) getGenericTemplate(tableName string) string {
 	var finalTpl bytes.Buffer
 
-	// at least we need a type definition
+	// at least we need a type definition and the reflection free scanner
 	if _, err := finalTpl.WriteString(tpl.Type); err != nil {
 		codegen.LogFatal(err)
 	}
+	if _, err := finalTpl.WriteString(tpl.Scanner); err != nil {
+		codegen.LogFatal(err)
+	}
 
 	if false == g.whiteListTables.Contains(tableName) {
 		return finalTpl.String()
@@ -28,6 +28,50 @@ type {{.Struct}} struct {
 {{ end }} }
 `
 
+// Scanner implements the interfaces dbr.RowScanner and dbr.ArgumentAssembler
+// to load and insert the types without reflection.
+const Scanner = `
+// RowScanArgs appends a new *{{.Struct}} and returns the addresses of
+// its fields for the columns. Implements interface dbr.RowScanner.
+// Generated via tableToStruct.
+func (s *{{.Slice}}) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	e := new({{.Struct}})
+	*s = append(*s, e)
+	return e.RowScanArgs(columns, args)
+}
+
+// RowScanArgs returns the addresses of the fields for the columns. Unknown
+// columns get discarded. Implements interface dbr.RowScanner.
+// Generated via tableToStruct.
+func (e *{{.Struct}}) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	args = args[:0]
+	for _, c := range columns {
+		switch c {
+		{{ range .GoColumns }}case "{{.Field.String}}":
+			args = append(args, &e.{{.GoName}})
+		{{ end }}default:
+			args = append(args, nil)
+		}
+	}
+	return args, nil
+}
+
+// AssembleArguments appends the values of the fields for the columns.
+// Implements interface dbr.ArgumentAssembler.
+// Generated via tableToStruct.
+func (e *{{.Struct}}) AssembleArguments(columns []string, args []interface{}) ([]interface{}, error) {
+	for _, c := range columns {
+		switch c {
+		{{ range .GoColumns }}case "{{.Field.String}}":
+			args = append(args, e.{{.GoName}})
+		{{ end }}default:
+			return nil, errors.NewNotFoundf("[{{.Package}}] {{.Struct}}.AssembleArguments: Unknown column %q", c)
+		}
+	}
+	return args, nil
+}
+`
+
 // Generics defines the available templates
 type Generics int
 
@@ -23,6 +23,7 @@ import (
 	"github.com/corestoreio/csfw/eav"{{end}}
 	"github.com/corestoreio/csfw/storage/csdb"
 	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/errors"
 )
 
 // TableIndex... is the index to a table. These constants are guaranteed
@@ -0,0 +1,64 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+)
+
+// Change represents one write of a configuration value.
+type Change struct {
+	// Path contains the route and the scope with its ID.
+	Path cfgpath.Path
+	// OldValue is nil if the path did not exist before.
+	OldValue interface{}
+	// NewValue is the written value.
+	NewValue interface{}
+	// Actor identifies who has changed the value, for example an user name.
+	// Empty if the context does not contain an actor.
+	Actor string
+	// Time of the change.
+	Time time.Time
+}
+
+// Sink stores and queries the changes. A Sink must be safe for concurrent
+// use.
+type Sink interface {
+	// Record saves a change.
+	Record(ctx context.Context, c Change) error
+	// History returns all changes of a path, oldest first. The scope of the
+	// path must match.
+	History(ctx context.Context, p cfgpath.Path) ([]Change, error)
+	// Range returns all changes within the time range, including from and to,
+	// oldest first.
+	Range(ctx context.Context, from, to time.Time) ([]Change, error)
+}
+
+type keyCtxActor struct{}
+
+// WithActor adds the actor to the context. The config.Service takes the actor
+// from the context while writing a value.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, keyCtxActor{}, actor)
+}
+
+// ActorFromContext returns the actor from the context or an empty string.
+func ActorFromContext(ctx context.Context) string {
+	a, _ := ctx.Value(keyCtxActor{}).(string)
+	return a
+}
@@ -0,0 +1,191 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/audit"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ audit.Sink   = (*audit.MemorySink)(nil)
+	_ audit.Sink   = (*audit.DBSink)(nil)
+	_ audit.Writer = (*config.Service)(nil)
+)
+
+func TestActorFromContext(t *testing.T) {
+	assert.Exactly(t, "", audit.ActorFromContext(context.Background()))
+	assert.Exactly(t, "gopher", audit.ActorFromContext(audit.WithActor(context.Background(), "gopher")))
+}
+
+func TestService_WithAudit(t *testing.T) {
+	sink := audit.NewMemorySink()
+	srv := config.MustNewService(config.NewInMemoryStore(), config.WithAudit(sink))
+
+	p := cfgpath.MustNewByParts("web/secure/base_url").BindStore(2)
+	ctx := audit.WithActor(context.Background(), "admin")
+	assert.NoError(t, srv.WriteContext(ctx, p, "https://a.com/"))
+	assert.NoError(t, srv.Write(p, "https://b.com/"))
+	assert.NoError(t, srv.Write(p.BindWebsite(1), "https://c.com/"))
+
+	cs, err := sink.History(ctx, p)
+	assert.NoError(t, err)
+	if assert.Len(t, cs, 2) {
+		assert.Nil(t, cs[0].OldValue)
+		assert.Exactly(t, "https://a.com/", cs[0].NewValue)
+		assert.Exactly(t, "admin", cs[0].Actor)
+		assert.Exactly(t, "https://a.com/", cs[1].OldValue)
+		assert.Exactly(t, "https://b.com/", cs[1].NewValue)
+		assert.Exactly(t, "", cs[1].Actor)
+		assert.False(t, cs[1].Time.Before(cs[0].Time))
+	}
+}
+
+type errSink struct {
+	audit.Sink
+}
+
+func (errSink) Record(_ context.Context, _ audit.Change) error {
+	return errors.NewFatalf("Sink gone")
+}
+
+type pathRecorder struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (pr *pathRecorder) MessageConfig(p cfgpath.Path) error {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.paths = append(pr.paths, p.String())
+	return nil
+}
+
+func (pr *pathRecorder) Send(p cfgpath.Path) error {
+	return pr.MessageConfig(p)
+}
+
+func TestService_WithAudit_Error(t *testing.T) {
+	tp := &pathRecorder{}
+	srv := config.MustNewService(config.NewInMemoryStore(), config.WithAudit(errSink{}), config.WithPubSub(), config.WithTransport(tp))
+	rec := &pathRecorder{}
+	p := cfgpath.MustNewByParts("aa/bb/cc")
+	_, err := srv.Subscribe(p.Route, rec)
+	assert.NoError(t, err)
+
+	err = srv.Write(p, 1)
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+	assert.True(t, srv.IsSet(p), "Value must be written")
+	assert.NoError(t, srv.Close())
+
+	// the value has been written, so everyone gets notified
+	assert.Exactly(t, []string{p.String()}, rec.paths)
+	assert.Exactly(t, []string{p.String()}, tp.paths)
+}
+
+// newRollbackSink writes three changes per path, one per hour.
+func newRollbackSink(t0 time.Time, paths ...cfgpath.Path) *audit.MemorySink {
+	sink := audit.NewMemorySink()
+	for _, p := range paths {
+		var old interface{}
+		for h := 0; h < 3; h++ {
+			_ = sink.Record(context.Background(), audit.Change{
+				Path:     p,
+				OldValue: old,
+				NewValue: h,
+				Time:     t0.Add(time.Duration(h) * time.Hour),
+			})
+			old = h
+		}
+	}
+	return sink
+}
+
+func TestRollbackPath(t *testing.T) {
+	t0 := time.Date(2016, 10, 17, 10, 0, 0, 0, time.UTC)
+	pA := cfgpath.MustNewByParts("aa/bb/cc").BindStore(1)
+	pB := cfgpath.MustNewByParts("aa/bb/dd")
+	sink := newRollbackSink(t0, pA, pB)
+
+	srv := config.MustNewService(config.NewInMemoryStore())
+	assert.NoError(t, srv.Write(pA, 2))
+	assert.NoError(t, srv.Write(pB, 2))
+
+	n, err := audit.RollbackPath(context.Background(), sink, srv, pA, t0.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Exactly(t, 1, n)
+
+	v, err := srv.Int(pA)
+	assert.NoError(t, err)
+	assert.Exactly(t, 0, v)
+	v, err = srv.Int(pB)
+	assert.NoError(t, err)
+	assert.Exactly(t, 2, v, "pB must not change")
+
+	// nothing to roll back
+	n, err = audit.RollbackPath(context.Background(), sink, srv, pA, t0.Add(5*time.Hour))
+	assert.NoError(t, err)
+	assert.Exactly(t, 0, n)
+}
+
+func TestRollbackRange(t *testing.T) {
+	t0 := time.Date(2016, 10, 17, 10, 0, 0, 0, time.UTC)
+	pA := cfgpath.MustNewByParts("aa/bb/cc").BindStore(1)
+	pB := cfgpath.MustNewByParts("aa/bb/dd")
+	sink := newRollbackSink(t0, pA, pB)
+
+	auditSink := audit.NewMemorySink()
+	srv := config.MustNewService(config.NewInMemoryStore(), config.WithAudit(auditSink))
+
+	ctx := audit.WithActor(context.Background(), "rollback")
+	n, err := audit.RollbackRange(ctx, sink, srv, t0, t0.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Exactly(t, 2, n)
+
+	// both paths did not exists before t0
+	for _, p := range []cfgpath.Path{pA, pB} {
+		assert.False(t, srv.IsSet(p), "Path %s", p)
+	}
+
+	// the rollback gets audited too
+	cs, err := auditSink.History(ctx, pA)
+	assert.NoError(t, err)
+	if assert.Len(t, cs, 1) {
+		assert.Exactly(t, "rollback", cs[0].Actor)
+		assert.Nil(t, cs[0].NewValue)
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) WriteContext(_ context.Context, _ cfgpath.Path, _ interface{}) error {
+	return errors.NewNotSupportedf("Read only")
+}
+
+func TestRollback_Error(t *testing.T) {
+	t0 := time.Now()
+	sink := newRollbackSink(t0, cfgpath.MustNewByParts("aa/bb/cc"))
+	n, err := audit.RollbackRange(context.Background(), sink, errWriter{}, t0, t0.Add(time.Hour))
+	assert.True(t, errors.IsNotSupported(err), "Error: %s", err)
+	assert.Exactly(t, 0, n)
+}
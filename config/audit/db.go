@@ -0,0 +1,171 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/corestoreio/csfw/util/null"
+)
+
+// DefaultTableName of the table which stores the changes.
+const DefaultTableName = "core_config_data_audit"
+
+// TableCreateStatement creates the audit table. The first argument to
+// fmt.Sprintf must be the table name.
+const TableCreateStatement = "CREATE TABLE IF NOT EXISTS `%s` (" +
+	"`audit_id` int(10) unsigned NOT NULL AUTO_INCREMENT," +
+	"`scope` varchar(8) NOT NULL DEFAULT 'default'," +
+	"`scope_id` int(11) NOT NULL DEFAULT '0'," +
+	"`path` varchar(255) NOT NULL DEFAULT 'general'," +
+	"`old_value` text," +
+	"`new_value` text," +
+	"`actor` varchar(255) NOT NULL DEFAULT ''," +
+	"`created_at` datetime(6) NOT NULL," +
+	"PRIMARY KEY (`audit_id`)," +
+	"KEY `CORE_CONFIG_DATA_AUDIT_SCOPE_SCOPE_ID_PATH` (`scope`,`scope_id`,`path`)," +
+	"KEY `CORE_CONFIG_DATA_AUDIT_CREATED_AT` (`created_at`)" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='Config Data Audit'"
+
+// DB defines the needed functions of a database connection, for example
+// *sql.DB or *sql.Tx.
+type DB interface {
+	csdb.Execer
+	csdb.Querier
+}
+
+// DBSink stores the changes in a separate MySQL table. The values get
+// converted to strings like in table core_config_data.
+type DBSink struct {
+	DB DB
+	// Table name, default DefaultTableName.
+	Table string
+}
+
+// NewDBSink creates a new DBSink with the DefaultTableName. The table must
+// exist, see TableCreateStatement.
+func NewDBSink(db DB) *DBSink {
+	return &DBSink{
+		DB:    db,
+		Table: DefaultTableName,
+	}
+}
+
+// CreateTable creates the audit table if it does not exist.
+func (ds *DBSink) CreateTable(ctx context.Context) error {
+	_, err := ds.DB.ExecContext(ctx, fmt.Sprintf(TableCreateStatement, ds.Table))
+	return errors.Wrapf(err, "[audit] DBSink.CreateTable %q", ds.Table)
+}
+
+func toNullString(v interface{}) (null.String, error) {
+	if v == nil {
+		return null.String{}, nil
+	}
+	s, err := conv.ToStringE(v)
+	if err != nil {
+		return null.String{}, errors.Wrapf(err, "[audit] conv.ToStringE Value: %v", v)
+	}
+	return null.StringFrom(s), nil
+}
+
+// Record inserts the change into the table.
+func (ds *DBSink) Record(ctx context.Context, c Change) error {
+	pl, err := c.Path.Level(-1)
+	if err != nil {
+		return errors.Wrapf(err, "[audit] DBSink.Record.Level Path %q", c.Path)
+	}
+	oldVal, err := toNullString(c.OldValue)
+	if err != nil {
+		return errors.Wrapf(err, "[audit] DBSink.Record.OldValue Path %q", c.Path)
+	}
+	newVal, err := toNullString(c.NewValue)
+	if err != nil {
+		return errors.Wrapf(err, "[audit] DBSink.Record.NewValue Path %q", c.Path)
+	}
+
+	scp, id := c.Path.ScopeID.Unpack()
+	_, err = ds.DB.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO `%s` (`scope`,`scope_id`,`path`,`old_value`,`new_value`,`actor`,`created_at`) VALUES (?,?,?,?,?,?,?)",
+		ds.Table,
+	), scp.StrType(), id, pl.String(), oldVal, newVal, c.Actor, c.Time)
+	return errors.Wrapf(err, "[audit] DBSink.Record.ExecContext Path %q", c.Path)
+}
+
+const selectColumns = "SELECT `scope`,`scope_id`,`path`,`old_value`,`new_value`,`actor`,`created_at` FROM `%s` "
+
+// History returns all changes of a path, oldest first.
+func (ds *DBSink) History(ctx context.Context, p cfgpath.Path) ([]Change, error) {
+	pl, err := p.Level(-1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[audit] DBSink.History.Level Path %q", p)
+	}
+	scp, id := p.ScopeID.Unpack()
+	cs, err := ds.query(ctx, fmt.Sprintf(
+		selectColumns+"WHERE `scope`=? AND `scope_id`=? AND `path`=? ORDER BY `created_at`,`audit_id`",
+		ds.Table,
+	), scp.StrType(), id, pl.String())
+	return cs, errors.Wrapf(err, "[audit] DBSink.History Path %q", p)
+}
+
+// Range returns all changes within the time range, oldest first.
+func (ds *DBSink) Range(ctx context.Context, from, to time.Time) ([]Change, error) {
+	cs, err := ds.query(ctx, fmt.Sprintf(
+		selectColumns+"WHERE `created_at` BETWEEN ? AND ? ORDER BY `created_at`,`audit_id`",
+		ds.Table,
+	), from, to)
+	return cs, errors.Wrapf(err, "[audit] DBSink.Range From %s To %s", from, to)
+}
+
+func (ds *DBSink) query(ctx context.Context, query string, args ...interface{}) ([]Change, error) {
+	rows, err := ds.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[audit] DBSink.QueryContext SQL %q", query)
+	}
+	defer rows.Close()
+
+	var ret []Change
+	for rows.Next() {
+		var sqlScope, sqlPath, oldVal, newVal, actor null.String
+		var sqlScopeID null.Int64
+		var createdAt null.Time
+		if err := rows.Scan(&sqlScope, &sqlScopeID, &sqlPath, &oldVal, &newVal, &actor, &createdAt); err != nil {
+			return nil, errors.Wrapf(err, "[audit] DBSink.rows.Scan SQL %q", query)
+		}
+		p, err := cfgpath.NewByParts(sqlPath.String)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[audit] DBSink.cfgpath.NewByParts Path %q", sqlPath.String)
+		}
+		c := Change{
+			Path:  p.Bind(scope.FromString(sqlScope.String).Pack(sqlScopeID.Int64)),
+			Actor: actor.String,
+			Time:  createdAt.Time,
+		}
+		if oldVal.Valid {
+			c.OldValue = oldVal.String
+		}
+		if newVal.Valid {
+			c.NewValue = newVal.String
+		}
+		ret = append(ret, c)
+	}
+	return ret, errors.Wrapf(rows.Err(), "[audit] DBSink.rows.Err SQL %q", query)
+}
@@ -0,0 +1,116 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/config/audit"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBSink_Record(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	now := time.Now()
+	dbMock.ExpectExec("INSERT INTO `core_config_data_audit` \\(.+\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?,\\?\\)").
+		WithArgs("stores", int64(2), "web/secure/base_url", nil, "https://x.com/", "admin", now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec("INSERT INTO `core_config_data_audit`").
+		WithArgs("default", int64(0), "web/secure/offloader_header", "SSL_OFFLOADED", "1", "", now).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	ds := audit.NewDBSink(dbc.DB)
+	assert.NoError(t, ds.Record(context.Background(), audit.Change{
+		Path:     cfgpath.MustNewByParts("web/secure/base_url").BindStore(2),
+		NewValue: "https://x.com/",
+		Actor:    "admin",
+		Time:     now,
+	}))
+	assert.NoError(t, ds.Record(context.Background(), audit.Change{
+		Path:     cfgpath.MustNewByParts("web/secure/offloader_header"),
+		OldValue: "SSL_OFFLOADED",
+		NewValue: 1,
+		Time:     now,
+	}))
+}
+
+func TestDBSink_History(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	t0 := time.Date(2016, 10, 17, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"scope", "scope_id", "path", "old_value", "new_value", "actor", "created_at"}).
+		AddRow("websites", 1, "general/locale/code", nil, "de_DE", "admin", t0).
+		AddRow("websites", 1, "general/locale/code", "de_DE", "de_CH", "", []byte("2016-10-17 11:00:00.000000"))
+	dbMock.ExpectQuery("SELECT .+ FROM `core_config_data_audit` WHERE `scope`=\\? AND `scope_id`=\\? AND `path`=\\? ORDER BY `created_at`,`audit_id`").
+		WithArgs("websites", int64(1), "general/locale/code").
+		WillReturnRows(rows)
+
+	p := cfgpath.MustNewByParts("general/locale/code").BindWebsite(1)
+	cs, err := audit.NewDBSink(dbc.DB).History(context.Background(), p)
+	assert.NoError(t, err)
+	if assert.Len(t, cs, 2) {
+		assert.Exactly(t, p.String(), cs[0].Path.String())
+		assert.Exactly(t, scope.Website.Pack(1), cs[0].Path.ScopeID)
+		assert.Nil(t, cs[0].OldValue)
+		assert.Exactly(t, "de_DE", cs[0].NewValue)
+		assert.Exactly(t, "admin", cs[0].Actor)
+		assert.Exactly(t, t0, cs[0].Time)
+		assert.Exactly(t, "de_DE", cs[1].OldValue)
+		assert.Exactly(t, t0.Add(time.Hour), cs[1].Time)
+	}
+}
+
+func TestDBSink_Range_Error(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	from, to := time.Now().Add(-time.Hour), time.Now()
+	dbMock.ExpectQuery("SELECT .+ FROM `core_config_data_audit` WHERE `created_at` BETWEEN \\? AND \\?").
+		WithArgs(driver.Value(from), driver.Value(to)).
+		WillReturnError(errors.NewAlreadyClosedf("Connection gone"))
+
+	cs, err := audit.NewDBSink(dbc.DB).Range(context.Background(), from, to)
+	assert.True(t, errors.IsAlreadyClosed(err), "Error: %s", err)
+	assert.Nil(t, cs)
+}
@@ -0,0 +1,33 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records all changes of configuration values.
+//
+// Each change contains the path including its scope, the old value, the new
+// value, the time and the actor who changed the value. The actor gets taken
+// from the context. A Sink stores the changes: MemorySink for testing and
+// DBSink for a separate MySQL table.
+//
+// Enable the audit log in the config.Service with the option function
+// config.WithAudit. Every call to Service.Write and Service.WriteContext gets
+// recorded:
+//		sink := audit.NewDBSink(db)
+//		srv := config.MustNewService(ccd.MustNewDBStorage(db), config.WithAudit(sink))
+//		ctx := audit.WithActor(r.Context(), "admin@example.com")
+//		err := srv.WriteContext(ctx, cfgpath.MustNewByParts("web/secure/base_url").BindStore(2), "https://x.com/")
+//
+// The functions RollbackPath and RollbackRange restore the old values of a
+// path or of all paths changed within a time range. A rollback writes through
+// the config.Service, so the rollback itself gets recorded too.
+package audit
@@ -0,0 +1,80 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+)
+
+// MemorySink stores the changes in memory. Mainly used for testing.
+type MemorySink struct {
+	mu      sync.RWMutex
+	changes []Change
+}
+
+// NewMemorySink creates a new empty MemorySink.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+// Record appends the change.
+func (ms *MemorySink) Record(_ context.Context, c Change) error {
+	ms.mu.Lock()
+	ms.changes = append(ms.changes, c)
+	ms.mu.Unlock()
+	return nil
+}
+
+// History returns all changes of a path, oldest first.
+func (ms *MemorySink) History(_ context.Context, p cfgpath.Path) ([]Change, error) {
+	key := p.String()
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	var ret []Change
+	for _, c := range ms.changes {
+		if c.Path.String() == key {
+			ret = append(ret, c)
+		}
+	}
+	sortByTime(ret)
+	return ret, nil
+}
+
+// Range returns all changes within the time range, oldest first.
+func (ms *MemorySink) Range(_ context.Context, from, to time.Time) ([]Change, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	var ret []Change
+	for _, c := range ms.changes {
+		if !c.Time.Before(from) && !c.Time.After(to) {
+			ret = append(ret, c)
+		}
+	}
+	sortByTime(ret)
+	return ret, nil
+}
+
+// sortByTime sorts the changes oldest first and keeps the order of recording
+// for equal times.
+func sortByTime(cs []Change) {
+	sort.SliceStable(cs, func(i, j int) bool {
+		return cs[i].Time.Before(cs[j].Time)
+	})
+}
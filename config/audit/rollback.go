@@ -0,0 +1,75 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// Writer writes a value with a context. Implemented by config.Service.
+type Writer interface {
+	WriteContext(ctx context.Context, p cfgpath.Path, value interface{}) error
+}
+
+// Rollback restores for each path the old value of its oldest change. The
+// changes must be sorted oldest first, as returned by a Sink. Paths which did
+// not exist before get the value nil because a config.Storager cannot delete
+// keys. Returns the number of restored paths.
+func Rollback(ctx context.Context, w Writer, changes []Change) (int, error) {
+	var seen = make(map[string]bool, len(changes))
+	var n int
+	for _, c := range changes {
+		key := c.Path.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if err := w.WriteContext(ctx, c.Path, c.OldValue); err != nil {
+			return n, errors.Wrapf(err, "[audit] Rollback.WriteContext Path %q", c.Path)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// RollbackPath restores the value of a path which it had before the time
+// since. All later changes of the path get reverted.
+func RollbackPath(ctx context.Context, s Sink, w Writer, p cfgpath.Path, since time.Time) (int, error) {
+	cs, err := s.History(ctx, p)
+	if err != nil {
+		return 0, errors.Wrapf(err, "[audit] RollbackPath.History Path %q", p)
+	}
+	for i, c := range cs {
+		if !c.Time.Before(since) {
+			return Rollback(ctx, w, cs[i:])
+		}
+	}
+	return 0, nil
+}
+
+// RollbackRange restores all paths changed within the time range to the
+// values they had before the time from. Changes after the time to get
+// overwritten too.
+func RollbackRange(ctx context.Context, s Sink, w Writer, from, to time.Time) (int, error) {
+	cs, err := s.Range(ctx, from, to)
+	if err != nil {
+		return 0, errors.Wrapf(err, "[audit] RollbackRange.Range From %s To %s", from, to)
+	}
+	return Rollback(ctx, w, cs)
+}
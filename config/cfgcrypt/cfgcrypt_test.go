@@ -0,0 +1,343 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgcrypt_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgcrypt"
+	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ cfgmodel.Encrypter = (*cfgcrypt.M1)(nil)
+	_ cfgmodel.Decrypter = (*cfgcrypt.M1)(nil)
+	_ cfgmodel.Encrypter = (*cfgcrypt.M2)(nil)
+	_ cfgmodel.Decrypter = (*cfgcrypt.M2)(nil)
+)
+
+const (
+	m1Key  = "0123456789abcdef"
+	m2Key0 = "0123456789abcdef0123456789abcdef"
+	m2Key1 = "fedcba9876543210fedcba9876543210"
+)
+
+func TestM1(t *testing.T) {
+	m := cfgcrypt.MustNewM1(m1Key)
+
+	// generated with PHP mcrypt_encrypt(MCRYPT_BLOWFISH, $key, $data, MCRYPT_MODE_ECB)
+	const encrypted = "rmz8ipPgO1PJwzmxZCEeag=="
+
+	ct, err := m.Encrypt([]byte("H3llo G0phers"))
+	assert.NoError(t, err)
+	assert.Exactly(t, encrypted, string(ct))
+
+	plain, err := m.Decrypt([]byte(encrypted))
+	assert.NoError(t, err)
+	assert.Exactly(t, "H3llo G0phers", string(plain))
+
+	_, err = m.Decrypt([]byte("not base64!"))
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+	_, err = m.Decrypt([]byte("SGVsbG8=")) // not a multiple of the block size
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+
+	_, err = cfgcrypt.NewM1("")
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+}
+
+func TestM2_Encrypt_Decrypt(t *testing.T) {
+	m := cfgcrypt.MustNewM2(m2Key0)
+
+	ct, err := m.Encrypt([]byte("H3llo G0phers"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(ct), "0:3:"), "%s", ct)
+
+	ct2, err := m.Encrypt([]byte("H3llo G0phers"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, string(ct), string(ct2), "Nonce must differ")
+
+	plain, err := m.Decrypt(ct)
+	assert.NoError(t, err)
+	assert.Exactly(t, "H3llo G0phers", string(plain))
+
+	// tampered cipher text
+	ct[len(ct)-3] ^= 'A' ^ 'B'
+	_, err = m.Decrypt(ct)
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+}
+
+func TestM2_Decrypt_Legacy(t *testing.T) {
+	m := cfgcrypt.MustNewM2(m2Key0)
+
+	// Magento 2.0 mcrypt Blowfish, without key and cipher version.
+	bf, err := cfgcrypt.MustNewM1(m2Key0).Encrypt([]byte("H3llo G0phers"))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		data   string
+		want   string
+		errBhf errors.BehaviourFunc
+	}{
+		// generated with PHP mcrypt_encrypt(MCRYPT_RIJNDAEL_128, $key, $data, MCRYPT_MODE_ECB)
+		{"0:1:LjZD1FGX/zFR65NBgz6jDg==", "H3llo G0phers", nil},
+		{"1:LjZD1FGX/zFR65NBgz6jDg==", "H3llo G0phers", nil},
+		{string(bf), "H3llo G0phers", nil},
+		{"0:0:" + string(bf), "H3llo G0phers", nil},
+		// Rijndael-256 CBC with and without initialization vector
+		{"0:2:abcdefghijklmnopqrstuvwxyz012345:WOZxoTLJ5SHp/BRAxG3CGn4gBaTWp7aVBgn0r/IiS0M=", "H3llo G0phers", nil},
+		{"0:2:J95NcvHsHBRFw2+k0FV4oOyA/AlxXnj4oAmwLYp3AeU=", "H3llo G0phers", nil},
+		{"0:2:abcd:WOZxoTLJ5SHp/BRAxG3CGn4gBaTWp7aVBgn0r/IiS0M=", "", errors.IsNotValid},
+		{"0:1:abcdefghijklmnopqrstuvwxyz012345:LjZD1FGX/zFR65NBgz6jDg==", "", errors.IsNotValid},
+		{"0:9:LjZD1FGX/zFR65NBgz6jDg==", "", errors.IsNotSupported},
+		{"5:1:LjZD1FGX/zFR65NBgz6jDg==", "", errors.IsNotFound},
+		{"x:1:LjZD1FGX/zFR65NBgz6jDg==", "", errors.IsNotValid},
+		{"0:y:LjZD1FGX/zFR65NBgz6jDg==", "", errors.IsNotValid},
+		{"0:3:AAAA", "", errors.IsNotValid},
+	}
+	for i, test := range tests {
+		plain, err := m.Decrypt([]byte(test.data))
+		if test.errBhf != nil {
+			assert.True(t, test.errBhf(err), "Index %d => %s", i, err)
+			assert.Nil(t, plain, "Index %d", i)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, string(plain), "Index %d", i)
+	}
+}
+
+func TestM2_AddKey(t *testing.T) {
+	m := cfgcrypt.MustNewM2(m2Key0)
+	old, err := m.Encrypt([]byte("Gopher"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.AddKey(m2Key1))
+	assert.Exactly(t, 1, m.KeyVersion())
+
+	ct, err := m.Encrypt([]byte("Gopher"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(ct), "1:3:"), "%s", ct)
+
+	for _, data := range [][]byte{old, ct} {
+		plain, err := m.Decrypt(data)
+		assert.NoError(t, err)
+		assert.Exactly(t, "Gopher", string(plain))
+	}
+
+	// only the new key
+	_, err = cfgcrypt.MustNewM2(m2Key1).Decrypt(old)
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+
+	err = m.AddKey("short")
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+}
+
+func TestNewM2_Error(t *testing.T) {
+	_, err := cfgcrypt.NewM2()
+	assert.True(t, errors.IsEmpty(err), "Error: %s", err)
+	_, err = cfgcrypt.NewM2(m2Key0, "short")
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+}
+
+func TestKeyFromLocalXML(t *testing.T) {
+	key, err := cfgcrypt.KeyFromLocalXML(strings.NewReader(`<?xml version="1.0"?>
+<config>
+    <global>
+        <crypt>
+            <key><![CDATA[ 0123456789abcdef ]]></key>
+        </crypt>
+    </global>
+</config>`))
+	assert.NoError(t, err)
+	assert.Exactly(t, m1Key, key)
+
+	_, err = cfgcrypt.KeyFromLocalXML(strings.NewReader(`<config><global></global></config>`))
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	_, err = cfgcrypt.KeyFromLocalXML(strings.NewReader(`<config>`))
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+}
+
+func TestKeysFromEnvPHP(t *testing.T) {
+	tests := []struct {
+		php    string
+		want   []string
+		errBhf errors.BehaviourFunc
+	}{
+		{`<?php
+return array (
+  'backend' => array ( 'frontName' => 'admin' ),
+  'crypt' =>
+  array (
+    'key' => '` + m2Key0 + `',
+  ),
+);`, []string{m2Key0}, nil},
+		{`<?php
+return [
+    'crypt' => [
+        'key' => '` + m2Key0 + "\n" + m2Key1 + `'
+    ],
+];`, []string{m2Key0, m2Key1}, nil},
+		{`<?php return [ 'db' => [] ];`, nil, errors.IsNotFound},
+		{`<?php return [ 'crypt' => [ 'key' => '' ] ];`, nil, errors.IsNotFound},
+	}
+	for i, test := range tests {
+		keys, err := cfgcrypt.KeysFromEnvPHP(strings.NewReader(test.php))
+		if test.errBhf != nil {
+			assert.True(t, test.errBhf(err), "Index %d => %s", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, keys, "Index %d", i)
+	}
+}
+
+var obscureSections = element.MustNewConfiguration(
+	element.Section{
+		ID: cfgpath.NewRoute(`payment`),
+		Groups: element.NewGroupSlice(
+			element.Group{
+				ID: cfgpath.NewRoute(`paypal`),
+				Fields: element.NewFieldSlice(
+					element.Field{
+						ID:     cfgpath.NewRoute(`api_password`),
+						Type:   element.TypeObscure,
+						Scopes: scope.PermStore,
+					},
+					element.Field{
+						ID:   cfgpath.NewRoute(`title`),
+						Type: element.TypeText,
+					},
+				),
+			},
+		),
+	},
+)
+
+func TestReencrypt(t *testing.T) {
+	m := cfgcrypt.MustNewM2(m2Key0)
+	s := config.NewInMemoryStore()
+
+	pw := cfgpath.MustNewByParts("payment/paypal/api_password")
+	ct, err := m.Encrypt([]byte("s3cr3t"))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Set(pw, string(ct)))
+	assert.NoError(t, s.Set(pw.BindStore(2), "1:LjZD1FGX/zFR65NBgz6jDg=="))
+	assert.NoError(t, s.Set(pw.BindWebsite(1), ""))
+	assert.NoError(t, s.Set(cfgpath.MustNewByParts("payment/paypal/title"), "PayPal"))
+	assert.NoError(t, s.Set(cfgpath.MustNewByParts("payment/unknown/field"), "x"))
+
+	assert.NoError(t, m.AddKey(m2Key1))
+	n, err := cfgcrypt.Reencrypt(s, obscureSections, m, m)
+	assert.NoError(t, err)
+	assert.Exactly(t, 2, n)
+
+	newM := cfgcrypt.MustNewM2(m2Key0, m2Key1)
+	for _, test := range []struct {
+		p    cfgpath.Path
+		want string
+	}{
+		{pw, "s3cr3t"},
+		{pw.BindStore(2), "H3llo G0phers"},
+	} {
+		p, want := test.p, test.want
+		v, err := s.Get(p)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(v.(string), "1:3:"), "%s => %s", p, v)
+		plain, err := newM.Decrypt([]byte(v.(string)))
+		assert.NoError(t, err)
+		assert.Exactly(t, want, string(plain))
+	}
+
+	v, err := s.Get(cfgpath.MustNewByParts("payment/paypal/title"))
+	assert.NoError(t, err)
+	assert.Exactly(t, "PayPal", v)
+
+	// a second run skips the values encrypted with the current key
+	v, err = s.Get(pw)
+	assert.NoError(t, err)
+	n, err = cfgcrypt.Reencrypt(s, obscureSections, m, m)
+	assert.NoError(t, err)
+	assert.Exactly(t, 0, n)
+	v2, err := s.Get(pw)
+	assert.NoError(t, err)
+	assert.Exactly(t, v, v2)
+}
+
+func TestReencrypt_M1toM2_Rerun(t *testing.T) {
+	m1 := cfgcrypt.MustNewM1(m1Key)
+	m2 := cfgcrypt.MustNewM2(m2Key0)
+	s := config.NewInMemoryStore()
+
+	pw := cfgpath.MustNewByParts("payment/paypal/api_password")
+	ct, err := m1.Encrypt([]byte("s3cr3t"))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Set(pw, string(ct)))
+	// an interrupted run has already migrated this value
+	ct, err = m2.Encrypt([]byte("H3llo G0phers"))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Set(pw.BindStore(2), string(ct)))
+
+	n, err := cfgcrypt.Reencrypt(s, obscureSections, m1, m2)
+	assert.NoError(t, err)
+	assert.Exactly(t, 1, n)
+
+	n, err = cfgcrypt.Reencrypt(s, obscureSections, m1, m2)
+	assert.NoError(t, err)
+	assert.Exactly(t, 0, n)
+
+	for _, test := range []struct {
+		p    cfgpath.Path
+		want string
+	}{
+		{pw, "s3cr3t"},
+		{pw.BindStore(2), "H3llo G0phers"},
+	} {
+		v, err := s.Get(test.p)
+		assert.NoError(t, err)
+		plain, err := m2.Decrypt([]byte(v.(string)))
+		assert.NoError(t, err)
+		assert.Exactly(t, test.want, string(plain))
+	}
+}
+
+func TestReencrypt_Error(t *testing.T) {
+	s := config.NewInMemoryStore()
+	assert.NoError(t, s.Set(cfgpath.MustNewByParts("payment/paypal/api_password"), "0:3:AAAA"))
+	m := cfgcrypt.MustNewM2(m2Key0, m2Key1)
+	n, err := cfgcrypt.Reencrypt(s, obscureSections, m, m)
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+	assert.Exactly(t, 0, n)
+}
+
+func TestM2_Obscure(t *testing.T) {
+	m := cfgcrypt.MustNewM2(m2Key0)
+	pw := cfgmodel.NewObscure("payment/paypal/api_password",
+		cfgmodel.WithEncrypter(m), cfgmodel.WithDecrypter(m),
+		cfgmodel.WithFieldFromSectionSlice(obscureSections),
+	)
+	srv := config.MustNewService(config.NewInMemoryStore())
+	assert.NoError(t, pw.Write(srv, []byte("s3cr3t"), scope.DefaultTypeID))
+
+	v, err := pw.Get(srv.NewScoped(0, 0))
+	assert.NoError(t, err)
+	assert.Exactly(t, "s3cr3t", string(v))
+}
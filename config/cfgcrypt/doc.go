@@ -0,0 +1,34 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cfgcrypt encrypts and decrypts configuration values compatible with
+// Magento 1 and Magento 2.
+//
+// The types M1 and M2 implement the interfaces cfgmodel.Encrypter and
+// cfgmodel.Decrypter and can be used with a cfgmodel.Obscure:
+//		keys, err := cfgcrypt.KeysFromEnvPHP(envPHPFile)
+//		m2, err := cfgcrypt.NewM2(keys...)
+//		password := cfgmodel.NewObscure("payment/paypal/api_password",
+//			cfgmodel.WithEncrypter(m2), cfgmodel.WithDecrypter(m2))
+//
+// M1 uses the mcrypt Blowfish cipher in ECB mode as Magento 1 does. M2 writes
+// the versioned format "<key version>:3:<base64>" using
+// ChaCha20-Poly1305 (IETF) like Magento 2 with libsodium. M2 can still read
+// values written with the old mcrypt Blowfish, Rijndael-128 and Rijndael-256
+// ciphers.
+//
+// Key rotation appends a new key and re-encrypts all obscured paths:
+//		err := m2.AddKey(newKey)
+//		n, err := cfgcrypt.Reencrypt(ccd.MustNewDBStorage(db), sections, m2, m2)
+package cfgcrypt
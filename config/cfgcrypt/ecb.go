@@ -0,0 +1,54 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgcrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// phpTrimCutset same characters as the PHP function trim() removes.
+const phpTrimCutset = " \t\n\r\x00\x0B"
+
+// ecbEncrypt encrypts the data in ECB mode with zero padding like mcrypt.
+func ecbEncrypt(b cipher.Block, data []byte) []byte {
+	bs := b.BlockSize()
+	n := len(data)
+	if r := n % bs; r != 0 {
+		n += bs - r
+	}
+	out := make([]byte, n)
+	copy(out, data)
+	for i := 0; i < n; i += bs {
+		b.Encrypt(out[i:i+bs], out[i:i+bs])
+	}
+	return out
+}
+
+// ecbDecrypt decrypts the data in ECB mode. The zero padding and white spaces
+// get trimmed like Magento does with PHP trim(). Error behaviour: NotValid.
+func ecbDecrypt(b cipher.Block, data []byte) ([]byte, error) {
+	bs := b.BlockSize()
+	if len(data)%bs != 0 {
+		return nil, errors.NewNotValidf("[cfgcrypt] Cipher text length %d is not a multiple of the block size %d", len(data), bs)
+	}
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += bs {
+		b.Decrypt(out[i:i+bs], data[i:i+bs])
+	}
+	return bytes.Trim(out, phpTrimCutset), nil
+}
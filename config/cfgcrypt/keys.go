@@ -0,0 +1,70 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgcrypt
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// KeyFromLocalXML extracts the crypt key from the Magento 1 file
+// app/etc/local.xml. Error behaviour: NotValid or NotFound.
+func KeyFromLocalXML(r io.Reader) (string, error) {
+	var lx struct {
+		Key string `xml:"global>crypt>key"`
+	}
+	if err := xml.NewDecoder(r).Decode(&lx); err != nil {
+		return "", errors.NewNotValidf("[cfgcrypt] KeyFromLocalXML.Decode: %s", err)
+	}
+	key := strings.TrimSpace(lx.Key)
+	if key == "" {
+		return "", errors.NewNotFoundf("[cfgcrypt] KeyFromLocalXML: crypt key not found")
+	}
+	return key, nil
+}
+
+// envPHPKey matches the crypt key in app/etc/env.php, for example:
+//		'crypt' => array ( 'key' => 'd9a1e8b0...', ),
+//		'crypt' => [ 'key' => 'd9a1e8b0...' ],
+var envPHPKey = regexp.MustCompile(`'crypt'\s*=>\s*(?:array\s*\(|\[)\s*'key'\s*=>\s*'([^']*)'`)
+
+// KeysFromEnvPHP extracts all crypt keys from the Magento 2 file
+// app/etc/env.php. Magento separates multiple keys by a new line, the index
+// of a key is its version. Error behaviour: Fatal or NotFound.
+func KeysFromEnvPHP(r io.Reader) ([]string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.NewFatalf("[cfgcrypt] KeysFromEnvPHP.ReadAll: %s", err)
+	}
+	m := envPHPKey.FindSubmatch(data)
+	if m == nil {
+		return nil, errors.NewNotFoundf("[cfgcrypt] KeysFromEnvPHP: crypt key not found")
+	}
+	var keys []string
+	for _, k := range strings.Split(string(m[1]), "\n") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.NewNotFoundf("[cfgcrypt] KeysFromEnvPHP: crypt key is empty")
+	}
+	return keys, nil
+}
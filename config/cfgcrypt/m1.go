@@ -0,0 +1,80 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgcrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/base64"
+
+	"github.com/corestoreio/csfw/util/errors"
+	"golang.org/x/crypto/blowfish"
+)
+
+// M1 encrypts and decrypts values like Mage_Core_Model_Encryption with the
+// mcrypt Blowfish cipher in ECB mode. The encrypted values are base64 encoded.
+type M1 struct {
+	block cipher.Block
+}
+
+// NewM1 creates a new M1 with the key from the app/etc/local.xml file. See
+// function KeyFromLocalXML. Error behaviour: NotValid.
+func NewM1(key string) (*M1, error) {
+	b, err := blowfish.NewCipher([]byte(key))
+	if err != nil {
+		return nil, errors.NewNotValidf("[cfgcrypt] NewM1.blowfish.NewCipher: %s", err)
+	}
+	return &M1{block: b}, nil
+}
+
+// MustNewM1 same as NewM1 but panics on error.
+func MustNewM1(key string) *M1 {
+	m, err := NewM1(key)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Encrypt encrypts the plain text and returns it base64 encoded.
+func (m *M1) Encrypt(plain []byte) ([]byte, error) {
+	ct := ecbEncrypt(m.block, plain)
+	out := make([]byte, base64.StdEncoding.EncodedLen(len(ct)))
+	base64.StdEncoding.Encode(out, ct)
+	return out, nil
+}
+
+// Decrypt decodes the base64 data and decrypts it. Same as Magento 1 all
+// null bytes get removed. Error behaviour: NotValid.
+func (m *M1) Decrypt(data []byte) ([]byte, error) {
+	ct, err := decodeBase64(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgcrypt] M1.Decrypt")
+	}
+	plain, err := ecbDecrypt(m.block, ct)
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgcrypt] M1.Decrypt")
+	}
+	return bytes.Replace(plain, []byte{0}, nil, -1), nil
+}
+
+func decodeBase64(data []byte) ([]byte, error) {
+	out := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(out, bytes.TrimSpace(data))
+	if err != nil {
+		return nil, errors.NewNotValidf("[cfgcrypt] Base64 decoding failed: %s", err)
+	}
+	return out[:n], nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/corestoreio/csfw/util/errors"
+	"golang.org/x/crypto/blowfish"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Cipher versions as defined in Magento\Framework\Encryption\Encryptor.
+const (
+	CipherBlowfish = iota
+	CipherRijndael128
+	CipherRijndael256
+	CipherChaCha20Poly1305
+)
+
+// M2 encrypts and decrypts values like Magento\Framework\Encryption\Encryptor.
+// M2 contains all keys from app/etc/env.php, the last key is the current key
+// for encryption. Older keys are needed to decrypt values which have not yet
+// been re-encrypted. M2 is safe for concurrent use.
+type M2 struct {
+	// Rand source for the nonce, default crypto/rand.Reader.
+	Rand io.Reader
+
+	mu   sync.RWMutex
+	keys [][]byte
+}
+
+// NewM2 creates a new M2 with the keys in the order of their version. See
+// function KeysFromEnvPHP. Error behaviour: Empty or NotValid.
+func NewM2(keys ...string) (*M2, error) {
+	if len(keys) == 0 {
+		return nil, errors.NewEmptyf("[cfgcrypt] NewM2 requires at least one key")
+	}
+	m := &M2{
+		Rand: rand.Reader,
+	}
+	for _, k := range keys {
+		if err := m.AddKey(k); err != nil {
+			return nil, errors.Wrap(err, "[cfgcrypt] NewM2")
+		}
+	}
+	return m, nil
+}
+
+// MustNewM2 same as NewM2 but panics on error.
+func MustNewM2(keys ...string) *M2 {
+	m, err := NewM2(keys...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// AddKey appends a new key which gets used for all further encryptions. The
+// key must have 32 bytes. Error behaviour: NotValid.
+func (m *M2) AddKey(key string) error {
+	if len(key) != chacha20poly1305.KeySize {
+		return errors.NewNotValidf("[cfgcrypt] Key must have %d bytes, got %d", chacha20poly1305.KeySize, len(key))
+	}
+	m.mu.Lock()
+	m.keys = append(m.keys, []byte(key))
+	m.mu.Unlock()
+	return nil
+}
+
+// KeyVersion returns the version of the current key.
+func (m *M2) KeyVersion() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.keys) - 1
+}
+
+func (m *M2) key(version int) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if version < 0 || version >= len(m.keys) {
+		return nil, errors.NewNotFoundf("[cfgcrypt] Key version %d not found", version)
+	}
+	return m.keys[version], nil
+}
+
+// Encrypt encrypts the plain text with ChaCha20-Poly1305 and the current key.
+// Returns the format "<key version>:3:<base64 of nonce and cipher text>".
+func (m *M2) Encrypt(plain []byte) ([]byte, error) {
+	version := m.KeyVersion()
+	key, err := m.key(version)
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgcrypt] M2.Encrypt")
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, errors.NewFatalf("[cfgcrypt] M2.Encrypt.chacha20poly1305.New: %s", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err := io.ReadFull(m.Rand, nonce); err != nil {
+		return nil, errors.NewFatalf("[cfgcrypt] M2.Encrypt.Rand: %s", err)
+	}
+	// Magento uses the nonce also as additional data.
+	ct := aead.Seal(nonce, nonce, plain, nonce)
+
+	var buf bytes.Buffer
+	buf.WriteString(strconv.Itoa(version))
+	buf.WriteString(":" + strconv.Itoa(CipherChaCha20Poly1305) + ":")
+	buf.WriteString(base64.StdEncoding.EncodeToString(ct))
+	return buf.Bytes(), nil
+}
+
+// Decrypt decrypts values of all cipher versions. Values without a key version
+// use the first key and values without a cipher version use Blowfish. The
+// legacy format "<key version>:2:<iv>:<base64 data>" uses Rijndael-256 in CBC
+// mode, without an initialization vector the IV contains only zero bytes.
+// Error behaviour: NotValid, NotFound or NotSupported.
+func (m *M2) Decrypt(data []byte) ([]byte, error) {
+	parts := bytes.SplitN(data, []byte{':'}, 4)
+	var keyVersion, cipherVersion = 0, CipherBlowfish
+	var iv []byte
+	var err error
+	switch len(parts) {
+	case 4:
+		if keyVersion, err = strconv.Atoi(string(parts[0])); err != nil {
+			return nil, errors.NewNotValidf("[cfgcrypt] M2.Decrypt: Invalid key version: %s", err)
+		}
+		if cipherVersion, err = strconv.Atoi(string(parts[1])); err != nil {
+			return nil, errors.NewNotValidf("[cfgcrypt] M2.Decrypt: Invalid cipher version: %s", err)
+		}
+		if cipherVersion != CipherRijndael256 {
+			return nil, errors.NewNotValidf("[cfgcrypt] M2.Decrypt: Initialization vector requires cipher version %d, got %d", CipherRijndael256, cipherVersion)
+		}
+		iv = parts[2]
+	case 3:
+		if keyVersion, err = strconv.Atoi(string(parts[0])); err != nil {
+			return nil, errors.NewNotValidf("[cfgcrypt] M2.Decrypt: Invalid key version: %s", err)
+		}
+		if cipherVersion, err = strconv.Atoi(string(parts[1])); err != nil {
+			return nil, errors.NewNotValidf("[cfgcrypt] M2.Decrypt: Invalid cipher version: %s", err)
+		}
+	case 2:
+		if cipherVersion, err = strconv.Atoi(string(parts[0])); err != nil {
+			return nil, errors.NewNotValidf("[cfgcrypt] M2.Decrypt: Invalid cipher version: %s", err)
+		}
+	}
+
+	key, err := m.key(keyVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgcrypt] M2.Decrypt")
+	}
+	ct, err := decodeBase64(parts[len(parts)-1])
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgcrypt] M2.Decrypt")
+	}
+
+	switch cipherVersion {
+	case CipherChaCha20Poly1305:
+		aead, err := chacha20poly1305.New(key)
+		if err != nil {
+			return nil, errors.NewFatalf("[cfgcrypt] M2.Decrypt.chacha20poly1305.New: %s", err)
+		}
+		if len(ct) < aead.NonceSize() {
+			return nil, errors.NewNotValidf("[cfgcrypt] M2.Decrypt: Cipher text too short")
+		}
+		nonce := ct[:aead.NonceSize()]
+		plain, err := aead.Open(nil, nonce, ct[aead.NonceSize():], nonce)
+		if err != nil {
+			return nil, errors.NewNotValidf("[cfgcrypt] M2.Decrypt: %s", err)
+		}
+		return plain, nil
+	case CipherBlowfish:
+		b, err := blowfish.NewCipher(key)
+		if err != nil {
+			return nil, errors.NewNotValidf("[cfgcrypt] M2.Decrypt.blowfish.NewCipher: %s", err)
+		}
+		plain, err := ecbDecrypt(b, ct)
+		return plain, errors.Wrap(err, "[cfgcrypt] M2.Decrypt.Blowfish")
+	case CipherRijndael128:
+		b, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, errors.NewNotValidf("[cfgcrypt] M2.Decrypt.aes.NewCipher: %s", err)
+		}
+		plain, err := ecbDecrypt(b, ct)
+		return plain, errors.Wrap(err, "[cfgcrypt] M2.Decrypt.Rijndael128")
+	case CipherRijndael256:
+		b, err := newRijndael(key, rijndael256BlockSize)
+		if err != nil {
+			return nil, errors.Wrap(err, "[cfgcrypt] M2.Decrypt.newRijndael")
+		}
+		if iv == nil {
+			iv = make([]byte, rijndael256BlockSize)
+		}
+		plain, err := cbcDecrypt(b, iv, ct)
+		return plain, errors.Wrap(err, "[cfgcrypt] M2.Decrypt.Rijndael256")
+	}
+	return nil, errors.NewNotSupportedf("[cfgcrypt] M2.Decrypt: Cipher version %d not supported", cipherVersion)
+}
@@ -0,0 +1,231 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgcrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// rijndael implements the Rijndael block cipher with a block size of 16, 24 or
+// 32 bytes. crypto/aes supports only the block size of 16 bytes but Magento
+// 2 before version 2.2 encrypted the values with mcrypt Rijndael-256, which
+// means a block size of 32 bytes. The implementation is byte oriented and
+// not optimized, it only decrypts the legacy values.
+type rijndael struct {
+	nb, nr int
+	// shifts contains the ShiftRows offsets of the rows 1 to 3.
+	shifts [4]int
+	// rk contains the expanded key as Nb*(Nr+1) words of four bytes.
+	rk [][4]byte
+}
+
+// rijndael256BlockSize defines the block size of mcrypt MCRYPT_RIJNDAEL_256.
+const rijndael256BlockSize = 32
+
+var rijndaelSbox, rijndaelInvSbox [256]byte
+
+func init() {
+	for i := 0; i < 256; i++ {
+		b := rijndaelInverse(byte(i))
+		s := b ^ rotl8(b, 1) ^ rotl8(b, 2) ^ rotl8(b, 3) ^ rotl8(b, 4) ^ 0x63
+		rijndaelSbox[i] = s
+		rijndaelInvSbox[s] = byte(i)
+	}
+}
+
+func rotl8(b byte, n uint) byte {
+	return b<<n | b>>(8-n)
+}
+
+// gmul multiplies two bytes in GF(2^8) with the Rijndael polynomial.
+func gmul(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 == 1 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// rijndaelInverse returns the multiplicative inverse in GF(2^8), zero maps to
+// zero.
+func rijndaelInverse(b byte) byte {
+	if b == 0 {
+		return 0
+	}
+	// b^254 == b^-1
+	r := byte(1)
+	for i := 0; i < 254; i++ {
+		r = gmul(r, b)
+	}
+	return r
+}
+
+// newRijndael creates a new Rijndael block cipher. The key must have 16, 24
+// or 32 bytes. Error behaviour: NotValid.
+func newRijndael(key []byte, blockSize int) (cipher.Block, error) {
+	nk := len(key) / 4
+	nb := blockSize / 4
+	if len(key)%4 != 0 || (nk != 4 && nk != 6 && nk != 8) {
+		return nil, errors.NewNotValidf("[cfgcrypt] Rijndael: Invalid key size %d", len(key))
+	}
+	if blockSize%4 != 0 || (nb != 4 && nb != 6 && nb != 8) {
+		return nil, errors.NewNotValidf("[cfgcrypt] Rijndael: Invalid block size %d", blockSize)
+	}
+
+	r := &rijndael{
+		nb:     nb,
+		nr:     nk + 6,
+		shifts: [4]int{0, 1, 2, 3},
+	}
+	if nb > nk {
+		r.nr = nb + 6
+	}
+	if nb == 8 {
+		r.shifts = [4]int{0, 1, 3, 4}
+	}
+
+	r.rk = make([][4]byte, nb*(r.nr+1))
+	rcon := byte(1)
+	for i := range r.rk {
+		if i < nk {
+			copy(r.rk[i][:], key[4*i:4*i+4])
+			continue
+		}
+		t := r.rk[i-1]
+		switch {
+		case i%nk == 0:
+			t = [4]byte{
+				rijndaelSbox[t[1]] ^ rcon,
+				rijndaelSbox[t[2]],
+				rijndaelSbox[t[3]],
+				rijndaelSbox[t[0]],
+			}
+			rcon = gmul(rcon, 2)
+		case nk > 6 && i%nk == 4:
+			for j := range t {
+				t[j] = rijndaelSbox[t[j]]
+			}
+		}
+		for j := range t {
+			r.rk[i][j] = r.rk[i-nk][j] ^ t[j]
+		}
+	}
+	return r, nil
+}
+
+// BlockSize returns the block size in bytes.
+func (r *rijndael) BlockSize() int { return 4 * r.nb }
+
+// addRoundKey XORs the round key into the state. The state contains the
+// columns one after another like the input block.
+func (r *rijndael) addRoundKey(s []byte, round int) {
+	for c := 0; c < r.nb; c++ {
+		w := r.rk[round*r.nb+c]
+		for row := 0; row < 4; row++ {
+			s[4*c+row] ^= w[row]
+		}
+	}
+}
+
+func (r *rijndael) shiftRows(s []byte, inverse bool) {
+	var tmp [32]byte
+	for row := 1; row < 4; row++ {
+		for c := 0; c < r.nb; c++ {
+			src := (c + r.shifts[row]) % r.nb
+			if inverse {
+				tmp[4*src+row] = s[4*c+row]
+			} else {
+				tmp[4*c+row] = s[4*src+row]
+			}
+		}
+		for c := 0; c < r.nb; c++ {
+			s[4*c+row] = tmp[4*c+row]
+		}
+	}
+}
+
+func (r *rijndael) mixColumns(s []byte, inverse bool) {
+	m := [4]byte{2, 3, 1, 1}
+	if inverse {
+		m = [4]byte{14, 11, 13, 9}
+	}
+	for c := 0; c < r.nb; c++ {
+		col := s[4*c : 4*c+4]
+		a := [4]byte{col[0], col[1], col[2], col[3]}
+		for row := 0; row < 4; row++ {
+			col[row] = gmul(a[0], m[(4-row)%4]) ^ gmul(a[1], m[(5-row)%4]) ^ gmul(a[2], m[(6-row)%4]) ^ gmul(a[3], m[(7-row)%4])
+		}
+	}
+}
+
+// Encrypt encrypts the first block of src into dst.
+func (r *rijndael) Encrypt(dst, src []byte) {
+	s := dst[:r.BlockSize()]
+	copy(s, src[:r.BlockSize()])
+	r.addRoundKey(s, 0)
+	for round := 1; round <= r.nr; round++ {
+		for i := range s {
+			s[i] = rijndaelSbox[s[i]]
+		}
+		r.shiftRows(s, false)
+		if round < r.nr {
+			r.mixColumns(s, false)
+		}
+		r.addRoundKey(s, round)
+	}
+}
+
+// Decrypt decrypts the first block of src into dst.
+func (r *rijndael) Decrypt(dst, src []byte) {
+	s := dst[:r.BlockSize()]
+	copy(s, src[:r.BlockSize()])
+	r.addRoundKey(s, r.nr)
+	for round := r.nr - 1; round >= 0; round-- {
+		r.shiftRows(s, true)
+		for i := range s {
+			s[i] = rijndaelInvSbox[s[i]]
+		}
+		r.addRoundKey(s, round)
+		if round > 0 {
+			r.mixColumns(s, true)
+		}
+	}
+}
+
+// cbcDecrypt decrypts the data in CBC mode. The zero padding and white spaces
+// get trimmed like in ecbDecrypt. Error behaviour: NotValid.
+func cbcDecrypt(b cipher.Block, iv, data []byte) ([]byte, error) {
+	bs := b.BlockSize()
+	if len(iv) != bs {
+		return nil, errors.NewNotValidf("[cfgcrypt] Initialization vector length %d does not match the block size %d", len(iv), bs)
+	}
+	if len(data)%bs != 0 {
+		return nil, errors.NewNotValidf("[cfgcrypt] Cipher text length %d is not a multiple of the block size %d", len(data), bs)
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(b, iv).CryptBlocks(out, data)
+	return bytes.Trim(out, phpTrimCutset), nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRijndael_AES(t *testing.T) {
+	key := []byte("Gophers are looking for 32 Bytes")
+	src := []byte("H3llo G0phers 16")
+	for _, keySize := range []int{16, 24, 32} {
+		a, err := aes.NewCipher(key[:keySize])
+		assert.NoError(t, err)
+		r, err := newRijndael(key[:keySize], aes.BlockSize)
+		assert.NoError(t, err)
+
+		want := make([]byte, aes.BlockSize)
+		have := make([]byte, aes.BlockSize)
+		a.Encrypt(want, src)
+		r.Encrypt(have, src)
+		assert.Exactly(t, want, have, "Key size %d", keySize)
+
+		r.Decrypt(have, have)
+		assert.Exactly(t, src, have, "Key size %d", keySize)
+	}
+}
+
+func TestRijndael256_CBC(t *testing.T) {
+	key := []byte("Gophers are looking for 32 Bytes")
+	iv := []byte("abcdefghijklmnopqrstuvwxyz012345")
+	r, err := newRijndael(key, rijndael256BlockSize)
+	assert.NoError(t, err)
+	assert.Exactly(t, rijndael256BlockSize, r.BlockSize())
+
+	src := make([]byte, 2*rijndael256BlockSize)
+	copy(src, "H3llo G0phers, padded with zero bytes")
+	ct := make([]byte, len(src))
+	cipher.NewCBCEncrypter(r, iv).CryptBlocks(ct, src)
+	assert.NotEqual(t, src, ct)
+
+	plain, err := cbcDecrypt(r, iv, ct)
+	assert.NoError(t, err)
+	assert.Exactly(t, "H3llo G0phers, padded with zero bytes", string(plain))
+
+	_, err = cbcDecrypt(r, iv[:16], ct)
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+	_, err = cbcDecrypt(r, iv, ct[:40])
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+}
+
+func TestNewRijndael_Error(t *testing.T) {
+	_, err := newRijndael([]byte("short"), rijndael256BlockSize)
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+	_, err = newRijndael(make([]byte, 32), 20)
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+}
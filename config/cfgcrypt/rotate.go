@@ -0,0 +1,101 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgcrypt
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// keyVersioner gets implemented by M2 and knows the version of the key used
+// for encryption.
+type keyVersioner interface {
+	KeyVersion() int
+}
+
+// isEncryptedWith reports whether the cipher text has already been encrypted
+// by enc with its current key. Only encrypters which implement KeyVersion, like
+// M2, can be detected.
+func isEncryptedWith(enc cfgmodel.Encrypter, cipherText []byte) bool {
+	kv, ok := enc.(keyVersioner)
+	if !ok {
+		return false
+	}
+	prefix := strconv.Itoa(kv.KeyVersion()) + ":" + strconv.Itoa(CipherChaCha20Poly1305) + ":"
+	return bytes.HasPrefix(cipherText, []byte(prefix))
+}
+
+// Reencrypt decrypts all values of the fields with type element.TypeObscure
+// and encrypts them again. Use it after adding a new key to M2, or to migrate
+// the values from M1 to M2. Empty values and values already encrypted with the
+// current key of an M2 encrypter get skipped. Each value gets written on its
+// own, so an interrupted run leaves some values rotated. Reencrypt can
+// therefore safely be run again until it succeeds. Returns the number of
+// re-encrypted values.
+func Reencrypt(s config.Storager, sections element.SectionSlice, dec cfgmodel.Decrypter, enc cfgmodel.Encrypter) (int, error) {
+	keys, err := s.AllKeys()
+	if err != nil {
+		return 0, errors.Wrap(err, "[cfgcrypt] Reencrypt.AllKeys")
+	}
+
+	var n int
+	for _, p := range keys {
+		f, _, err := sections.FindField(p.Route)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return n, errors.Wrapf(err, "[cfgcrypt] Reencrypt.FindField Route %q", p.Route)
+		}
+		if f.Type == nil || f.Type.Type() != element.TypeObscure {
+			continue
+		}
+
+		v, err := s.Get(p)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return n, errors.Wrapf(err, "[cfgcrypt] Reencrypt.Get Path %q", p)
+		}
+		cipherText, err := conv.ToByteE(v)
+		if err != nil {
+			return n, errors.Wrapf(err, "[cfgcrypt] Reencrypt.ToByte Path %q", p)
+		}
+		if len(cipherText) == 0 || isEncryptedWith(enc, cipherText) {
+			continue
+		}
+
+		plain, err := dec.Decrypt(cipherText)
+		if err != nil {
+			return n, errors.Wrapf(err, "[cfgcrypt] Reencrypt.Decrypt Path %q", p)
+		}
+		cipherText, err = enc.Encrypt(plain)
+		if err != nil {
+			return n, errors.Wrapf(err, "[cfgcrypt] Reencrypt.Encrypt Path %q", p)
+		}
+		if err := s.Set(p, string(cipherText)); err != nil {
+			return n, errors.Wrapf(err, "[cfgcrypt] Reencrypt.Set Path %q", p)
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgfile_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgfile"
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newResolver() cfgfile.CodeResolver {
+	return cfgfile.NewStoreResolver(storemock.NewEurozzyService(cfgmock.NewService()))
+}
+
+func newStorage(t *testing.T) config.Storager {
+	s := config.NewInMemoryStore()
+	for _, pv := range []struct {
+		p cfgpath.Path
+		v interface{}
+	}{
+		{cfgpath.MustNewByParts("web/secure/base_url"), "https://example.com/"},
+		{cfgpath.MustNewByParts("general/locale/code").BindWebsite(1), "de_DE"},
+		{cfgpath.MustNewByParts("general/locale/code").BindStore(4), "en_GB"},
+		{cfgpath.MustNewByParts("general/locale/timezone").BindStore(2), "Europe/Vienna"},
+		{cfgpath.MustNewByParts("web/cookie/cookie_lifetime"), 3600},
+	} {
+		if err := s.Set(pv.p, pv.v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestExport_Encode(t *testing.T) {
+	tree, err := cfgfile.Export(newStorage(t), newResolver())
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tree.Encode(&buf, cfgfile.YAML))
+	assert.Exactly(t, `default:
+  web/cookie/cookie_lifetime: "3600"
+  web/secure/base_url: https://example.com/
+websites:
+  euro:
+    general/locale/code: de_DE
+stores:
+  at:
+    general/locale/timezone: Europe/Vienna
+  uk:
+    general/locale/code: en_GB
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, tree.Encode(&buf, cfgfile.JSON))
+	have, err := cfgfile.Decode(&buf, cfgfile.JSON)
+	assert.NoError(t, err)
+	assert.Exactly(t, tree, have)
+}
+
+func TestExport_UnknownStore(t *testing.T) {
+	s := config.NewInMemoryStore()
+	assert.NoError(t, s.Set(cfgpath.MustNewByParts("aa/bb/cc").BindStore(99), 1))
+	_, err := cfgfile.Export(s, newResolver())
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+}
+
+func TestLoadFiles_Apply(t *testing.T) {
+	tree, err := cfgfile.LoadFiles("testdata/base.yaml", "testdata/prod.json")
+	assert.NoError(t, err)
+
+	srv := config.MustNewService(config.NewInMemoryStore())
+	n, err := cfgfile.Apply(srv, tree, newResolver())
+	assert.NoError(t, err)
+	assert.Exactly(t, 6, n)
+
+	tests := []struct {
+		p    cfgpath.Path
+		want string
+	}{
+		{cfgpath.MustNewByParts("web/secure/base_url"), "https://shop.example.com/"},
+		{cfgpath.MustNewByParts("web/secure/base_url").BindStore(4), "https://shop.example.co.uk/"},
+		{cfgpath.MustNewByParts("web/cookie/cookie_lifetime"), "3600"},
+		{cfgpath.MustNewByParts("general/locale/code").BindWebsite(1), "de_DE"},
+		{cfgpath.MustNewByParts("general/locale/code").BindStore(4), "en_GB"},
+		{cfgpath.MustNewByParts("general/locale/timezone").BindStore(2), "Europe/Vienna"},
+	}
+	for i, test := range tests {
+		have, err := srv.String(test.p)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
+
+func TestApply_UnknownCode(t *testing.T) {
+	tree := cfgfile.NewTree()
+	tree.Default["aa/bb/cc"] = 1
+	tree.Stores["xx"] = cfgfile.Values{"aa/bb/cc": 2}
+
+	srv := config.MustNewService(config.NewInMemoryStore())
+	n, err := cfgfile.Apply(srv, tree, newResolver())
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	assert.Exactly(t, 0, n)
+	assert.False(t, srv.IsSet(cfgpath.MustNewByParts("aa/bb/cc")), "nothing must be written")
+}
+
+func TestDiff(t *testing.T) {
+	overlay, err := cfgfile.LoadFile("testdata/prod.json")
+	assert.NoError(t, err)
+
+	d, err := cfgfile.Diff(newStorage(t), overlay, newResolver())
+	assert.NoError(t, err)
+	assert.Contains(t, d, "-  web/secure/base_url: https://example.com/\n")
+	assert.Contains(t, d, "+  web/secure/base_url: https://shop.example.com/\n")
+	assert.Contains(t, d, "+    web/secure/base_url: https://shop.example.co.uk/\n")
+	assert.Exactly(t, 3, strings.Count(d, "\n+ ")+strings.Count(d, "\n- "), "Diff:\n%s", d)
+
+	base, err := cfgfile.LoadFile("testdata/base.yaml")
+	assert.NoError(t, err)
+	d, err = cfgfile.Diff(newStorage(t), base, newResolver())
+	assert.NoError(t, err)
+	assert.Empty(t, d)
+}
+
+func TestFormatByFilename(t *testing.T) {
+	_, err := cfgfile.FormatByFilename("config.xml")
+	assert.True(t, errors.IsNotSupported(err), "Error: %s", err)
+	_, err = cfgfile.LoadFile("testdata/base.yml")
+	assert.Error(t, err)
+	_, err = cfgfile.Decode(strings.NewReader("default: [1"), cfgfile.YAML)
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+}
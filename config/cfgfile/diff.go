@@ -0,0 +1,83 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgfile
+
+import (
+	"bytes"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/diff"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// normalize returns a copy of the Tree with all values converted to strings.
+func (t *Tree) normalize() (*Tree, error) {
+	n := NewTree()
+	toStrings := func(vs Values) (Values, error) {
+		ret := make(Values, len(vs))
+		for r, v := range vs {
+			s, err := conv.ToStringE(v)
+			if err != nil {
+				return nil, errors.NewNotValidf("[cfgfile] Route %q: %s", r, err)
+			}
+			ret[r] = s
+		}
+		return ret, nil
+	}
+	var err error
+	if n.Default, err = toStrings(t.Default); err != nil {
+		return nil, errors.Wrap(err, "[cfgfile] normalize.Default")
+	}
+	for code, vs := range t.Websites {
+		if n.Websites[code], err = toStrings(vs); err != nil {
+			return nil, errors.Wrapf(err, "[cfgfile] normalize.Website %q", code)
+		}
+	}
+	for code, vs := range t.Stores {
+		if n.Stores[code], err = toStrings(vs); err != nil {
+			return nil, errors.Wrapf(err, "[cfgfile] normalize.Store %q", code)
+		}
+	}
+	return n, nil
+}
+
+// Diff is the dry-run of Apply. It returns the unified diff in YAML format
+// between the current values of the Storager and the values after applying
+// the Tree. An empty string means that Apply won't change anything.
+func Diff(src config.Storager, t *Tree, cr CodeResolver) (string, error) {
+	// check that all codes can be resolved, same as in Apply
+	if _, err := t.paths(cr); err != nil {
+		return "", errors.Wrap(err, "[cfgfile] Diff")
+	}
+	current, err := Export(src, cr)
+	if err != nil {
+		return "", errors.Wrap(err, "[cfgfile] Diff.Export")
+	}
+	nt, err := t.normalize()
+	if err != nil {
+		return "", errors.Wrap(err, "[cfgfile] Diff.normalize")
+	}
+
+	var before, after bytes.Buffer
+	if err := current.Encode(&before, YAML); err != nil {
+		return "", errors.Wrap(err, "[cfgfile] Diff.Encode")
+	}
+	if err := Merge(Merge(nil, current), nt).Encode(&after, YAML); err != nil {
+		return "", errors.Wrap(err, "[cfgfile] Diff.Encode")
+	}
+	d, err := diff.Unified(before.String(), after.String())
+	return d, errors.NewFatal(err, "[cfgfile] Diff.Unified")
+}
@@ -0,0 +1,36 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cfgfile imports and exports configuration values as YAML or JSON
+// files.
+//
+// All values of a config.Storager get grouped into a Tree by their scope. The
+// IDs of the websites and stores get replaced by their codes, so the same file
+// can be used for dev, staging and production even if the IDs differ:
+//		default:
+//		  web/secure/base_url: https://example.com/
+//		websites:
+//		  euro:
+//		    general/locale/code: de_DE
+//		stores:
+//		  at:
+//		    general/locale/timezone: Europe/Vienna
+//
+// Overlays for an environment get merged on top of a base file with
+// LoadFiles or Merge. Diff shows the changes before Apply writes them:
+//		tree, err := cfgfile.LoadFiles("config.yaml", "config.prod.yaml")
+//		cr := cfgfile.NewStoreResolver(storeService)
+//		d, err := cfgfile.Diff(backend, tree, cr) // dry-run
+//		n, err := cfgfile.Apply(configService, tree, cr)
+package cfgfile
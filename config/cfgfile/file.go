@@ -0,0 +1,122 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgfile
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/corestoreio/csfw/util/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Format defines the encoding of a file.
+type Format uint8
+
+// Supported file formats.
+const (
+	YAML Format = iota + 1
+	JSON
+)
+
+// FormatByFilename detects the format by the file extension: .yaml, .yml or
+// .json. Error behaviour: NotSupported.
+func FormatByFilename(name string) (Format, error) {
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml":
+		return YAML, nil
+	case ".json":
+		return JSON, nil
+	}
+	return 0, errors.NewNotSupportedf("[cfgfile] Unknown file extension of %q", name)
+}
+
+// Decode reads a Tree in the provided format. Error behaviour: NotValid or
+// NotSupported.
+func Decode(r io.Reader, f Format) (*Tree, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgfile] Decode.ReadAll")
+	}
+	t := NewTree()
+	switch f {
+	case YAML:
+		err = yaml.Unmarshal(data, t)
+	case JSON:
+		err = json.Unmarshal(data, t)
+	default:
+		return nil, errors.NewNotSupportedf("[cfgfile] Unknown Format %d", f)
+	}
+	if err != nil {
+		return nil, errors.NewNotValidf("[cfgfile] Decode: %s", err)
+	}
+	return t, nil
+}
+
+// Encode writes the Tree in the provided format. Routes and codes are sorted.
+// Error behaviour: NotSupported.
+func (t *Tree) Encode(w io.Writer, f Format) error {
+	var data []byte
+	var err error
+	switch f {
+	case YAML:
+		data, err = yaml.Marshal(t)
+	case JSON:
+		data, err = json.MarshalIndent(t, "", "  ")
+		data = append(data, '\n')
+	default:
+		return errors.NewNotSupportedf("[cfgfile] Unknown Format %d", f)
+	}
+	if err != nil {
+		return errors.NewFatalf("[cfgfile] Encode: %s", err)
+	}
+	_, err = w.Write(data)
+	return errors.Wrap(err, "[cfgfile] Encode.Write")
+}
+
+// LoadFile reads a YAML or JSON file.
+func LoadFile(name string) (*Tree, error) {
+	f, err := FormatByFilename(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgfile] LoadFile")
+	}
+	fh, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[cfgfile] LoadFile.Open %q", name)
+	}
+	defer fh.Close()
+	t, err := Decode(fh, f)
+	return t, errors.Wrapf(err, "[cfgfile] LoadFile %q", name)
+}
+
+// LoadFiles reads the base file and merges the overlay files in the provided
+// order on top of it. The files can have different formats.
+func LoadFiles(base string, overlays ...string) (*Tree, error) {
+	t, err := LoadFile(base)
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgfile] LoadFiles.Base")
+	}
+	for _, name := range overlays {
+		o, err := LoadFile(name)
+		if err != nil {
+			return nil, errors.Wrap(err, "[cfgfile] LoadFiles.Overlay")
+		}
+		Merge(t, o)
+	}
+	return t, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgfile
+
+import (
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// CodeResolver maps the IDs of websites and stores to their codes and back.
+// Error behaviour: NotFound.
+type CodeResolver interface {
+	WebsiteCode(id int64) (string, error)
+	WebsiteID(code string) (int64, error)
+	StoreCode(id int64) (string, error)
+	StoreID(code string) (int64, error)
+}
+
+type storeResolver struct {
+	srv *store.Service
+}
+
+// NewStoreResolver creates a CodeResolver which uses the websites and stores
+// of the store.Service.
+func NewStoreResolver(srv *store.Service) CodeResolver {
+	return storeResolver{srv: srv}
+}
+
+func (sr storeResolver) WebsiteCode(id int64) (string, error) {
+	w, err := sr.srv.Website(id)
+	if err != nil {
+		return "", errors.Wrapf(err, "[cfgfile] WebsiteCode ID %d", id)
+	}
+	return w.Code(), nil
+}
+
+func (sr storeResolver) WebsiteID(code string) (int64, error) {
+	for _, w := range sr.srv.Websites() {
+		if w.Code() == code {
+			return w.ID(), nil
+		}
+	}
+	return 0, errors.NewNotFoundf("[cfgfile] Website code %q not found", code)
+}
+
+func (sr storeResolver) StoreCode(id int64) (string, error) {
+	s, err := sr.srv.Store(id)
+	if err != nil {
+		return "", errors.Wrapf(err, "[cfgfile] StoreCode ID %d", id)
+	}
+	return s.Code(), nil
+}
+
+func (sr storeResolver) StoreID(code string) (int64, error) {
+	s, ok := sr.srv.Stores().FindOne(func(s store.Store) bool {
+		return s.Code() == code
+	})
+	if !ok {
+		return 0, errors.NewNotFoundf("[cfgfile] Store code %q not found", code)
+	}
+	return s.ID(), nil
+}
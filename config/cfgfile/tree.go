@@ -0,0 +1,216 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgfile
+
+import (
+	"sort"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// Values maps a route, like "web/secure/base_url", to its value.
+type Values map[string]interface{}
+
+// Tree contains all configuration values grouped by scope. Websites and
+// stores are keyed by their codes.
+type Tree struct {
+	Default  Values            `json:"default,omitempty" yaml:"default,omitempty"`
+	Websites map[string]Values `json:"websites,omitempty" yaml:"websites,omitempty"`
+	Stores   map[string]Values `json:"stores,omitempty" yaml:"stores,omitempty"`
+}
+
+// NewTree creates a new empty Tree.
+func NewTree() *Tree {
+	return &Tree{
+		Default:  make(Values),
+		Websites: make(map[string]Values),
+		Stores:   make(map[string]Values),
+	}
+}
+
+// set adds a value to the scope with the code. Code is empty for the default
+// scope.
+func (t *Tree) set(scp scope.Type, code, route string, v interface{}) {
+	var vs Values
+	switch scp {
+	case scope.Website:
+		if t.Websites == nil {
+			t.Websites = make(map[string]Values)
+		}
+		if vs = t.Websites[code]; vs == nil {
+			vs = make(Values)
+			t.Websites[code] = vs
+		}
+	case scope.Store:
+		if t.Stores == nil {
+			t.Stores = make(map[string]Values)
+		}
+		if vs = t.Stores[code]; vs == nil {
+			vs = make(Values)
+			t.Stores[code] = vs
+		}
+	default:
+		if t.Default == nil {
+			t.Default = make(Values)
+		}
+		vs = t.Default
+	}
+	vs[route] = v
+}
+
+// Merge copies all values of the overlays into the base Tree. Values of later
+// overlays overwrite the values of earlier ones. Returns the base Tree.
+func Merge(base *Tree, overlays ...*Tree) *Tree {
+	if base == nil {
+		base = NewTree()
+	}
+	for _, o := range overlays {
+		if o == nil {
+			continue
+		}
+		for r, v := range o.Default {
+			base.set(scope.Default, "", r, v)
+		}
+		for code, vs := range o.Websites {
+			for r, v := range vs {
+				base.set(scope.Website, code, r, v)
+			}
+		}
+		for code, vs := range o.Stores {
+			for r, v := range vs {
+				base.set(scope.Store, code, r, v)
+			}
+		}
+	}
+	return base
+}
+
+// Export reads all values from the Storager and converts them into a Tree.
+// The values get converted to strings like in table core_config_data. Paths
+// with a nil value get skipped.
+func Export(src config.Storager, cr CodeResolver) (*Tree, error) {
+	keys, err := src.AllKeys()
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgfile] Export.AllKeys")
+	}
+	t := NewTree()
+	for _, p := range keys {
+		v, err := src.Get(p)
+		if errors.IsNotFound(err) || (err == nil && v == nil) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "[cfgfile] Export.Get Path %q", p)
+		}
+		s, err := conv.ToStringE(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[cfgfile] Export.conv.ToStringE Path %q", p)
+		}
+
+		scp, id := p.ScopeID.Unpack()
+		var code string
+		switch scp {
+		case scope.Website:
+			code, err = cr.WebsiteCode(id)
+		case scope.Store:
+			code, err = cr.StoreCode(id)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "[cfgfile] Export Path %q", p)
+		}
+		t.set(scp, code, p.Route.String(), s)
+	}
+	return t, nil
+}
+
+// pathValue a path with its value.
+type pathValue struct {
+	path  cfgpath.Path
+	value interface{}
+}
+
+// paths resolves the codes into IDs and returns all paths of the Tree sorted
+// by default, websites and stores.
+func (t *Tree) paths(cr CodeResolver) ([]pathValue, error) {
+	var ret []pathValue
+	add := func(id scope.TypeID, vs Values) error {
+		routes := make([]string, 0, len(vs))
+		for r := range vs {
+			routes = append(routes, r)
+		}
+		sort.Strings(routes)
+		for _, r := range routes {
+			p, err := cfgpath.NewByParts(r)
+			if err != nil {
+				return errors.Wrapf(err, "[cfgfile] Route %q", r)
+			}
+			ret = append(ret, pathValue{path: p.Bind(id), value: vs[r]})
+		}
+		return nil
+	}
+
+	if err := add(scope.DefaultTypeID, t.Default); err != nil {
+		return nil, errors.Wrap(err, "[cfgfile] Tree.paths.Default")
+	}
+	for _, code := range sortedCodes(t.Websites) {
+		id, err := cr.WebsiteID(code)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[cfgfile] Tree.paths.WebsiteID %q", code)
+		}
+		if err := add(scope.Website.Pack(id), t.Websites[code]); err != nil {
+			return nil, errors.Wrapf(err, "[cfgfile] Tree.paths.Website %q", code)
+		}
+	}
+	for _, code := range sortedCodes(t.Stores) {
+		id, err := cr.StoreID(code)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[cfgfile] Tree.paths.StoreID %q", code)
+		}
+		if err := add(scope.Store.Pack(id), t.Stores[code]); err != nil {
+			return nil, errors.Wrapf(err, "[cfgfile] Tree.paths.Store %q", code)
+		}
+	}
+	return ret, nil
+}
+
+func sortedCodes(m map[string]Values) []string {
+	codes := make([]string, 0, len(m))
+	for c := range m {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Apply writes all values of the Tree into the config.Writer, mostly the
+// config.Service. The codes of websites and stores get resolved into their
+// IDs before the first value gets written. Returns the number of written
+// values.
+func Apply(w config.Writer, t *Tree, cr CodeResolver) (int, error) {
+	pvs, err := t.paths(cr)
+	if err != nil {
+		return 0, errors.Wrap(err, "[cfgfile] Apply")
+	}
+	for i, pv := range pvs {
+		if err := w.Write(pv.path, pv.value); err != nil {
+			return i, errors.Wrapf(err, "[cfgfile] Apply.Write Path %q", pv.path)
+		}
+	}
+	return len(pvs), nil
+}
@@ -15,7 +15,6 @@
 package cfgmodel
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/corestoreio/csfw/config"
@@ -298,41 +297,29 @@ func (bv baseValue) IsSet() bool {
 
 // ValidateString checks if string v is contained in Source cfgsource.Slice.
 // Error behaviour: NotValid
-func (bv baseValue) ValidateString(v string) (err error) {
-	if bv.Source != nil && false == bv.Source.ContainsValString(v) {
-		jv, jErr := bv.Source.ToJSON()
-		if jErr != nil {
-			return errors.NewFatal(err, fmt.Sprintf("[cfgmodel] Source: %#v", bv.Source))
-		}
-		err = errors.NewNotValidf(errValueNotFoundInOptions, v, jv)
+func (bv baseValue) ValidateString(v string) error {
+	if bv.Source == nil {
+		return nil
 	}
-	return
+	return errors.Wrap(bv.Source.ValidateString(v), "[cfgmodel] ValidateString")
 }
 
 // ValidateInt checks if int v is contained in non-nil Source cfgsource.Slice.
 // Error behaviour: NotValid
-func (bv baseValue) ValidateInt(v int) (err error) {
-	if bv.Source != nil && false == bv.Source.ContainsValInt(v) {
-		jv, jErr := bv.Source.ToJSON()
-		if jErr != nil {
-			return errors.NewFatal(err, fmt.Sprintf("[cfgmodel] Source: %#v", bv.Source))
-		}
-		err = errors.NewNotValidf("[cfgmodel] The value '%d' cannot be found within the allowed Options():\n%s", v, jv)
+func (bv baseValue) ValidateInt(v int) error {
+	if bv.Source == nil {
+		return nil
 	}
-	return
+	return errors.Wrap(bv.Source.ValidateInt(v), "[cfgmodel] ValidateInt")
 }
 
 // ValidateFloat64 checks if float64 v is contained in non-nil Source cfgsource.Slice.
 // Error behaviour: NotValid
-func (bv baseValue) ValidateFloat64(v float64) (err error) {
-	if bv.Source != nil && false == bv.Source.ContainsValFloat64(v) {
-		jv, jErr := bv.Source.ToJSON()
-		if jErr != nil {
-			return errors.NewFatal(err, fmt.Sprintf("[cfgmodel] Source: %#v", bv.Source))
-		}
-		err = errors.NewNotValidf("[cfgmodel] The value '%.14f' cannot be found within the allowed Options():\n%s", v, jv)
+func (bv baseValue) ValidateFloat64(v float64) error {
+	if bv.Source == nil {
+		return nil
 	}
-	return
+	return errors.Wrap(bv.Source.ValidateFloat64(v), "[cfgmodel] ValidateFloat64")
 }
 
 // ValidateTime checks if time.Time v is contained in non-nil Source cfgsource.Slice.
@@ -0,0 +1,222 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgmodel
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/corestoreio/csfw/util/php/phpserialize"
+)
+
+// SerializeFormat defines how Magento stores an array in a configuration
+// value.
+type SerializeFormat uint8
+
+// Supported serialization formats.
+const (
+	// SerializeJSON used by Magento >= 2.2.
+	SerializeJSON SerializeFormat = iota
+	// SerializePHP used by Magento 1 and Magento 2 < 2.2.
+	SerializePHP
+)
+
+// String returns the name of the format.
+func (f SerializeFormat) String() string {
+	if f == SerializePHP {
+		return "PHP"
+	}
+	return "JSON"
+}
+
+// SerializeFormatByVersion returns the format which the Magento version, like
+// 1.9.3.1 or 2.1.8, expects. Error behaviour: NotValid.
+func SerializeFormatByVersion(version string) (SerializeFormat, error) {
+	parts := strings.SplitN(strings.TrimSpace(version), ".", 3)
+	if len(parts) < 2 {
+		return 0, errors.NewNotValidf("[cfgmodel] Invalid Magento version %q", version)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, errors.NewNotValidf("[cfgmodel] Invalid major Magento version %q: %s", version, err)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, errors.NewNotValidf("[cfgmodel] Invalid minor Magento version %q: %s", version, err)
+	}
+	if major > 2 || (major == 2 && minor >= 2) {
+		return SerializeJSON, nil
+	}
+	return SerializePHP, nil
+}
+
+// Serializer implements the Encoder and Decoder interfaces for arrays stored
+// by Magento. Decode detects the format of the data and can read JSON and
+// PHP serialized values. Encode writes the data in the Format.
+//
+// PHP arrays with the keys 0 to n-1 become JSON arrays, all other PHP arrays
+// become JSON objects. The decoding into the target type uses the JSON rules
+// and struct tags. Magento stores numbers mostly as strings, so numeric struct
+// fields require the ",string" option in the JSON struct tag.
+type Serializer struct {
+	Format SerializeFormat
+}
+
+// Encode serializes the value v in the Format. Error behaviour: NotValid.
+func (s Serializer) Encode(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.NewNotValidf("[cfgmodel] Serializer.Encode.json.Marshal: %s", err)
+	}
+	if s.Format == SerializeJSON {
+		return data, nil
+	}
+
+	var jv interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&jv); err != nil {
+		return nil, errors.NewNotValidf("[cfgmodel] Serializer.Encode.json.Decode: %s", err)
+	}
+	str, err := phpserialize.Serialize(toPHP(jv))
+	if err != nil {
+		return nil, errors.NewNotValidf("[cfgmodel] Serializer.Encode.phpserialize.Serialize: %s", err)
+	}
+	return []byte(str), nil
+}
+
+// Decode unserializes JSON or PHP serialized data into the pointer vPtr. Empty
+// data leaves vPtr untouched. Error behaviour: NotValid.
+func (s Serializer) Decode(data []byte, vPtr interface{}) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+	if isPHPSerialized(data) {
+		pv, err := phpserialize.UnSerialize(data)
+		if err != nil {
+			return errors.NewNotValidf("[cfgmodel] Serializer.Decode.phpserialize.UnSerialize: %s", err)
+		}
+		if data, err = json.Marshal(fromPHP(pv)); err != nil {
+			return errors.NewNotValidf("[cfgmodel] Serializer.Decode.json.Marshal: %s", err)
+		}
+	}
+	if err := json.Unmarshal(data, vPtr); err != nil {
+		return errors.NewNotValidf("[cfgmodel] Serializer.Decode.json.Unmarshal: %s", err)
+	}
+	return nil
+}
+
+// isPHPSerialized checks for the type token of PHP serialized data, for
+// example a:1:{...} or s:3:"foo";
+func isPHPSerialized(data []byte) bool {
+	if len(data) < 2 {
+		return false
+	}
+	if data[0] == phpserialize.TokeNull && data[1] == phpserialize.SeparatorValues {
+		return true
+	}
+	return data[1] == phpserialize.SepratorValueTypes
+}
+
+// fromPHP converts an unserialized PHP value into a value which the JSON
+// encoder understands.
+func fromPHP(pv phpserialize.PhpValue) interface{} {
+	switch t := pv.(type) {
+	case phpserialize.PhpArray:
+		if isPHPList(t) {
+			ret := make([]interface{}, len(t))
+			for k, v := range t {
+				ret[k.(int)] = fromPHP(v)
+			}
+			return ret
+		}
+		ret := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			ret[conv.ToString(k)] = fromPHP(v)
+		}
+		return ret
+	case phpserialize.PhpSlice:
+		ret := make([]interface{}, len(t))
+		for i, v := range t {
+			ret[i] = fromPHP(v)
+		}
+		return ret
+	case *phpserialize.PhpObject:
+		return fromPHP(t.GetMembers())
+	}
+	return pv
+}
+
+// isPHPList returns true if the array has only the integer keys 0 to n-1. An
+// empty array is a list, same as json_encode() does.
+func isPHPList(arr phpserialize.PhpArray) bool {
+	for k := range arr {
+		i, ok := k.(int)
+		if !ok || i < 0 || i >= len(arr) {
+			return false
+		}
+	}
+	return true
+}
+
+// toPHP converts a decoded JSON value into a PHP value. Numeric object keys
+// become integer keys like PHP does.
+func toPHP(jv interface{}) phpserialize.PhpValue {
+	switch t := jv.(type) {
+	case map[string]interface{}:
+		ret := make(phpserialize.PhpArray, len(t))
+		for k, v := range t {
+			var key phpserialize.PhpValue = k
+			if i, err := strconv.Atoi(k); err == nil && strconv.Itoa(i) == k {
+				key = i
+			}
+			ret[key] = toPHP(v)
+		}
+		return ret
+	case []interface{}:
+		ret := make(phpserialize.PhpSlice, len(t))
+		for i, v := range t {
+			ret[i] = toPHP(v)
+		}
+		return ret
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return int(i)
+		}
+		f, _ := t.Float64()
+		return f
+	}
+	return jv
+}
+
+// NewSerialized creates a new Encode for arrays stored by Magento, for example
+// the design exceptions in design/theme/ua_regexp. It reads JSON and PHP
+// serialized values and writes them in the format f. See function
+// SerializeFormatByVersion.
+//		uaRegexp := cfgmodel.NewSerialized("design/theme/ua_regexp", cfgmodel.SerializePHP)
+//		var rows map[string]struct {
+//			Regexp string `json:"regexp"`
+//			Value  string `json:"value"`
+//		}
+//		err := uaRegexp.Get(sg, &rows)
+func NewSerialized(path string, f SerializeFormat, opts ...Option) Encode {
+	s := Serializer{Format: f}
+	return NewEncode(path, append([]Option{WithEncoder(s), WithDecoder(s)}, opts...)...)
+}
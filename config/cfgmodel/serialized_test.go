@@ -0,0 +1,143 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgmodel_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ cfgmodel.Encoder = (*cfgmodel.Serializer)(nil)
+var _ cfgmodel.Decoder = (*cfgmodel.Serializer)(nil)
+
+type uaRegexpRow struct {
+	Regexp string `json:"regexp"`
+	Value  string `json:"value"`
+}
+
+func TestSerialized_Get(t *testing.T) {
+	const cfgPath = "design/theme/ua_regexp"
+	wantPath := cfgpath.MustNewByParts(cfgPath).String()
+	wantRows := map[string]uaRegexpRow{
+		"_1490000000000_123": {Regexp: "iPhone", Value: "mobile"},
+		"_1490000000001_456": {Regexp: "Android", Value: "mobile"},
+	}
+
+	tests := []struct {
+		raw string
+	}{
+		{`a:2:{s:18:"_1490000000000_123";a:2:{s:6:"regexp";s:6:"iPhone";s:5:"value";s:6:"mobile";}s:18:"_1490000000001_456";a:2:{s:6:"regexp";s:7:"Android";s:5:"value";s:6:"mobile";}}`},
+		{`{"_1490000000000_123":{"regexp":"iPhone","value":"mobile"},"_1490000000001_456":{"regexp":"Android","value":"mobile"}}`},
+	}
+	for i, test := range tests {
+		for _, f := range []cfgmodel.SerializeFormat{cfgmodel.SerializeJSON, cfgmodel.SerializePHP} {
+			b := cfgmodel.NewSerialized(cfgPath, f, cfgmodel.WithScopeStore())
+			var haveRows map[string]uaRegexpRow
+			err := b.Get(cfgmock.NewService(cfgmock.PathValue{
+				wantPath: []byte(test.raw),
+			}).NewScoped(1, 1), &haveRows)
+			assert.NoError(t, err, "Index %d Format %s", i, f)
+			assert.Exactly(t, wantRows, haveRows, "Index %d Format %s", i, f)
+		}
+	}
+}
+
+func TestSerialized_Write(t *testing.T) {
+	const cfgPath = "cataloginventory/item_options/min_sale_qty"
+	minSaleQty := map[int]string{32000: "2", 1: "5.5"}
+
+	tests := []struct {
+		format cfgmodel.SerializeFormat
+		want   string
+	}{
+		{cfgmodel.SerializePHP, `a:2:{i:1;s:3:"5.5";i:32000;s:1:"2";}`},
+		{cfgmodel.SerializeJSON, `{"1":"5.5","32000":"2"}`},
+	}
+	for i, test := range tests {
+		b := cfgmodel.NewSerialized(cfgPath, test.format, cfgmodel.WithScopeStore())
+		mw := new(cfgmock.Write)
+		assert.NoError(t, b.Write(mw, minSaleQty, scope.Website.Pack(2)), "Index %d", i)
+		assert.Exactly(t, test.want, string(mw.ArgValue.([]byte)), "Index %d", i)
+		assert.Exactly(t, "websites/2/"+cfgPath, mw.ArgPath, "Index %d", i)
+
+		var have map[int]string
+		assert.NoError(t, b.Decode(mw.ArgValue.([]byte), &have), "Index %d", i)
+		assert.Exactly(t, minSaleQty, have, "Index %d", i)
+	}
+}
+
+func TestSerializer(t *testing.T) {
+	type row struct {
+		ID    int      `json:"id,string"`
+		Tags  []string `json:"tags"`
+		Price float64  `json:"price"`
+	}
+	s := cfgmodel.Serializer{Format: cfgmodel.SerializePHP}
+
+	raw, err := s.Encode([]row{{ID: 3, Tags: []string{"a", "b"}, Price: 1.5}})
+	assert.NoError(t, err)
+	assert.Exactly(t, `a:1:{i:0;a:3:{s:2:"id";s:1:"3";s:5:"price";d:1.5;s:4:"tags";a:2:{i:0;s:1:"a";i:1;s:1:"b";}}}`, string(raw))
+
+	var rows []row
+	assert.NoError(t, s.Decode(raw, &rows))
+	assert.Exactly(t, []row{{ID: 3, Tags: []string{"a", "b"}, Price: 1.5}}, rows)
+
+	rows = nil
+	assert.NoError(t, s.Decode([]byte("  "), &rows))
+	assert.Nil(t, rows)
+
+	var str string
+	assert.NoError(t, s.Decode([]byte(`s:6:"Gopher";`), &str))
+	assert.Exactly(t, "Gopher", str)
+
+	err = s.Decode([]byte(`a:1:{i:0;`), &rows)
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+	err = s.Decode([]byte(`{"id":`), &rows)
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+	_, err = s.Encode(make(chan int))
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+}
+
+func TestSerializeFormatByVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    cfgmodel.SerializeFormat
+		wantErr bool
+	}{
+		{"1.9.3.1", cfgmodel.SerializePHP, false},
+		{"2.0.18", cfgmodel.SerializePHP, false},
+		{"2.1.8", cfgmodel.SerializePHP, false},
+		{"2.2.0", cfgmodel.SerializeJSON, false},
+		{"2.3", cfgmodel.SerializeJSON, false},
+		{"2", 0, true},
+		{"x.1", 0, true},
+		{"2.y", 0, true},
+	}
+	for i, test := range tests {
+		have, err := cfgmodel.SerializeFormatByVersion(test.version)
+		if test.wantErr {
+			assert.True(t, errors.IsNotValid(err), "Index %d => %s", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
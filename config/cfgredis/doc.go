@@ -0,0 +1,35 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cfgredis sends and receives configuration changes between
+// processes with the Redis PUBLISH and SUBSCRIBE commands.
+//
+// Transport implements the config.Transport interface. Each config.Service.Write
+// publishes the written path into a Redis channel. The Listen function
+// subscribes to the same channel and forwards the paths written by other
+// processes to the config.Publisher, mostly the config.Service with an enabled
+// pub/sub service. Messages sent by the own Transport get skipped because the
+// config.Service already notified its MessageReceivers.
+//		t, err := cfgredis.NewByURL("redis://localhost:6379/0")
+//		cfgSrv := config.MustNewService(ccd.MustNewDBStorage(db),
+//			config.WithPubSub(), config.WithTransport(t))
+//		go func() {
+//			if err := t.Listen(ctx, cfgSrv); err != nil {
+//				// connection lost, restart Listen
+//			}
+//		}()
+//
+// Only the paths get transported, the values must be stored in a shared
+// storage, for example the core_config_data table.
+package cfgredis
@@ -0,0 +1,338 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgredis_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/cfgredis"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/garyburd/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ config.Transport = (*cfgredis.Transport)(nil)
+
+// fakeRedis is a minimal stand-in for a Redis server which understands the
+// commands PING, PUBLISH, SUBSCRIBE and UNSUBSCRIBE. miniredis does not
+// support pub/sub.
+type fakeRedis struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	subs map[string]map[*fakeConn]struct{}
+}
+
+type fakeConn struct {
+	mu sync.Mutex
+	w  *bufio.Writer
+	c  net.Conn
+}
+
+func (fc *fakeConn) write(args ...interface{}) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if len(args) > 1 {
+		fmt.Fprintf(fc.w, "*%d\r\n", len(args))
+	}
+	for _, a := range args {
+		switch v := a.(type) {
+		case int:
+			fmt.Fprintf(fc.w, ":%d\r\n", v)
+		case string:
+			fmt.Fprintf(fc.w, "$%d\r\n%s\r\n", len(v), v)
+		case nil:
+			fmt.Fprint(fc.w, "$-1\r\n")
+		}
+	}
+	_ = fc.w.Flush()
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fr := &fakeRedis{
+		ln:   ln,
+		subs: make(map[string]map[*fakeConn]struct{}),
+	}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go fr.serve(c)
+		}
+	}()
+	return fr
+}
+
+func (fr *fakeRedis) Addr() string { return fr.ln.Addr().String() }
+func (fr *fakeRedis) Close() error { return fr.ln.Close() }
+
+func (fr *fakeRedis) Pool() *redis.Pool {
+	return &redis.Pool{
+		Dial: func() (redis.Conn, error) { return redis.Dial("tcp", fr.Addr()) },
+	}
+}
+
+// readCommand reads a RESP array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil { // $length
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func (fr *fakeRedis) serve(c net.Conn) {
+	fc := &fakeConn{w: bufio.NewWriter(c), c: c}
+	subscribed := map[string]bool{}
+	defer func() {
+		fr.mu.Lock()
+		for ch := range subscribed {
+			delete(fr.subs[ch], fc)
+		}
+		fr.mu.Unlock()
+		_ = c.Close()
+	}()
+
+	r := bufio.NewReader(c)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			fc.mu.Lock()
+			fmt.Fprint(fc.w, "+PONG\r\n")
+			_ = fc.w.Flush()
+			fc.mu.Unlock()
+		case "PUBLISH":
+			fr.mu.Lock()
+			receivers := make([]*fakeConn, 0, len(fr.subs[args[1]]))
+			for sub := range fr.subs[args[1]] {
+				receivers = append(receivers, sub)
+			}
+			fr.mu.Unlock()
+			for _, sub := range receivers {
+				sub.write("message", args[1], args[2])
+			}
+			fc.write(len(receivers))
+		case "SUBSCRIBE":
+			for _, ch := range args[1:] {
+				fr.mu.Lock()
+				if fr.subs[ch] == nil {
+					fr.subs[ch] = make(map[*fakeConn]struct{})
+				}
+				fr.subs[ch][fc] = struct{}{}
+				fr.mu.Unlock()
+				subscribed[ch] = true
+				fc.write("subscribe", ch, len(subscribed))
+			}
+		case "UNSUBSCRIBE":
+			channels := args[1:]
+			if len(channels) == 0 {
+				for ch := range subscribed {
+					channels = append(channels, ch)
+				}
+			}
+			if len(channels) == 0 {
+				fc.write("unsubscribe", nil, 0)
+			}
+			for _, ch := range channels {
+				fr.mu.Lock()
+				delete(fr.subs[ch], fc)
+				fr.mu.Unlock()
+				delete(subscribed, ch)
+				fc.write("unsubscribe", ch, len(subscribed))
+			}
+		default:
+			fc.mu.Lock()
+			fmt.Fprintf(fc.w, "-ERR unknown command '%s'\r\n", args[0])
+			_ = fc.w.Flush()
+			fc.mu.Unlock()
+		}
+	}
+}
+
+type testSubscriber struct {
+	mu    sync.Mutex
+	paths []string
+	recv  chan struct{}
+}
+
+func (ts *testSubscriber) MessageConfig(p cfgpath.Path) error {
+	ts.mu.Lock()
+	ts.paths = append(ts.paths, p.String())
+	ts.mu.Unlock()
+	ts.recv <- struct{}{}
+	return nil
+}
+
+func (ts *testSubscriber) Paths() []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return append([]string(nil), ts.paths...)
+}
+
+func TestTransport_Listen(t *testing.T) {
+	fr := newFakeRedis(t)
+	defer fr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// two nodes sharing the same Redis server but each has its own storage.
+	nodeA := cfgredis.New(fr.Pool())
+	nodeB := cfgredis.New(fr.Pool())
+	srvA := config.MustNewService(config.NewInMemoryStore(), config.WithPubSub(), config.WithTransport(nodeA))
+	srvB := config.MustNewService(config.NewInMemoryStore(), config.WithPubSub(), config.WithTransport(nodeB))
+
+	subA := &testSubscriber{recv: make(chan struct{}, 10)}
+	subB := &testSubscriber{recv: make(chan struct{}, 10)}
+	_, err := srvA.Subscribe(cfgpath.NewRoute("payment/checkmo"), subA)
+	assert.NoError(t, err)
+	_, err = srvB.Subscribe(cfgpath.NewRoute("payment/checkmo"), subB)
+	assert.NoError(t, err)
+
+	listenErr := make(chan error, 2)
+	go func() { listenErr <- nodeA.Listen(ctx, srvA) }()
+	go func() { listenErr <- nodeB.Listen(ctx, srvB) }()
+	time.Sleep(100 * time.Millisecond) // wait until both nodes have subscribed
+
+	p := cfgpath.MustNewByParts("payment/checkmo/active").BindStore(2)
+	assert.NoError(t, srvA.Write(p, 1))
+
+	for _, sub := range []*testSubscriber{subA, subB} {
+		select {
+		case <-sub.recv:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timeout waiting for the MessageReceiver")
+		}
+	}
+	time.Sleep(100 * time.Millisecond) // a duplicate message would arrive now
+
+	// nodeA receives the message only once, from its own Write
+	assert.Exactly(t, []string{p.String()}, subA.Paths())
+	assert.Exactly(t, []string{p.String()}, subB.Paths())
+
+	cancel()
+	assert.NoError(t, <-listenErr)
+	assert.NoError(t, <-listenErr)
+	assert.NoError(t, srvA.Close())
+	assert.NoError(t, srvB.Close())
+}
+
+type testPublisher struct {
+	paths chan string
+}
+
+func (tp testPublisher) Publish(p cfgpath.Path) { tp.paths <- p.String() }
+
+func TestTransport_Listen_InvalidMessages(t *testing.T) {
+	fr := newFakeRedis(t)
+	defer fr.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tr := cfgredis.New(fr.Pool())
+	tp := testPublisher{paths: make(chan string, 10)}
+	go func() { _ = tr.Listen(ctx, tp) }()
+	time.Sleep(100 * time.Millisecond)
+
+	c := fr.Pool().Get()
+	defer c.Close()
+	for _, msg := range []string{
+		"no-separator",
+		"otherNode invalid/path",
+		"otherNode stores/3/aa/bb/cc",
+	} {
+		_, err := c.Do("PUBLISH", cfgredis.DefaultChannel, msg)
+		assert.NoError(t, err)
+	}
+
+	select {
+	case p := <-tp.paths:
+		assert.Exactly(t, "stores/3/aa/bb/cc", p)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the Publisher")
+	}
+	assert.Len(t, tp.paths, 0)
+}
+
+func TestTransport_Listen_AlreadyRunning(t *testing.T) {
+	fr := newFakeRedis(t)
+	defer fr.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tr := cfgredis.New(fr.Pool())
+	go func() { _ = tr.Listen(ctx, testPublisher{}) }()
+	time.Sleep(50 * time.Millisecond)
+
+	err := tr.Listen(ctx, testPublisher{})
+	assert.True(t, errors.IsAlreadyExists(err), "Error: %s", err)
+}
+
+func TestTransport_ConnectionError(t *testing.T) {
+	fr := newFakeRedis(t)
+	tr := cfgredis.New(fr.Pool())
+	assert.NoError(t, fr.Close())
+
+	err := tr.Send(cfgpath.MustNewByParts("aa/bb/cc"))
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+	err = tr.Listen(context.Background(), testPublisher{})
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+}
+
+func TestNewByURL(t *testing.T) {
+	fr := newFakeRedis(t)
+	defer fr.Close()
+
+	tr, err := cfgredis.NewByURL("redis://" + fr.Addr() + "/3")
+	assert.NoError(t, err)
+	assert.NoError(t, tr.Send(cfgpath.MustNewByParts("aa/bb/cc")))
+
+	_, err = cfgredis.NewByURL("mysql://localhost")
+	assert.Error(t, err)
+}
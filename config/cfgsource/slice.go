@@ -16,10 +16,12 @@ package cfgsource
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 	"sort"
 
 	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/csfw/util/conv"
 	"github.com/corestoreio/csfw/util/errors"
 )
 
@@ -180,6 +182,86 @@ func (s Slice) IndexValBool(k bool) int {
 	return -1
 }
 
+// ValidateString checks if the string value v exists. Error behaviour:
+// NotValid or Fatal.
+func (s Slice) ValidateString(v string) error {
+	if s.ContainsValString(v) {
+		return nil
+	}
+	return s.errValueNotFound("'%s'", v)
+}
+
+// ValidateInt checks if the int value v exists. Error behaviour: NotValid or
+// Fatal.
+func (s Slice) ValidateInt(v int) error {
+	if s.ContainsValInt(v) {
+		return nil
+	}
+	return s.errValueNotFound("'%d'", v)
+}
+
+// ValidateFloat64 checks if the float64 value v exists. Error behaviour:
+// NotValid or Fatal.
+func (s Slice) ValidateFloat64(v float64) error {
+	if s.ContainsValFloat64(v) {
+		return nil
+	}
+	return s.errValueNotFound("'%.14f'", v)
+}
+
+// ValidateBool checks if the bool value v exists. Error behaviour: NotValid
+// or Fatal.
+func (s Slice) ValidateBool(v bool) error {
+	if s.ContainsValBool(v) {
+		return nil
+	}
+	return s.errValueNotFound("'%t'", v)
+}
+
+// Validate converts v into the type of the first option and checks if the
+// value exists. An empty Slice accepts all values. Error behaviour: NotValid
+// or Fatal.
+func (s Slice) Validate(v interface{}) error {
+	if len(s) == 0 {
+		return nil
+	}
+	switch s[0].NotNull {
+	case NotNullInt:
+		i, err := conv.ToIntE(v)
+		if err != nil {
+			return errors.NewNotValidf("[cfgsource] Type mismatch, want int: %s", err)
+		}
+		return s.ValidateInt(i)
+	case NotNullFloat64:
+		f, err := conv.ToFloat64E(v)
+		if err != nil {
+			return errors.NewNotValidf("[cfgsource] Type mismatch, want float64: %s", err)
+		}
+		return s.ValidateFloat64(f)
+	case NotNullBool:
+		b, err := conv.ToBoolE(v)
+		if err != nil {
+			return errors.NewNotValidf("[cfgsource] Type mismatch, want bool: %s", err)
+		}
+		return s.ValidateBool(b)
+	}
+	str, err := conv.ToStringE(v)
+	if err != nil {
+		return errors.NewNotValidf("[cfgsource] Type mismatch, want string: %s", err)
+	}
+	return s.ValidateString(str)
+}
+
+// errValueNotFound creates the NotValid error with all options. verb formats
+// the value.
+func (s Slice) errValueNotFound(verb string, v interface{}) error {
+	jv, err := s.ToJSON()
+	if err != nil {
+		return errors.NewFatal(err, fmt.Sprintf("[cfgsource] Slice: %#v", s))
+	}
+	return errors.NewNotValidf("[cfgsource] The value "+verb+" cannot be found within the allowed Options():\n%s", v, jv)
+}
+
 // ContainsLabel checks if k has an entry as a label.
 func (s Slice) ContainsLabel(l string) bool {
 	return s.IndexLabel(l) > -1
@@ -435,3 +435,30 @@ func TestSliceUnmarshalJSON(t *testing.T) {
 		assert.Exactly(t, test.want, have, "Index %d", i)
 	}
 }
+
+func TestSliceValidate(t *testing.T) {
+	tests := []struct {
+		sl      cfgsource.Slice
+		v       interface{}
+		wantErr errors.BehaviourFunc
+	}{
+		{nil, "anything", nil},
+		{cfgsource.NewByStringValue("a", "b"), "b", nil},
+		{cfgsource.NewByStringValue("a", "b"), "c", errors.IsNotValid},
+		{cfgsource.NewByIntValue(1, 2), "2", nil},
+		{cfgsource.NewByIntValue(1, 2), 3, errors.IsNotValid},
+		{cfgsource.NewByIntValue(1, 2), "x", errors.IsNotValid},
+		{cfgsource.NewByFloat64(cfgsource.F64s{{2.5, "a"}}), 2.5, nil},
+		{cfgsource.NewByFloat64(cfgsource.F64s{{2.5, "a"}}), 2.6, errors.IsNotValid},
+		{cfgsource.NewByBool(cfgsource.Bools{{true, "Yes"}}), "1", nil},
+		{cfgsource.NewByBool(cfgsource.Bools{{true, "Yes"}}), false, errors.IsNotValid},
+	}
+	for i, test := range tests {
+		err := test.sl.Validate(test.v)
+		if test.wantErr != nil {
+			assert.True(t, test.wantErr(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+	}
+}
@@ -39,6 +39,10 @@ To get a value from the configuration Service via any type method you have to pr
 path.Path. If you use the ScopedGetter via function NewScoped() you can only provide a
 path.Route to the type methods String(), Int(), Float64(), etc.
 
+The option WithScopeFallback lets the Service itself resolve a path like Magento does:
+store -> website -> default -> element.Field Default. The function Service.Value
+additionally reports the scope from which the value has been taken.
+
 The examples show the overall best practices.
 */
 package config
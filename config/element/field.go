@@ -18,6 +18,7 @@ import (
 	"sort"
 
 	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/cfgsource"
 	"github.com/corestoreio/csfw/storage/text"
 	"github.com/corestoreio/csfw/store/scope"
 	"github.com/corestoreio/csfw/util/errors"
@@ -59,6 +60,9 @@ type Field struct {
 	CanBeEmpty bool `json:",omitempty"`
 	// Default can contain any default config value: float64, int64, string, bool
 	Default interface{} `json:",omitempty"`
+	// Source contains the allowed options for a select or multiselect field,
+	// same as the source_model in Magento. Used for validation. Can be nil.
+	Source cfgsource.Slice `json:",omitempty"`
 }
 
 // NewFieldSlice wrapper to create a new FieldSlice
@@ -158,6 +162,9 @@ func (f Field) Update(new Field) Field {
 	if new.Default != nil {
 		f.Default = new.Default
 	}
+	if new.Source != nil {
+		f.Source = new.Source
+	}
 	return f
 }
 
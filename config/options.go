@@ -15,6 +15,8 @@
 package config
 
 import (
+	"github.com/corestoreio/csfw/config/audit"
+	"github.com/corestoreio/csfw/config/element"
 	"github.com/corestoreio/csfw/log"
 	"github.com/corestoreio/csfw/util/errors"
 )
@@ -52,3 +54,60 @@ func WithPubSub() Option {
 		return nil
 	}
 }
+
+// WithScopeFallback enables the Magento like resolving of paths for all
+// getter functions of the Service. A value which cannot be found in the store
+// scope gets looked up in the website scope, then in the default scope and at
+// last in the Default of the element.Field from the SectionSlice. The
+// websiteID function maps a store to its website, if nil store scoped paths
+// fall back directly to the default scope. Use Service.Value to find out which
+// scope provided the value.
+//		srv, err := config.NewService(backend, config.WithScopeFallback(
+//			packageConfiguration,
+//			func(storeID int64) (int64, error) {
+//				st, err := storeService.Store(storeID)
+//				return st.WebsiteID(), err
+//			},
+//		))
+func WithScopeFallback(sections element.SectionSlice, websiteID WebsiteIDFunc) Option {
+	return func(s *Service) error {
+		s.fallback = &scopeFallback{
+			sections:  sections,
+			websiteID: websiteID,
+		}
+		return nil
+	}
+}
+
+// WithTransport sends the path of each written value to other processes. The
+// other processes forward the path to their MessageReceivers. If sending fails,
+// Write returns an error although the value has been written.
+func WithTransport(t Transport) Option {
+	return func(s *Service) error {
+		s.transport = t
+		return nil
+	}
+}
+
+// WithAudit records every write of a value in the Sink. The old value gets
+// read from the backend Storager before writing the new value. The actor of a
+// change gets taken from the context of Service.WriteContext.
+func WithAudit(sink audit.Sink) Option {
+	return func(s *Service) error {
+		s.audit = sink
+		return nil
+	}
+}
+
+// WithFieldValidation validates each written value against its element.Field
+// from the SectionSlice. A write gets rejected if the scope of the path is not
+// allowed in Field.Scopes, if the value cannot be found in the Field.Source
+// options or if the value cannot be converted into the type of the
+// Field.Default or of the time and duration front end types. Paths without a
+// Field won't be validated. Error behaviour: Unauthorized or NotValid.
+func WithFieldValidation(sections element.SectionSlice) Option {
+	return func(s *Service) error {
+		s.validation = sections
+		return nil
+	}
+}
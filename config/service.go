@@ -15,9 +15,12 @@
 package config
 
 import (
+	"context"
 	"time"
 
+	"github.com/corestoreio/csfw/config/audit"
 	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/element"
 	"github.com/corestoreio/csfw/log"
 	"github.com/corestoreio/csfw/util/conv"
 	"github.com/corestoreio/csfw/util/errors"
@@ -90,6 +93,21 @@ type Service struct {
 	// config values.
 	*pubSub
 
+	// fallback if set resolves a path through the parent scopes and the
+	// element.Field defaults. See WithScopeFallback.
+	fallback *scopeFallback
+
+	// audit if set records all changes. See WithAudit.
+	audit audit.Sink
+
+	// transport if set notifies other processes about written paths. See
+	// WithTransport.
+	transport Transport
+
+	// validation if set validates all writes against the element.Field. See
+	// WithFieldValidation.
+	validation element.SectionSlice
+
 	// Log can be set for debugging purpose. If nil, it panics. Default
 	// log.Blackhole with disabled debug and info logging. You should use the
 	// option function WithLogger because the logger gets also set to the
@@ -168,17 +186,61 @@ func (s *Service) NewScoped(websiteID, storeID int64) Scoped {
 //		// 6 for example comes from core_store/store database table
 //		err := Write(p.Bind(scope.StoreID, 6), "CHF")
 func (s *Service) Write(p cfgpath.Path, v interface{}) error {
+	return s.WriteContext(context.Background(), p, v)
+}
+
+// WriteContext same as Write but the context provides the actor for the audit
+// log. See WithAudit and audit.WithActor.
+func (s *Service) WriteContext(ctx context.Context, p cfgpath.Path, v interface{}) error {
 	if s.Log.IsDebug() {
 		s.Log.Debug("config.Service.Write", log.Stringer("path", p), log.Object("val", v))
 	}
 
+	if s.validation != nil {
+		if err := s.validate(p, v); err != nil {
+			return errors.Wrap(err, "[config] Service.Write.validate")
+		}
+	}
+
+	var oldVal interface{}
+	if s.audit != nil {
+		ov, err := s.backend.Get(p)
+		if err != nil && !errors.IsNotFound(err) {
+			return errors.Wrap(err, "[config] Service.Audit.Storage.Get")
+		}
+		oldVal = ov
+	}
+
 	if err := s.backend.Set(p, v); err != nil {
 		return errors.Wrap(err, "[config] sStorage.Set")
 	}
+
+	var auditErr error
+	if s.audit != nil {
+		auditErr = s.audit.Record(ctx, audit.Change{
+			Path:     p,
+			OldValue: oldVal,
+			NewValue: v,
+			Actor:    audit.ActorFromContext(ctx),
+			Time:     time.Now(),
+		})
+	}
+
+	// The value has been written, so the subscribers and the other processes
+	// must hear about it even if the audit log fails.
 	if s.pubSub != nil {
 		s.sendMsg(p)
 	}
-	return nil
+
+	if s.transport != nil {
+		if err := s.transport.Send(p); err != nil {
+			if auditErr != nil {
+				return errors.Wrapf(err, "[config] Service.Transport.Send and Service.Audit.Record: %s", auditErr)
+			}
+			return errors.Wrap(err, "[config] Service.Transport.Send")
+		}
+	}
+	return errors.Wrap(auditErr, "[config] Service.Audit.Record")
 }
 
 // get generic getter ... not sure if this should be public ...
@@ -186,6 +248,10 @@ func (s *Service) get(p cfgpath.Path) (interface{}, error) {
 	if s.Log.IsDebug() {
 		s.Log.Debug("config.Service.get", log.Stringer("path", p))
 	}
+	if s.fallback != nil {
+		v, _, err := s.Value(p)
+		return v, err
+	}
 	return s.backend.Get(p)
 }
 
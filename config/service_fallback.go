@@ -0,0 +1,116 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/log"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// ElementDefaultTypeID gets returned by Service.Value when the value has been
+// taken from the Default field of an element.Field because no scope contains
+// the path. The type of this TypeID is scope.Absent.
+const ElementDefaultTypeID scope.TypeID = 0
+
+// WebsiteIDFunc returns the parent website ID of a store ID. The function
+// store.Service.Store(storeID) provides a Store whose WebsiteID() can be
+// returned.
+type WebsiteIDFunc func(storeID int64) (websiteID int64, err error)
+
+// scopeFallback resolves a path like Magento does. Enabled with the option
+// function WithScopeFallback.
+type scopeFallback struct {
+	sections  element.SectionSlice
+	websiteID WebsiteIDFunc
+}
+
+// scopeIDs returns the hierarchical order of the scopes to query for a path.
+// Store falls back to its website and website falls back to default.
+func (sf *scopeFallback) scopeIDs(p cfgpath.Path) (scope.TypeIDs, error) {
+	var ids = make(scope.TypeIDs, 0, 3)
+	switch scp, id := p.ScopeID.Unpack(); scp {
+	case scope.Store:
+		ids = append(ids, p.ScopeID)
+		if sf.websiteID != nil {
+			wID, err := sf.websiteID(id)
+			if err != nil {
+				return nil, errors.Wrapf(err, "[config] WebsiteIDFunc StoreID %d", id)
+			}
+			ids = append(ids, scope.Website.Pack(wID))
+		}
+	case scope.Website:
+		ids = append(ids, p.ScopeID)
+	}
+	return append(ids, scope.DefaultTypeID), nil
+}
+
+// Value returns the raw value of a path and the scope in which the value has
+// been found. Without the option WithScopeFallback the path gets only looked
+// up in its own scope.
+//
+// With an enabled scope fallback, a value which cannot be found in the store
+// scope gets looked up in the website scope of the store and at last in the
+// default scope. Scopes which are not allowed in the Scopes field of the
+// element.Field get skipped. If all scopes are missing the path then the
+// Default of the element.Field gets returned together with the
+// ElementDefaultTypeID. Error behaviour: NotFound or any other error from the
+// backend Storager.
+func (s *Service) Value(p cfgpath.Path) (v interface{}, found scope.TypeID, err error) {
+	if s.fallback == nil {
+		v, err = s.backend.Get(p)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "[config] Service.Value.Get")
+		}
+		return v, p.ScopeID, nil
+	}
+
+	ids, err := s.fallback.scopeIDs(p)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "[config] Service.Value Path %q", p)
+	}
+
+	// a missing field is not an error because not all paths must be
+	// declared in the element.SectionSlice.
+	f, _, errField := s.fallback.sections.FindField(p.Route)
+	hasField := errField == nil
+
+	for _, id := range ids {
+		if hasField && f.Scopes > 0 && !f.Scopes.Has(id.Type()) {
+			continue
+		}
+		p.ScopeID = id
+		v, err = s.backend.Get(p)
+		if err == nil {
+			if s.Log.IsDebug() {
+				s.Log.Debug("config.Service.Value.Found", log.Stringer("path", p), log.Stringer("scope", id))
+			}
+			return v, id, nil
+		}
+		if !errors.IsNotFound(err) {
+			return nil, 0, errors.Wrapf(err, "[config] Service.Value.Get Path %q", p)
+		}
+	}
+
+	if hasField && f.Default != nil {
+		if s.Log.IsDebug() {
+			s.Log.Debug("config.Service.Value.ElementDefault", log.Stringer("route", p.Route), log.Object("default", f.Default))
+		}
+		return f.Default, ElementDefaultTypeID, nil
+	}
+	return nil, 0, errors.NewNotFoundf("[config] Service.Value Route %q not found in any scope", p.Route)
+}
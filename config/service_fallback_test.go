@@ -0,0 +1,148 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var fallbackSections = element.MustNewConfiguration(
+	element.Section{
+		ID: cfgpath.NewRoute(`web`),
+		Groups: element.NewGroupSlice(
+			element.Group{
+				ID: cfgpath.NewRoute(`cookie`),
+				Fields: element.NewFieldSlice(
+					element.Field{
+						ID:      cfgpath.NewRoute(`cookie_lifetime`),
+						Scopes:  scope.PermStore,
+						Default: 3600,
+					},
+					element.Field{
+						// store scope not allowed
+						ID:      cfgpath.NewRoute(`cookie_path`),
+						Scopes:  scope.PermWebsite,
+						Default: "/",
+					},
+					element.Field{
+						ID:     cfgpath.NewRoute(`cookie_domain`),
+						Scopes: scope.PermStore,
+					},
+				),
+			},
+		),
+	},
+)
+
+// fallbackWebsiteID maps store 1 and 2 to website 1 and store 3 to website 2.
+func fallbackWebsiteID(storeID int64) (int64, error) {
+	switch storeID {
+	case 1, 2:
+		return 1, nil
+	case 3:
+		return 2, nil
+	}
+	return 0, errors.NewNotFoundf("Store %d not found", storeID)
+}
+
+func TestService_Value_ScopeFallback(t *testing.T) {
+	srv := config.MustNewService(config.NewInMemoryStore(), config.WithScopeFallback(fallbackSections, fallbackWebsiteID))
+
+	pLifetime := cfgpath.MustNewByParts("web/cookie/cookie_lifetime")
+	pPath := cfgpath.MustNewByParts("web/cookie/cookie_path")
+	assert.NoError(t, srv.Write(pLifetime.BindStore(2), 60))
+	assert.NoError(t, srv.Write(pLifetime.BindWebsite(1), 120))
+	assert.NoError(t, srv.Write(pPath.BindStore(1), "/store"))
+	assert.NoError(t, srv.Write(pPath.BindWebsite(1), "/website"))
+	assert.NoError(t, srv.Write(cfgpath.MustNewByParts("web/unsecure/base_url"), "http://x.com/"))
+
+	tests := []struct {
+		path      cfgpath.Path
+		wantVal   string
+		wantScope scope.TypeID
+	}{
+		{pLifetime.BindStore(2), "60", scope.Store.Pack(2)},
+		{pLifetime.BindStore(1), "120", scope.Website.Pack(1)},
+		{pLifetime.BindWebsite(1), "120", scope.Website.Pack(1)},
+		{pLifetime.BindStore(3), "3600", config.ElementDefaultTypeID},
+		{pLifetime, "3600", config.ElementDefaultTypeID},
+		// store scope gets skipped due to the element.Field Scopes
+		{pPath.BindStore(1), "/website", scope.Website.Pack(1)},
+		{pPath.BindStore(3), "/", config.ElementDefaultTypeID},
+		// not defined in the element.SectionSlice
+		{cfgpath.MustNewByParts("web/unsecure/base_url").BindStore(3), "http://x.com/", scope.DefaultTypeID},
+	}
+	for i, test := range tests {
+		v, haveScope, err := srv.Value(test.path)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantVal, conv.ToString(v), "Index %d", i)
+		assert.Exactly(t, test.wantScope, haveScope, "Index %d => %s", i, haveScope)
+
+		s, err := srv.String(test.path)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantVal, s, "Index %d", i)
+	}
+}
+
+func TestService_Value_ScopeFallback_Errors(t *testing.T) {
+	srv := config.MustNewService(config.NewInMemoryStore(), config.WithScopeFallback(fallbackSections, fallbackWebsiteID))
+
+	// no value and no element default
+	v, _, err := srv.Value(cfgpath.MustNewByParts("web/cookie/cookie_domain").BindStore(1))
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	assert.Nil(t, v)
+
+	// unknown store
+	_, _, err = srv.Value(cfgpath.MustNewByParts("web/cookie/cookie_lifetime").BindStore(4))
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+
+	_, err = srv.Int(cfgpath.MustNewByParts("web/cookie/cookie_domain").BindWebsite(1))
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+}
+
+func TestService_Value_NoFallback(t *testing.T) {
+	srv := config.MustNewService(config.NewInMemoryStore())
+	p := cfgpath.MustNewByParts("web/cookie/cookie_lifetime")
+	assert.NoError(t, srv.Write(p.BindWebsite(1), 120))
+
+	v, haveScope, err := srv.Value(p.BindWebsite(1))
+	assert.NoError(t, err)
+	assert.Exactly(t, 120, v)
+	assert.Exactly(t, scope.Website.Pack(1), haveScope)
+
+	_, _, err = srv.Value(p.BindStore(1))
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+}
+
+func TestWithScopeFallback_NoWebsiteID(t *testing.T) {
+	srv := config.MustNewService(config.NewInMemoryStore(), config.WithScopeFallback(fallbackSections, nil))
+	p := cfgpath.MustNewByParts("web/cookie/cookie_lifetime")
+	assert.NoError(t, srv.Write(p.BindWebsite(1), 120))
+	assert.NoError(t, srv.Write(p, 90))
+
+	// website gets skipped because the store cannot be mapped
+	v, haveScope, err := srv.Value(p.BindStore(1))
+	assert.NoError(t, err)
+	assert.Exactly(t, 90, v)
+	assert.Exactly(t, scope.DefaultTypeID, haveScope)
+}
@@ -15,13 +15,19 @@
 package config
 
 import (
+	"strings"
 	"sync"
 
 	"github.com/corestoreio/csfw/config/cfgpath"
 	"github.com/corestoreio/csfw/log"
+	"github.com/corestoreio/csfw/store/scope"
 	"github.com/corestoreio/csfw/util/errors"
 )
 
+// patternWildcard matches any value of a route level in a Subscription
+// pattern.
+const patternWildcard = "*"
+
 // MessageReceiver allows you to listen to write actions. The order of calling
 // each subscriber is totally random. If a subscriber panics, it gets securely
 // removed without crashing the whole system. This interface should be
@@ -46,18 +52,121 @@ type Subscriber interface {
 	// or "system/smtp" to receive message from all smtp changes or "system" to
 	// receive changes for all paths beginning with "system". A path is equal to
 	// a topic in a PubSub system. Path cannot be empty means you cannot listen
-	// to all changes. Returns a unique identifier for the Subscriber for later
-	// removal, or an error.
+	// to all changes, use the PatternSubscriber for that. Returns a unique
+	// identifier for the Subscriber for later removal, or an error.
 	Subscribe(cfgpath.Route, MessageReceiver) (subscriptionID int, err error)
 }
 
+// PatternSubscriber subscribes MessageReceivers with wildcard patterns and
+// scope filters. This interface is at the moment only implemented by the
+// config.Service.
+type PatternSubscriber interface {
+	// SubscribePattern subscribes a MessageReceiver to all paths matching the
+	// Subscription. Returns a unique identifier for the Subscriber for later
+	// removal, or an error.
+	SubscribePattern(Subscription, MessageReceiver) (subscriptionID int, err error)
+}
+
+// Subscription defines the filter and the delivery of a pattern based
+// subscription.
+type Subscription struct {
+	// Pattern matches the route of a path level by level. A star matches any
+	// value of one level, for example "payment/*/active" or "*/*/base_url". A
+	// pattern with less levels than the route also matches all routes below,
+	// so "*" matches all routes.
+	Pattern string
+	// ScopeID if not zero restricts the messages to paths bound to this scope
+	// and ID, for example scope.MakeTypeID(scope.Store, 2).
+	ScopeID scope.TypeID
+	// QueueSize if greater than zero delivers the messages in a separate
+	// goroutine through a queue with this capacity, so a slow MessageReceiver
+	// does not block other receivers. The messages arrive in the order of the
+	// writes. If the queue is full, new messages get dropped. If zero, the
+	// messages get delivered synchronously like in Subscribe.
+	QueueSize int
+	// Dropped gets called for each message which has been dropped because of a
+	// full queue. Dropped must not block. Optional.
+	Dropped func(subscriptionID int, p cfgpath.Path)
+}
+
+// patternSub a subscribed MessageReceiver with its parsed pattern.
+type patternSub struct {
+	Subscription
+	levels []string
+	mr     MessageReceiver
+	queue  chan cfgpath.Path // nil for synchronous delivery
+}
+
+func newPatternSub(sub Subscription, mr MessageReceiver) (*patternSub, error) {
+	if sub.Pattern == "" {
+		return nil, errors.NewEmptyf("[config] Subscription with empty pattern")
+	}
+	levels := strings.Split(sub.Pattern, string(cfgpath.Separator))
+	for _, l := range levels {
+		if l == "" {
+			return nil, errors.NewNotValidf("[config] Subscription pattern %q contains an empty level", sub.Pattern)
+		}
+	}
+	ps := &patternSub{
+		Subscription: sub,
+		levels:       levels,
+		mr:           mr,
+	}
+	if sub.QueueSize > 0 {
+		ps.queue = make(chan cfgpath.Path, sub.QueueSize)
+	}
+	return ps, nil
+}
+
+// match checks the scope and the route of a path against the Subscription.
+func (ps *patternSub) match(p cfgpath.Path) bool {
+	if ps.ScopeID > 0 && ps.ScopeID != p.ScopeID {
+		return false
+	}
+	route := strings.Split(p.Route.String(), string(cfgpath.Separator))
+	if len(ps.levels) > len(route) {
+		return false
+	}
+	for i, l := range ps.levels {
+		if l != patternWildcard && l != route[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Publisher sends a path to all subscribed MessageReceivers without writing a
+// value into the Storager. Storage engines which receive changes from other
+// nodes, for example config/storage/etcd, use this interface to notify the
+// MessageReceivers of the current process. This interface is at the moment
+// only implemented by the config.Service.
+type Publisher interface {
+	// Publish notifies all MessageReceivers which are subscribed to the
+	// path or to a part of the path.
+	Publish(cfgpath.Path)
+}
+
+// Transport sends the paths of written values to other processes. A Transport
+// implementation must also receive the paths sent by other processes and
+// forward them to a Publisher, like config/storage/etcd does in its Watch
+// function. See package config/cfgredis.
+type Transport interface {
+	// Send broadcasts the path to all other processes. It gets called after
+	// the value has been written successfully.
+	Send(cfgpath.Path) error
+}
+
 // pubSub embedded pointer struct into the Service
 type pubSub struct {
 	// subMap, subscribed writers are getting called when a write event
 	// will happen. uint64 is the path/route (aka topic) and int the Subscriber ID for later
 	// removal.
-	subMap     map[uint32]map[int]MessageReceiver
-	subAutoInc int // subAutoInc increased whenever a Subscriber has been added
+	subMap map[uint32]map[int]MessageReceiver
+	// patSubs contains the pattern based subscriptions. int is the
+	// Subscriber ID.
+	patSubs    map[int]*patternSub
+	queues     sync.WaitGroup // running goroutines of the queued patSubs
+	subAutoInc int            // subAutoInc increased whenever a Subscriber has been added
 	mu         sync.RWMutex
 	pubPath    chan cfgpath.Path
 	stop       chan struct{} // terminates the goroutine
@@ -112,11 +221,61 @@ func (s *pubSub) Subscribe(r cfgpath.Route, mr MessageReceiver) (subscriptionID
 	return
 }
 
+// SubscribePattern adds a MessageReceiver to be called when a path matching
+// the Subscription has been written. See type Subscription for the pattern
+// syntax, the scope filter and the queued delivery. Error behaviour: Empty,
+// NotValid or AlreadyClosed.
+func (s *pubSub) SubscribePattern(sub Subscription, mr MessageReceiver) (subscriptionID int, err error) {
+	ps, err := newPatternSub(sub, mr)
+	if err != nil {
+		return 0, errors.Wrap(err, "[config] pubSub.SubscribePattern")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, errors.NewAlreadyClosedf("[config] PubSub Service already closed")
+	}
+	s.subAutoInc++
+	subscriptionID = s.subAutoInc
+	s.patSubs[subscriptionID] = ps
+
+	if ps.queue != nil {
+		s.queues.Add(1)
+		go s.consume(subscriptionID, ps)
+	}
+	return
+}
+
+// consume delivers the queued messages in order until the queue gets closed.
+// A failing MessageReceiver gets unsubscribed.
+func (s *pubSub) consume(id int, ps *patternSub) {
+	defer s.queues.Done()
+	for p := range ps.queue {
+		if err := s.sendMsgRecoverable(id, ps.mr, p); err != nil {
+			if s.log.IsDebug() {
+				s.log.Debug("config.pubSub.consume.sendMessage", log.Err(err), log.Int("id", id), log.Stringer("path", p))
+			}
+			if err := s.Unsubscribe(id); err != nil && s.log.IsDebug() {
+				s.log.Debug("config.pubSub.consume.Unsubscribe.err", log.Err(err), log.Int("subscriptionID", id))
+			}
+			return
+		}
+	}
+}
+
 // Unsubscribe removes a subscriber with a specific ID.
 func (s *pubSub) Unsubscribe(subscriptionID int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if ps, ok := s.patSubs[subscriptionID]; ok {
+		delete(s.patSubs, subscriptionID)
+		if ps.queue != nil {
+			close(ps.queue)
+		}
+		return nil
+	}
+
 	for path, subs := range s.subMap {
 		if _, ok := subs[subscriptionID]; ok {
 			delete(s.subMap[path], subscriptionID) // mem leaks?
@@ -129,6 +288,15 @@ func (s *pubSub) Unsubscribe(subscriptionID int) error {
 	return nil
 }
 
+// Publish sends the path to all subscribed MessageReceivers. Does nothing if
+// the pub/sub service has not been started or has already been closed.
+func (s *pubSub) Publish(p cfgpath.Path) {
+	if s == nil {
+		return
+	}
+	s.sendMsg(p)
+}
+
 // sendMsg sends the arg into the channel
 func (s *pubSub) sendMsg(p cfgpath.Path) {
 	if false == s.closed {
@@ -147,6 +315,7 @@ func (s *pubSub) publish() {
 	for {
 		select {
 		case <-s.stop:
+			s.closeQueues()
 			s.closeErr <- nil
 			return
 		case p, ok := <-s.pubPath:
@@ -155,11 +324,15 @@ func (s *pubSub) publish() {
 				return
 			}
 
-			if len(s.subMap) == 0 {
+			s.mu.RLock()
+			noSubs := len(s.subMap) == 0 && len(s.patSubs) == 0
+			s.mu.RUnlock()
+			if noSubs {
 				break
 			}
 
 			var evict []int
+			evict = append(evict, s.sendPatterns(p)...)
 
 			evict = append(evict, s.readMapAndSend(p, 1)...)  // e.g.: system and StrScope/ID/system
 			evict = append(evict, s.readMapAndSend(p, 2)...)  // e.g.: system/smtp and StrScope/ID/system/smtp
@@ -177,6 +350,53 @@ func (s *pubSub) publish() {
 	}
 }
 
+// sendPatterns delivers the path to all matching pattern based
+// subscriptions. Queued subscriptions drop the path if their queue is full.
+func (s *pubSub) sendPatterns(p cfgpath.Path) (evict []int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for id, ps := range s.patSubs {
+		if !ps.match(p) {
+			continue
+		}
+		if ps.queue == nil {
+			if err := s.sendMsgRecoverable(id, ps.mr, p); err != nil {
+				if s.log.IsDebug() {
+					s.log.Debug("config.pubSub.publish.sendPatterns", log.Err(err), log.Int("id", id), log.Stringer("path", p))
+				}
+				evict = append(evict, id)
+			}
+			continue
+		}
+		select {
+		case ps.queue <- p:
+		default:
+			if s.log.IsDebug() {
+				s.log.Debug("config.pubSub.publish.sendPatterns.dropped", log.Int("id", id), log.Stringer("path", p), log.Int("queueSize", ps.QueueSize))
+			}
+			if ps.Dropped != nil {
+				ps.Dropped(id, p)
+			}
+		}
+	}
+	return
+}
+
+// closeQueues removes all queued pattern based subscriptions and waits until
+// their goroutines have delivered the remaining messages.
+func (s *pubSub) closeQueues() {
+	s.mu.Lock()
+	for id, ps := range s.patSubs {
+		if ps.queue != nil {
+			delete(s.patSubs, id)
+			close(ps.queue)
+		}
+	}
+	s.mu.Unlock()
+	s.queues.Wait()
+}
+
 func (s *pubSub) readMapAndSend(p cfgpath.Path, level int) (evict []int) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -233,6 +453,7 @@ func (s *pubSub) sendMsgRecoverable(id int, sl MessageReceiver, p cfgpath.Path)
 func newPubSub(l log.Logger) *pubSub {
 	return &pubSub{
 		subMap:   make(map[uint32]map[int]MessageReceiver),
+		patSubs:  make(map[int]*patternSub),
 		pubPath:  make(chan cfgpath.Path),
 		stop:     make(chan struct{}),
 		closeErr: make(chan error),
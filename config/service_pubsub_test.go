@@ -19,6 +19,7 @@ import (
 	goLog "log"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/corestoreio/csfw/config"
 	"github.com/corestoreio/csfw/config/cfgpath"
@@ -259,3 +260,210 @@ func TestPubSubEvict(t *testing.T) {
 	err = s.Close()
 	assert.True(t, errors.IsAlreadyClosed(err), "Error: %s", err)
 }
+
+func TestPubSubPublish(t *testing.T) {
+
+	s := config.MustNewService(config.NewInMemoryStore(), config.WithPubSub())
+	testPath := cfgpath.MustNewByParts("aa/bb/cc").BindStore(4)
+
+	var calls int
+	_, err := s.Subscribe(cfgpath.NewRoute("aa/bb"), &testSubscriber{
+		t: t,
+		f: func(p cfgpath.Path) error {
+			assert.Exactly(t, testPath.String(), p.String())
+			calls++
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+
+	s.Publish(testPath)
+	assert.NoError(t, s.Close())
+	assert.Exactly(t, 1, calls)
+	assert.False(t, s.IsSet(testPath), "Publish must not write into the Storager")
+
+	// Publish after Close does not block
+	s.Publish(testPath)
+}
+
+func TestPubSubPublish_Disabled(t *testing.T) {
+	s := config.MustNewService(config.NewInMemoryStore())
+	s.Publish(cfgpath.MustNewByParts("aa/bb/cc")) // must not panic
+	var _ config.Publisher = s
+}
+
+type testTransport struct {
+	paths []string
+	err   error
+}
+
+func (tt *testTransport) Send(p cfgpath.Path) error {
+	tt.paths = append(tt.paths, p.String())
+	return tt.err
+}
+
+func TestWithTransport(t *testing.T) {
+	tt := &testTransport{}
+	s := config.MustNewService(config.NewInMemoryStore(), config.WithTransport(tt))
+
+	p := cfgpath.MustNewByParts("aa/bb/cc").BindWebsite(2)
+	assert.NoError(t, s.Write(p, 1))
+	assert.Exactly(t, []string{p.String()}, tt.paths)
+
+	tt.err = errors.NewFatalf("Redis gone")
+	err := s.Write(p, 2)
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+	assert.True(t, s.IsSet(p), "Value must be written before sending")
+}
+
+type pathRecorder struct {
+	mu    sync.Mutex
+	paths []string
+	block chan struct{} // if not nil, MessageConfig waits until closed
+	err   error
+}
+
+func (pr *pathRecorder) MessageConfig(p cfgpath.Path) error {
+	if pr.block != nil {
+		<-pr.block
+	}
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.paths = append(pr.paths, p.String())
+	return pr.err
+}
+
+func (pr *pathRecorder) Paths() []string {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	return append([]string(nil), pr.paths...)
+}
+
+func TestPubSubSubscribePattern(t *testing.T) {
+	s := config.MustNewService(config.NewInMemoryStore(), config.WithPubSub())
+
+	var _ config.PatternSubscriber = s
+
+	active := &pathRecorder{}
+	baseURL := &pathRecorder{}
+	all := &pathRecorder{}
+	store2 := &pathRecorder{}
+
+	for _, sub := range []struct {
+		config.Subscription
+		mr config.MessageReceiver
+	}{
+		{config.Subscription{Pattern: "payment/*/active"}, active},
+		{config.Subscription{Pattern: "*/*/base_url"}, baseURL},
+		{config.Subscription{Pattern: "*"}, all},
+		{config.Subscription{Pattern: "payment", ScopeID: scope.Store.Pack(2)}, store2},
+	} {
+		_, err := s.SubscribePattern(sub.Subscription, sub.mr)
+		assert.NoError(t, err)
+	}
+
+	paths := []cfgpath.Path{
+		cfgpath.MustNewByParts("payment/checkmo/active"),
+		cfgpath.MustNewByParts("payment/ccsave/active").BindStore(2),
+		cfgpath.MustNewByParts("payment/ccsave/title").BindStore(2),
+		cfgpath.MustNewByParts("web/secure/base_url").BindWebsite(1),
+		cfgpath.MustNewByParts("web/unsecure/base_url"),
+		cfgpath.MustNewByParts("payment/ccsave/active").BindStore(3),
+	}
+	for _, p := range paths {
+		assert.NoError(t, s.Write(p, 1))
+	}
+	assert.NoError(t, s.Close())
+
+	assert.Exactly(t, []string{
+		"default/0/payment/checkmo/active",
+		"stores/2/payment/ccsave/active",
+		"stores/3/payment/ccsave/active",
+	}, active.Paths())
+	assert.Exactly(t, []string{
+		"websites/1/web/secure/base_url",
+		"default/0/web/unsecure/base_url",
+	}, baseURL.Paths())
+	assert.Len(t, all.Paths(), len(paths))
+	assert.Exactly(t, []string{
+		"stores/2/payment/ccsave/active",
+		"stores/2/payment/ccsave/title",
+	}, store2.Paths())
+}
+
+func TestPubSubSubscribePattern_Errors(t *testing.T) {
+	s := config.MustNewService(config.NewInMemoryStore(), config.WithPubSub())
+
+	_, err := s.SubscribePattern(config.Subscription{}, &pathRecorder{})
+	assert.True(t, errors.IsEmpty(err), "Error: %s", err)
+	_, err = s.SubscribePattern(config.Subscription{Pattern: "payment//active"}, &pathRecorder{})
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+
+	assert.NoError(t, s.Close())
+	_, err = s.SubscribePattern(config.Subscription{Pattern: "*", QueueSize: 1}, &pathRecorder{})
+	assert.True(t, errors.IsAlreadyClosed(err), "Error: %s", err)
+}
+
+func TestPubSubSubscribePattern_Queue(t *testing.T) {
+	s := config.MustNewService(config.NewInMemoryStore(), config.WithPubSub())
+
+	slow := &pathRecorder{block: make(chan struct{})}
+	fast := &pathRecorder{}
+
+	var dropMu sync.Mutex
+	var dropped []string
+	slowID, err := s.SubscribePattern(config.Subscription{
+		Pattern:   "aa/bb",
+		QueueSize: 2,
+		Dropped: func(id int, p cfgpath.Path) {
+			dropMu.Lock()
+			dropped = append(dropped, p.String())
+			dropMu.Unlock()
+		},
+	}, slow)
+	assert.NoError(t, err)
+	_, err = s.SubscribePattern(config.Subscription{Pattern: "aa/bb", QueueSize: 10}, fast)
+	assert.NoError(t, err)
+
+	// the slow receiver blocks on the first message, two messages wait in the
+	// queue and the remaining two messages get dropped.
+	var want []string
+	for i := 0; i < 5; i++ {
+		p := cfgpath.MustNewByParts("aa/bb/cc").BindStore(int64(i))
+		want = append(want, p.String())
+		assert.NoError(t, s.Write(p, i))
+		if i == 0 {
+			time.Sleep(20 * time.Millisecond) // let the slow receiver take the first message
+		}
+	}
+
+	close(slow.block)
+	assert.NoError(t, s.Close()) // waits until the queues are drained
+
+	assert.Exactly(t, want, fast.Paths(), "fast receiver gets all messages in order")
+	assert.Exactly(t, want[:3], slow.Paths())
+	dropMu.Lock()
+	assert.Exactly(t, want[3:], dropped)
+	dropMu.Unlock()
+	assert.NoError(t, s.Unsubscribe(slowID))
+}
+
+func TestPubSubSubscribePattern_Evict(t *testing.T) {
+	s := config.MustNewService(config.NewInMemoryStore(), config.WithPubSub())
+
+	failSync := &pathRecorder{err: errors.New("sync failed")}
+	failQueued := &pathRecorder{err: errors.New("queued failed")}
+	_, err := s.SubscribePattern(config.Subscription{Pattern: "aa"}, failSync)
+	assert.NoError(t, err)
+	_, err = s.SubscribePattern(config.Subscription{Pattern: "aa", QueueSize: 5}, failQueued)
+	assert.NoError(t, err)
+
+	p := cfgpath.MustNewByParts("aa/bb/cc")
+	assert.NoError(t, s.Write(p, 1))
+	time.Sleep(20 * time.Millisecond) // let the queued receiver fail
+	assert.NoError(t, s.Write(p, 2))
+	assert.NoError(t, s.Close())
+
+	assert.Len(t, failSync.Paths(), 1)
+	assert.Len(t, failQueued.Paths(), 1)
+}
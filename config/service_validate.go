@@ -0,0 +1,106 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/cfgsource"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// validate checks a value before writing it. Paths without an element.Field
+// won't be validated. Error behaviour: Unauthorized or NotValid.
+func (s *Service) validate(p cfgpath.Path, v interface{}) error {
+	f, _, err := s.validation.FindField(p.Route)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "[config] Service.validate.FindField Route %q", p.Route)
+	}
+	return errors.Wrapf(validateField(f, p, v), "[config] Service.validate Path %q", p)
+}
+
+// validateField checks the scope permission, the type and the source options
+// of a Field. A nil value resets the path and is always valid.
+func validateField(f element.Field, p cfgpath.Path, v interface{}) error {
+	if f.Scopes > 0 && !f.Scopes.Has(p.ScopeID.Type()) {
+		return errors.NewUnauthorizedf("[config] Scope permission insufficient: Have %q; Want %q; Route: %q", p.ScopeID, f.Scopes, p.Route)
+	}
+	if v == nil {
+		return nil
+	}
+
+	var ft element.FieldType
+	if f.Type != nil {
+		ft = f.Type.Type()
+	}
+
+	if ft != element.TypeMultiselect {
+		if err := validateType(ft, f.Default, v); err != nil {
+			return errors.Wrapf(err, "[config] Route %q", p.Route)
+		}
+		return errors.Wrapf(validateSource(f.Source, v), "[config] Route %q", p.Route)
+	}
+
+	// a multiselect contains comma separated values
+	str, err := conv.ToStringE(v)
+	if err != nil {
+		return errors.NewNotValidf("[config] Multiselect value must be a string: %s", err)
+	}
+	if str == "" {
+		return nil
+	}
+	for _, sv := range strings.Split(str, ",") {
+		if err := validateSource(f.Source, sv); err != nil {
+			return errors.Wrapf(err, "[config] Multiselect Route %q", p.Route)
+		}
+	}
+	return nil
+}
+
+// validateType checks if the value can be converted into the type of the
+// front end field or into the type of the default value.
+func validateType(ft element.FieldType, def, v interface{}) (err error) {
+	switch ft {
+	case element.TypeTime:
+		_, err = conv.ToTimeE(v)
+	case element.TypeDuration:
+		_, err = conv.ToDurationE(v)
+	default:
+		switch def.(type) {
+		case bool:
+			_, err = conv.ToBoolE(v)
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			_, err = conv.ToInt64E(v)
+		case float32, float64:
+			_, err = conv.ToFloat64E(v)
+		}
+	}
+	if err != nil {
+		return errors.NewNotValidf("[config] Type mismatch: %s", err)
+	}
+	return nil
+}
+
+// validateSource checks if the value is one of the options. The type of the
+// first option determines the type of all options.
+func validateSource(src cfgsource.Slice, v interface{}) error {
+	return errors.Wrap(src.Validate(v), "[config] validateSource")
+}
@@ -0,0 +1,124 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/cfgsource"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var validateSections = element.MustNewConfiguration(
+	element.Section{
+		ID: cfgpath.NewRoute(`catalog`),
+		Groups: element.NewGroupSlice(
+			element.Group{
+				ID: cfgpath.NewRoute(`frontend`),
+				Fields: element.NewFieldSlice(
+					element.Field{
+						// showInStore=0
+						ID:      cfgpath.NewRoute(`flat_catalog`),
+						Type:    element.TypeSelect,
+						Scopes:  scope.PermWebsite,
+						Default: true,
+					},
+					element.Field{
+						ID:      cfgpath.NewRoute(`list_mode`),
+						Type:    element.TypeSelect,
+						Scopes:  scope.PermStore,
+						Default: "grid",
+						Source:  cfgsource.NewByStringValue("grid", "list", "grid-list"),
+					},
+					element.Field{
+						ID:      cfgpath.NewRoute(`grid_per_page`),
+						Type:    element.TypeText,
+						Scopes:  scope.PermStore,
+						Default: 12,
+					},
+					element.Field{
+						ID:     cfgpath.NewRoute(`sort_by`),
+						Type:   element.TypeMultiselect,
+						Scopes: scope.PermStore,
+						Source: cfgsource.NewByIntValue(1, 2, 3),
+					},
+					element.Field{
+						ID:     cfgpath.NewRoute(`lifetime`),
+						Type:   element.TypeDuration,
+						Scopes: scope.PermStore,
+					},
+				),
+			},
+		),
+	},
+)
+
+func TestWithFieldValidation(t *testing.T) {
+	srv := config.MustNewService(config.NewInMemoryStore(), config.WithFieldValidation(validateSections))
+
+	route := func(r string) cfgpath.Path { return cfgpath.MustNewByParts("catalog/frontend/" + r) }
+
+	tests := []struct {
+		path    cfgpath.Path
+		val     interface{}
+		errBhf  errors.BehaviourFunc
+		wantVal string
+	}{
+		{route("flat_catalog").BindWebsite(1), 1, nil, "1"},
+		{route("flat_catalog").BindStore(1), 1, errors.IsUnauthorized, ""},
+		{route("flat_catalog"), "yes please", errors.IsNotValid, ""},
+		{route("list_mode").BindStore(2), "list", nil, "list"},
+		{route("list_mode").BindStore(2), "table", errors.IsNotValid, ""},
+		{route("grid_per_page").BindStore(2), "24", nil, "24"},
+		{route("grid_per_page").BindStore(2), "many", errors.IsNotValid, ""},
+		{route("sort_by").BindStore(2), "1,3", nil, "1,3"},
+		{route("sort_by").BindStore(2), "", nil, ""},
+		{route("sort_by").BindStore(2), "1,4", errors.IsNotValid, ""},
+		{route("sort_by").BindStore(2), "1,name", errors.IsNotValid, ""},
+		{route("lifetime").BindStore(2), "1h", nil, "1h"},
+		{route("lifetime").BindStore(2), time.Minute, nil, "1m0s"},
+		{route("lifetime").BindStore(2), "a while", errors.IsNotValid, ""},
+		// nil resets a value
+		{route("list_mode").BindStore(3), nil, nil, ""},
+		// no element.Field defined
+		{cfgpath.MustNewByParts("catalog/frontend/unknown").BindStore(3), "x", nil, "x"},
+		{cfgpath.MustNewByParts("aa/bb/cc").BindStore(3), "x", nil, "x"},
+	}
+	for i, test := range tests {
+		err := srv.Write(test.path, test.val)
+		if test.errBhf != nil {
+			assert.True(t, test.errBhf(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		if test.val != nil {
+			have, err := srv.String(test.path)
+			assert.NoError(t, err, "Index %d", i)
+			assert.Exactly(t, test.wantVal, have, "Index %d", i)
+		}
+	}
+}
+
+func TestWithFieldValidation_Disabled(t *testing.T) {
+	srv := config.MustNewService(config.NewInMemoryStore())
+	p := cfgpath.MustNewByParts("catalog/frontend/flat_catalog").BindStore(1)
+	assert.NoError(t, srv.Write(p, "yes please"))
+}
@@ -0,0 +1,253 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/boltdb/bolt"
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+var errKeyNotFound = errors.NewNotFoundf(`[boltdb] Key not found`)
+
+// Storage wrapper around the bolt.DB type. Implements interface
+// config.Storager.
+type Storage struct {
+	DB *bolt.DB
+}
+
+// New uses an existing bolt database.
+func New(db *bolt.DB) *Storage {
+	return &Storage{
+		DB: db,
+	}
+}
+
+// NewFile creates and opens a bolt database at the given path. If the file
+// does not exist then it will be created automatically. If the third argument
+// Options doesn't get applied bolt.DefaultOptions will be used.
+func NewFile(path string, mode os.FileMode, options ...*bolt.Options) (*Storage, error) {
+	var opt = bolt.DefaultOptions
+	if len(options) == 1 {
+		opt = options[0]
+	}
+	db, err := bolt.Open(path, mode, opt)
+	if err != nil {
+		return nil, errors.NewFatalf("[boltdb] bolt.Open: %s", err)
+	}
+	return New(db), nil
+}
+
+// Close closes the underlying bolt database.
+func (s *Storage) Close() error {
+	return errors.Wrap(s.DB.Close(), "[boltdb] DB.Close")
+}
+
+// split separates the fully qualified path into the bucket name containing
+// the scope and its ID and into the route.
+//		stores/2/aa/bb/cc => stores/2 and aa/bb/cc
+func split(p cfgpath.Path) (bucket, route []byte, err error) {
+	fq, err := p.FQ()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "[boltdb] Path.FQ")
+	}
+	first := bytes.IndexByte(fq.Chars, cfgpath.Separator)
+	second := bytes.IndexByte(fq.Chars[first+1:], cfgpath.Separator) + first + 1
+	return fq.Chars[:second], fq.Chars[second+1:], nil
+}
+
+// join reverses the function split.
+func join(bucket, route []byte) (cfgpath.Path, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(bucket) + len(route) + 1)
+	_, _ = buf.Write(bucket)
+	_ = buf.WriteByte(cfgpath.Separator)
+	_, _ = buf.Write(route)
+	p, err := cfgpath.SplitFQ(buf.String())
+	if err != nil {
+		return cfgpath.Path{}, errors.Wrapf(err, "[boltdb] cfgpath.SplitFQ Bucket %q Route %q", bucket, route)
+	}
+	return p, nil
+}
+
+// Set writes a key with its value into the bucket of the scope. The value gets
+// converted to a byte slice.
+func (s *Storage) Set(key cfgpath.Path, value interface{}) error {
+	bucket, route, err := split(key)
+	if err != nil {
+		return errors.Wrap(err, "[boltdb] Set.split")
+	}
+	b, err := conv.ToByteE(value)
+	if err != nil {
+		return errors.Wrapf(err, "[boltdb] Set.conv.ToByteE Key: %q Value: %v", key, value)
+	}
+	err = s.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists(bucket)
+		if err != nil {
+			return errors.NewFatalf("[boltdb] bolt.CreateBucketIfNotExists %q: %s", bucket, err)
+		}
+		if err := bkt.Put(route, b); err != nil {
+			return errors.NewFatalf("[boltdb] Bucket.Put %q: %s", route, err)
+		}
+		return nil
+	})
+	return errors.Wrap(err, "[boltdb] Set.DB.Update")
+}
+
+// Get returns a copied byte slice from the bucket of the scope. Error
+// behaviour: NotFound.
+func (s *Storage) Get(key cfgpath.Path) (interface{}, error) {
+	bucket, route, err := split(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "[boltdb] Get.split")
+	}
+
+	var buf []byte
+	if err := s.DB.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucket)
+		if bkt == nil {
+			return nil
+		}
+		if v := bkt.Get(route); v != nil {
+			buf = make([]byte, len(v))
+			copy(buf, v)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.NewFatalf("[boltdb] Get.DB.View: %s", err)
+	}
+	if buf == nil {
+		return nil, errKeyNotFound
+	}
+	return buf, nil
+}
+
+// AllKeys returns all keys of all scopes.
+func (s *Storage) AllKeys() (cfgpath.PathSlice, error) {
+	return s.PrefixKeys(cfgpath.Route{})
+}
+
+// PrefixKeys returns all keys of all scopes whose route starts with the
+// provided route. An empty route returns all keys.
+//		PrefixKeys(cfgpath.NewRoute("payment/")) => all payment keys
+func (s *Storage) PrefixKeys(r cfgpath.Route) (cfgpath.PathSlice, error) {
+	var ret cfgpath.PathSlice
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(bucket []byte, bkt *bolt.Bucket) error {
+			c := bkt.Cursor()
+			for k, _ := c.Seek(r.Chars); k != nil && bytes.HasPrefix(k, r.Chars); k, _ = c.Next() {
+				p, err := join(bucket, k)
+				if err != nil {
+					return errors.Wrap(err, "[boltdb] PrefixKeys.join")
+				}
+				ret = append(ret, p)
+			}
+			return nil
+		})
+	})
+	return ret, errors.Wrap(err, "[boltdb] PrefixKeys.DB.View")
+}
+
+// Import copies all values from the source Storager, for example ccd.DBStorage,
+// into the bolt database within one transaction. Existing values get
+// overwritten. Keys which cannot be found in the source will be skipped.
+// Returns the number of imported values.
+func (s *Storage) Import(src config.Storager) (int, error) {
+	keys, err := src.AllKeys()
+	if err != nil {
+		return 0, errors.Wrap(err, "[boltdb] Import.AllKeys")
+	}
+
+	type keyVal struct {
+		bucket, route, value []byte
+	}
+	var kvs = make([]keyVal, 0, len(keys))
+	for _, p := range keys {
+		v, err := src.Get(p)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return 0, errors.Wrapf(err, "[boltdb] Import.Get Key: %q", p)
+		}
+		b, err := conv.ToByteE(v)
+		if err != nil {
+			return 0, errors.Wrapf(err, "[boltdb] Import.conv.ToByteE Key: %q Value: %v", p, v)
+		}
+		bucket, route, err := split(p)
+		if err != nil {
+			return 0, errors.Wrap(err, "[boltdb] Import.split")
+		}
+		kvs = append(kvs, keyVal{bucket, route, b})
+	}
+
+	err = s.DB.Update(func(tx *bolt.Tx) error {
+		for _, kv := range kvs {
+			bkt, err := tx.CreateBucketIfNotExists(kv.bucket)
+			if err != nil {
+				return errors.NewFatalf("[boltdb] bolt.CreateBucketIfNotExists %q: %s", kv.bucket, err)
+			}
+			if err := bkt.Put(kv.route, kv.value); err != nil {
+				return errors.NewFatalf("[boltdb] Bucket.Put %q: %s", kv.route, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "[boltdb] Import.DB.Update")
+	}
+	return len(kvs), nil
+}
+
+// Export writes all values of the bolt database into the destination
+// Storager, for example ccd.DBStorage. Returns the number of exported values.
+func (s *Storage) Export(dst config.Storager) (int, error) {
+	type pathVal struct {
+		p cfgpath.Path
+		v []byte
+	}
+	var pvs []pathVal
+	// collect first, so that the bolt transaction does not block while
+	// writing into the destination.
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(bucket []byte, bkt *bolt.Bucket) error {
+			return bkt.ForEach(func(k, v []byte) error {
+				p, err := join(bucket, k)
+				if err != nil {
+					return errors.Wrap(err, "[boltdb] Export.join")
+				}
+				buf := make([]byte, len(v))
+				copy(buf, v)
+				pvs = append(pvs, pathVal{p, buf})
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "[boltdb] Export.DB.View")
+	}
+
+	for i, pv := range pvs {
+		if err := dst.Set(pv.p, pv.v); err != nil {
+			return i, errors.Wrapf(err, "[boltdb] Export.Set Key: %q", pv.p)
+		}
+	}
+	return len(pvs), nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/storage/boltdb"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ config.Storager = (*boltdb.Storage)(nil)
+
+func newStorage(t *testing.T) (*boltdb.Storage, func()) {
+	dir, err := ioutil.TempDir("", "csfw_boltdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := boltdb.NewFile(filepath.Join(dir, "config.db"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, func() {
+		assert.NoError(t, s.Close())
+		assert.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func TestStorage_SetGet(t *testing.T) {
+	s, closer := newStorage(t)
+	defer closer()
+
+	tests := []struct {
+		key cfgpath.Path
+		val interface{}
+	}{
+		{cfgpath.MustNewByParts("aa/bb/cc"), 12345},
+		{cfgpath.MustNewByParts("aa/bb/cc").BindWebsite(3), "Gopher"},
+		{cfgpath.MustNewByParts("aa/bb/cc").BindStore(4), 3.14159},
+		{cfgpath.MustNewByParts("aa/bb/cc").BindStore(4), 2.71828}, // overwrite
+	}
+	for idx, test := range tests {
+		assert.NoError(t, s.Set(test.key, test.val), "Index %d", idx)
+		haveVal, haveErr := s.Get(test.key)
+		assert.NoError(t, haveErr, "Index %d", idx)
+		assert.Exactly(t, conv.ToString(test.val), conv.ToString(haveVal), "Index %d", idx)
+	}
+}
+
+func TestStorage_GetNotFound(t *testing.T) {
+	s, closer := newStorage(t)
+	defer closer()
+
+	// bucket does not exists
+	v, err := s.Get(cfgpath.MustNewByParts("xx/yy/zz").BindStore(2))
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	assert.Nil(t, v)
+
+	// bucket exists but key not
+	assert.NoError(t, s.Set(cfgpath.MustNewByParts("xx/yy/aa").BindStore(2), 1))
+	v, err = s.Get(cfgpath.MustNewByParts("xx/yy/zz").BindStore(2))
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	assert.Nil(t, v)
+
+	_, err = s.Get(cfgpath.Path{})
+	assert.True(t, errors.IsEmpty(err), "Error: %s", err)
+}
+
+func pathStrings(ps cfgpath.PathSlice) []string {
+	ret := make([]string, len(ps))
+	for i, p := range ps {
+		ret[i] = p.String()
+	}
+	return ret
+}
+
+func TestStorage_AllKeys_PrefixKeys(t *testing.T) {
+	s, closer := newStorage(t)
+	defer closer()
+
+	keys := cfgpath.PathSlice{
+		cfgpath.MustNewByParts("payment/checkmo/active"),
+		cfgpath.MustNewByParts("payment/checkmo/active").BindStore(1),
+		cfgpath.MustNewByParts("payment/ccsave/title").BindWebsite(2),
+		cfgpath.MustNewByParts("web/secure/base_url").BindStore(1),
+	}
+	for _, p := range keys {
+		assert.NoError(t, s.Set(p, "x"))
+	}
+
+	all, err := s.AllKeys()
+	assert.NoError(t, err)
+	assert.Exactly(t, []string{
+		"default/0/payment/checkmo/active",
+		"stores/1/payment/checkmo/active",
+		"stores/1/web/secure/base_url",
+		"websites/2/payment/ccsave/title",
+	}, pathStrings(all))
+
+	payment, err := s.PrefixKeys(cfgpath.NewRoute("payment/"))
+	assert.NoError(t, err)
+	assert.Exactly(t, []string{
+		"default/0/payment/checkmo/active",
+		"stores/1/payment/checkmo/active",
+		"websites/2/payment/ccsave/title",
+	}, pathStrings(payment))
+}
+
+func TestStorage_ImportExport(t *testing.T) {
+	s, closer := newStorage(t)
+	defer closer()
+
+	src := config.NewInMemoryStore()
+	keys := cfgpath.PathSlice{
+		cfgpath.MustNewByParts("general/locale/code").BindStore(1),
+		cfgpath.MustNewByParts("general/locale/code").BindWebsite(1),
+		cfgpath.MustNewByParts("general/locale/timezone"),
+	}
+	for i, p := range keys {
+		assert.NoError(t, src.Set(p, i))
+	}
+
+	n, err := s.Import(src)
+	assert.NoError(t, err)
+	assert.Exactly(t, len(keys), n)
+
+	dst := config.NewInMemoryStore()
+	n, err = s.Export(dst)
+	assert.NoError(t, err)
+	assert.Exactly(t, len(keys), n)
+
+	for i, p := range keys {
+		v, err := dst.Get(p)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, conv.ToString(i), conv.ToString(v), "Index %d", i)
+	}
+}
+
+type errStorage struct {
+	config.Storager
+	err error
+}
+
+func (es errStorage) Set(_ cfgpath.Path, _ interface{}) error { return es.err }
+func (es errStorage) AllKeys() (cfgpath.PathSlice, error) { return nil, es.err }
+
+func TestStorage_ImportExport_Error(t *testing.T) {
+	s, closer := newStorage(t)
+	defer closer()
+	assert.NoError(t, s.Set(cfgpath.MustNewByParts("aa/bb/cc"), 1))
+
+	es := errStorage{err: errors.NewFatalf("Database gone")}
+	n, err := s.Import(es)
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+	assert.Exactly(t, 0, n)
+
+	n, err = s.Export(es)
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+	assert.Exactly(t, 0, n)
+}
@@ -15,6 +15,17 @@
 // Package boltdb uses the bolt database for reading and writing
 // configuration paths.
 //
-// Maybe implements synchronization with MySQL core_config_data table.
-// Converts all values to byte slices.
+// Each scope and its ID gets its own bucket, for example "default/0",
+// "websites/1" or "stores/2". The keys within a bucket are the routes like
+// "web/secure/base_url". Converts all values to byte slices.
+//
+// The functions Import and Export copy all values from and to another
+// config.Storager. With the MySQL based ccd.DBStorage a node can create a
+// local snapshot of the table core_config_data and boot offline from that
+// single file:
+//		bs, err := boltdb.NewFile("core_config_data.db", 0600)
+//		// online: write the snapshot
+//		n, err := bs.Import(ccd.MustNewDBStorage(db))
+//		// offline: use the snapshot
+//		cfgSrv := config.MustNewService(bs)
 package boltdb
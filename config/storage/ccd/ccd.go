@@ -16,12 +16,14 @@ package ccd
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/corestoreio/csfw/config/cfgpath"
 	"github.com/corestoreio/csfw/log"
 	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/storage/dbr"
 	"github.com/corestoreio/csfw/store/scope"
 	"github.com/corestoreio/csfw/util/conv"
 	"github.com/corestoreio/csfw/util/errors"
@@ -56,21 +58,24 @@ func NewDBStorage(p csdb.Preparer) (*DBStorage, error) {
 	// for continuously reading from the error channel. or we accept an error channel
 	// as argument here and then writing to it ...
 
+	tn := TableCollection.Name(TableIndexCoreConfigData)
+	writeSQL, _, err := (&dbr.Insert{Into: dbr.Quoter.QuoteAs(tn)}).
+		Columns("scope", "scope_id", "path", "value").
+		Values(nil, nil, nil, nil).
+		OnDuplicateKeyValues("value").
+		ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "[ccd] NewDBStorage.Insert.ToSQL")
+	}
+
 	dbs := &DBStorage{
 		log: log.BlackHole{}, // skip debug and info level via init with empty fields
 		All: csdb.NewResurrectStmt(p, fmt.Sprintf(
-			"SELECT scope,scope_id,path FROM `%s` ORDER BY scope,scope_id,path",
-			TableCollection.Name(TableIndexCoreConfigData),
-		)),
+			"SELECT scope,scope_id,path FROM `%s` ORDER BY scope,scope_id,path", tn)),
 		Read: csdb.NewResurrectStmt(p, fmt.Sprintf(
-			"SELECT `value` FROM `%s` WHERE `scope`=? AND `scope_id`=? AND `path`=?",
-			TableCollection.Name(TableIndexCoreConfigData),
-		)),
+			"SELECT `value` FROM `%s` WHERE `scope`=? AND `scope_id`=? AND `path`=?", tn)),
 
-		Write: csdb.NewResurrectStmt(p, fmt.Sprintf(
-			"INSERT INTO `%s` (`scope`,`scope_id`,`path`,`value`) VALUES (?,?,?,?) ON DUPLICATE KEY UPDATE `value`=?",
-			TableCollection.Name(TableIndexCoreConfigData),
-		)),
+		Write: csdb.NewResurrectStmt(p, writeSQL),
 	}
 	dbs.All.Idle = time.Second * 15
 	dbs.All.Log = dbs.log
@@ -78,7 +83,6 @@ func NewDBStorage(p csdb.Preparer) (*DBStorage, error) {
 	dbs.Read.Log = dbs.log
 	dbs.Write.Idle = time.Second * 30
 	dbs.Write.Log = dbs.log
-	// in the future we may add errors ... just to have for now the func signature
 	return dbs, nil
 }
 
@@ -127,6 +131,12 @@ func (dbs *DBStorage) Stop() error {
 // Set sets a value with its key. Database errors get logged as Info message.
 // Enabled debug level logs the insert ID or rows affected.
 func (dbs *DBStorage) Set(key cfgpath.Path, value interface{}) error {
+	return dbs.SetContext(context.Background(), key, value)
+}
+
+// SetContext same as Set but the context gets used to prepare and execute the
+// statement.
+func (dbs *DBStorage) SetContext(ctx context.Context, key cfgpath.Path, value interface{}) error {
 	// update lastUsed at the end because there might be the slight chance that
 	// a statement gets closed despite we're waiting for the result from the
 	// server.
@@ -138,7 +148,7 @@ func (dbs *DBStorage) Set(key cfgpath.Path, value interface{}) error {
 		return errors.Wrapf(err, "[ccd] Set.conv.ToStringE. SQL: %q Key: %q Value: %v", dbs.Write.SQL, key, value)
 	}
 
-	stmt, err := dbs.Write.Stmt(context.TODO())
+	stmt, err := dbs.Write.Stmt(ctx)
 	if err != nil {
 		return errors.Wrapf(err, "[ccd] Set.Write.Stmt. SQL: %q Key: %q", dbs.Write.SQL, key)
 	}
@@ -149,9 +159,9 @@ func (dbs *DBStorage) Set(key cfgpath.Path, value interface{}) error {
 	}
 
 	scp, id := key.ScopeID.Unpack()
-	result, err := stmt.Exec(scp.StrType(), id, pathLeveled, valStr, valStr)
+	result, err := stmt.ExecContext(ctx, scp.StrType(), id, pathLeveled, valStr)
 	if err != nil {
-		return errors.Wrapf(err, "[ccd] Set.stmt.Exec. SQL: %q KeyID: %d Scope: %q Path: %q Value: %q", dbs.Write.SQL, id, scp, pathLeveled, valStr)
+		return errors.Wrapf(err, "[ccd] Set.stmt.ExecContext. SQL: %q KeyID: %d Scope: %q Path: %q Value: %q", dbs.Write.SQL, id, scp, pathLeveled, valStr)
 	}
 	if dbs.log.IsDebug() {
 		li, err1 := result.LastInsertId()
@@ -174,13 +184,19 @@ func (dbs *DBStorage) Set(key cfgpath.Path, value interface{}) error {
 // type in the empty interface is a string. It returns nil on error but errors
 // get logged as info message. Error behaviour: NotFound
 func (dbs *DBStorage) Get(key cfgpath.Path) (interface{}, error) {
+	return dbs.GetContext(context.Background(), key)
+}
+
+// GetContext same as Get but the context gets used to prepare and execute the
+// statement.
+func (dbs *DBStorage) GetContext(ctx context.Context, key cfgpath.Path) (interface{}, error) {
 	// update lastUsed at the end because there might be the slight chance that
 	// a statement gets closed despite we're waiting for the result from the
 	// server.
 	dbs.Read.StartStmtUse()
 	defer dbs.Read.StopStmtUse()
 
-	stmt, err := dbs.Read.Stmt(context.TODO())
+	stmt, err := dbs.Read.Stmt(ctx)
 	if err != nil {
 		return nil, errors.Wrapf(err, "[ccd] Get.Read.Stmt. SQL: %q Key: %q", dbs.Read.SQL, key)
 	}
@@ -192,7 +208,10 @@ func (dbs *DBStorage) Get(key cfgpath.Path) (interface{}, error) {
 
 	var data null.String
 	scp, id := key.ScopeID.Unpack()
-	err = stmt.QueryRow(scp.StrType(), id, pl).Scan(&data)
+	err = stmt.QueryRowContext(ctx, scp.StrType(), id, pl).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, errKeyNotFound
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "[ccd] Get.QueryRow. SQL: %q Key: %q PathLevel: %q", dbs.Read.SQL, key, pl)
 	}
@@ -206,18 +225,24 @@ var errKeyNotFound = errors.NewNotFoundf(`[ccd] Key not found`) // todo add test
 
 // AllKeys returns all available keys. Database errors get logged as info message.
 func (dbs *DBStorage) AllKeys() (cfgpath.PathSlice, error) {
+	return dbs.AllKeysContext(context.Background())
+}
+
+// AllKeysContext same as AllKeys but the context gets used to prepare and
+// execute the statement.
+func (dbs *DBStorage) AllKeysContext(ctx context.Context) (cfgpath.PathSlice, error) {
 	// update lastUsed at the end because there might be the slight chance
 	// that a statement gets closed despite we're waiting for the result
 	// from the server.
 	dbs.All.StartStmtUse()
 	defer dbs.All.StopStmtUse()
 
-	stmt, err := dbs.All.Stmt(context.TODO())
+	stmt, err := dbs.All.Stmt(ctx)
 	if err != nil {
 		return nil, errors.Wrapf(err, "[ccd] AllKeys.All.Stmt. SQL: %q", dbs.All.SQL)
 	}
 
-	rows, err := stmt.Query()
+	rows, err := stmt.QueryContext(ctx)
 	if err != nil {
 		return nil, errors.Wrapf(err, "[ccd] AllKeys.All.Query. SQL: %q", dbs.All.SQL)
 	}
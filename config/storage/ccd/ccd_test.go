@@ -26,6 +26,7 @@ import (
 	"github.com/corestoreio/csfw/config/storage/ccd"
 	"github.com/corestoreio/csfw/store/scope"
 	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/csfw/util/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -64,15 +65,14 @@ func TestDBStorageOneStmt(t *testing.T) {
 		{cfgpath.MustNewByParts("testDBStorage/catalog/clean").Bind(scope.DefaultTypeID), 0, false, "0"},
 	}
 
-	prepIns := dbMock.ExpectPrepare("INSERT INTO `[^`]+` \\(.+\\) VALUES \\(\\?,\\?,\\?,\\?\\) ON DUPLICATE KEY UPDATE `value`=\\?")
+	prepIns := dbMock.ExpectPrepare("INSERT INTO `[^`]+` \\(.+\\) VALUES \\(\\?,\\?,\\?,\\?\\) ON DUPLICATE KEY UPDATE `value`=VALUES\\(`value`\\)")
 	for i, test := range tests {
 
 		prepIns.ExpectExec().WithArgs(
 			driver.Value(test.key.ScopeID.Type().StrType()),
 			driver.Value(test.key.ScopeID.ID()),
 			driver.Value(test.key.Bytes()),
-			driver.Value(test.wantValue), // value
-			driver.Value(test.wantValue), // value fall back on duplicate key
+			driver.Value(test.wantValue),
 		).WillReturnResult(sqlmock.NewResult(0, 1))
 
 		if err := sdb.Set(test.key, test.value); err != nil {
@@ -155,15 +155,14 @@ func TestDBStorageMultipleStmt_Set(t *testing.T) {
 	var prepIns *sqlmock.ExpectedPrepare
 	for i, test := range dbStorageMultiTests {
 		if i < 3 {
-			prepIns = dbMock.ExpectPrepare("INSERT INTO `[^`]+` \\(.+\\) VALUES \\(\\?,\\?,\\?,\\?\\) ON DUPLICATE KEY UPDATE `value`=\\?")
+			prepIns = dbMock.ExpectPrepare("INSERT INTO `[^`]+` \\(.+\\) VALUES \\(\\?,\\?,\\?,\\?\\) ON DUPLICATE KEY UPDATE `value`=VALUES\\(`value`\\)")
 		}
 
 		prepIns.ExpectExec().WithArgs(
 			driver.Value(test.key.ScopeID.Type().StrType()),
 			driver.Value(test.key.ScopeID.ID()),
 			driver.Value(test.key.Bytes()),
-			driver.Value(test.wantValue), // value
-			driver.Value(test.wantValue), // value fall back on duplicate key
+			driver.Value(test.wantValue),
 		).WillReturnResult(sqlmock.NewResult(0, 1))
 
 		if err := sdb.Set(test.key, test.value); err != nil {
@@ -270,3 +269,29 @@ func TestDBStorageMultipleStmt_All(t *testing.T) {
 	assert.NoError(t, sdb.Stop())
 
 }
+
+func TestDBStorage_Get_NotFound(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	sdb := ccd.MustNewDBStorage(dbc.DB)
+	key := cfgpath.MustNewByParts("testDBStorage/log/missing").BindStore(3)
+
+	dbMock.ExpectPrepare("SELECT `value` FROM `[^`]+` WHERE `scope`=\\? AND `scope_id`=\\? AND `path`=\\?").
+		ExpectQuery().WithArgs(
+		driver.Value(key.ScopeID.Type().StrType()),
+		driver.Value(key.ScopeID.ID()),
+		driver.Value(key.Bytes()),
+	).WillReturnRows(sqlmock.NewRows([]string{"value"}))
+
+	v, err := sdb.Get(key)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	assert.Nil(t, v)
+	assert.NoError(t, sdb.Stop())
+}
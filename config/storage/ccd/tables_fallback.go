@@ -22,6 +22,7 @@ package ccd
 
 import (
 	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/util/errors"
 	"github.com/corestoreio/csfw/util/null"
 )
 
@@ -60,3 +61,59 @@ type TableCoreConfigData struct {
 	Path     string      `db:"path" json:",omitempty"`      // path varchar(255) NOT NULL  DEFAULT 'general'
 	Value    null.String `db:"value" json:",omitempty"`     // value text NULL
 }
+
+// RowScanArgs appends a new *TableCoreConfigData and returns the addresses of
+// its fields for the columns. Implements interface dbr.RowScanner.
+// Generated via tableToStruct.
+func (s *TableCoreConfigDataSlice) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	e := new(TableCoreConfigData)
+	*s = append(*s, e)
+	return e.RowScanArgs(columns, args)
+}
+
+// RowScanArgs returns the addresses of the fields for the columns. Unknown
+// columns get discarded. Implements interface dbr.RowScanner.
+// Generated via tableToStruct.
+func (e *TableCoreConfigData) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	args = args[:0]
+	for _, c := range columns {
+		switch c {
+		case "config_id":
+			args = append(args, &e.ConfigID)
+		case "scope":
+			args = append(args, &e.Scope)
+		case "scope_id":
+			args = append(args, &e.ScopeID)
+		case "path":
+			args = append(args, &e.Path)
+		case "value":
+			args = append(args, &e.Value)
+		default:
+			args = append(args, nil)
+		}
+	}
+	return args, nil
+}
+
+// AssembleArguments appends the values of the fields for the columns.
+// Implements interface dbr.ArgumentAssembler.
+// Generated via tableToStruct.
+func (e *TableCoreConfigData) AssembleArguments(columns []string, args []interface{}) ([]interface{}, error) {
+	for _, c := range columns {
+		switch c {
+		case "config_id":
+			args = append(args, e.ConfigID)
+		case "scope":
+			args = append(args, e.Scope)
+		case "scope_id":
+			args = append(args, e.ScopeID)
+		case "path":
+			args = append(args, e.Path)
+		case "value":
+			args = append(args, e.Value)
+		default:
+			return nil, errors.NewNotFoundf("[ccd] TableCoreConfigData.AssembleArguments: Unknown column %q", c)
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package envflag overrides configuration paths with environment variables
+// and command line flags.
+//
+// An environment variable starts with the Prefix, default "CS_CONFIG__". The
+// parts of the route are separated by two underscores. An optional suffix
+// binds the path to a website or store, either by code or by ID:
+//		CS_CONFIG__WEB__SECURE__BASE_URL=https://x.com/               default/0/web/secure/base_url
+//		CS_CONFIG__WEB__SECURE__BASE_URL__WEBSITE__EURO=https://x.eu/ websites/1/web/secure/base_url
+//		CS_CONFIG__WEB__SECURE__BASE_URL__STORE__DE=https://x.de/     stores/1/web/secure/base_url
+//		CS_CONFIG__WEB__SECURE__BASE_URL__STORE__2=https://x.at/      stores/2/web/secure/base_url
+//
+// The name of a flag is either the route or the scope, code and route
+// separated by slashes. Only flags which have been set on the command line
+// are overrides. Flags take precedence over environment variables.
+//		-web/secure/base_url=https://x.com/
+//		-stores/de/web/secure/base_url=https://x.de/
+//
+// The Storage is read only. Stacked in front of another Storager, writes get
+// forwarded to and missing paths get read from the next Storager:
+//		s, err := envflag.New(
+//			envflag.WithEnviron(os.Environ()),
+//			envflag.WithFlagSet(flag.CommandLine),
+//			envflag.WithCodeResolver(cfgfile.NewStoreResolver(storeService)),
+//			envflag.WithNext(ccd.MustNewDBStorage(db)),
+//		)
+//		cfgSrv := config.MustNewService(s)
+package envflag
@@ -0,0 +1,281 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envflag
+
+import (
+	"flag"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// DefaultPrefix of the environment variables.
+const DefaultPrefix = "CS_CONFIG__"
+
+// envSeparator separates the parts of an environment variable.
+const envSeparator = "__"
+
+var errKeyNotFound = errors.NewNotFoundf(`[envflag] Key not found`)
+
+// Storage contains the overridden paths. Implements interface config.Storager.
+// Please use the New() function.
+type Storage struct {
+	// Next if set receives all writes and gets asked for paths which are not
+	// overridden.
+	Next config.Storager
+	// overrides key is the fully qualified path.
+	overrides map[string]override
+}
+
+type override struct {
+	path  cfgpath.Path
+	value string
+}
+
+// CodeResolver converts the codes of websites and stores into their IDs. The
+// resolver of cfgfile.NewStoreResolver implements the interface.
+type CodeResolver interface {
+	WebsiteID(code string) (int64, error)
+	StoreID(code string) (int64, error)
+}
+
+// options collects the sources until all options have been applied.
+type options struct {
+	prefix   string
+	environ  []string
+	flagSets []*flag.FlagSet
+	resolver CodeResolver
+	next     config.Storager
+}
+
+// New creates a new Storage and parses the environment variables and flags.
+// The codes of websites and stores get resolved with the CodeResolver.
+// Error behaviour: NotValid or NotFound.
+func New(opts ...Option) (*Storage, error) {
+	o := &options{
+		prefix: DefaultPrefix,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt(o); err != nil {
+				return nil, errors.Wrap(err, "[envflag] New.Option")
+			}
+		}
+	}
+
+	s := &Storage{
+		Next:      o.next,
+		overrides: make(map[string]override),
+	}
+	for _, kv := range o.environ {
+		if !strings.HasPrefix(kv, o.prefix) {
+			continue
+		}
+		eq := strings.IndexByte(kv, '=')
+		if eq < 0 {
+			continue
+		}
+		p, err := o.parseEnv(kv[len(o.prefix):eq])
+		if err != nil {
+			return nil, errors.Wrapf(err, "[envflag] New.parseEnv %q", kv[:eq])
+		}
+		if err := s.add(p, kv[eq+1:]); err != nil {
+			return nil, errors.Wrapf(err, "[envflag] New.Environment %q", kv[:eq])
+		}
+	}
+
+	var err error
+	for _, fs := range o.flagSets {
+		fs.Visit(func(f *flag.Flag) {
+			if err != nil || strings.Count(f.Name, "/") < 2 {
+				return // not a config path
+			}
+			var p cfgpath.Path
+			if p, err = o.parseFlag(f.Name); err != nil {
+				err = errors.Wrapf(err, "[envflag] New.parseFlag %q", f.Name)
+				return
+			}
+			err = errors.Wrapf(s.add(p, f.Value.String()), "[envflag] New.Flag %q", f.Name)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// MustNew same as New but panics on error.
+func MustNew(opts ...Option) *Storage {
+	s, err := New(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Storage) add(p cfgpath.Path, v string) error {
+	fq, err := p.FQ()
+	if err != nil {
+		return errors.Wrap(err, "[envflag] Path.FQ")
+	}
+	s.overrides[fq.String()] = override{path: p, value: v}
+	return nil
+}
+
+// resolve converts a code or an ID of a website or store into a TypeID.
+func (o *options) resolve(scp scope.Type, code string) (scope.TypeID, error) {
+	code = strings.ToLower(code)
+	if id, err := strconv.ParseInt(code, 10, 64); err == nil {
+		return scp.Pack(id), nil
+	}
+	if o.resolver == nil {
+		return 0, errors.NewNotValidf("[envflag] Missing CodeResolver for %s code %q", scp, code)
+	}
+	var id int64
+	var err error
+	switch scp {
+	case scope.Website:
+		id, err = o.resolver.WebsiteID(code)
+	case scope.Store:
+		id, err = o.resolver.StoreID(code)
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "[envflag] Resolve %s code %q", scp, code)
+	}
+	return scp.Pack(id), nil
+}
+
+// scopeByName returns the scope type of the scope part in an environment
+// variable or flag name.
+func scopeByName(name string) scope.Type {
+	switch strings.ToLower(name) {
+	case "website", "websites":
+		return scope.Website
+	case "store", "stores":
+		return scope.Store
+	}
+	return scope.Absent
+}
+
+// parseEnv parses the name of an environment variable without prefix:
+//		WEB__SECURE__BASE_URL__STORE__DE
+func (o *options) parseEnv(name string) (cfgpath.Path, error) {
+	parts := strings.Split(strings.ToLower(name), envSeparator)
+	id := scope.DefaultTypeID
+	if l := len(parts); l > cfgpath.Levels+1 {
+		if scp := scopeByName(parts[l-2]); scp > scope.Absent {
+			var err error
+			if id, err = o.resolve(scp, parts[l-1]); err != nil {
+				return cfgpath.Path{}, errors.Wrap(err, "[envflag] parseEnv")
+			}
+			parts = parts[:l-2]
+		}
+	}
+	p, err := cfgpath.NewByParts(strings.Join(parts, "/"))
+	if err != nil {
+		return cfgpath.Path{}, errors.Wrap(err, "[envflag] parseEnv.NewByParts")
+	}
+	return p.Bind(id), nil
+}
+
+// parseFlag parses the name of a flag:
+//		web/secure/base_url
+//		stores/de/web/secure/base_url
+func (o *options) parseFlag(name string) (cfgpath.Path, error) {
+	id := scope.DefaultTypeID
+	route := name
+	parts := strings.SplitN(name, "/", 3)
+	switch {
+	case parts[0] == scope.StrDefault.String() && parts[1] == "0":
+		route = parts[2]
+	case scopeByName(parts[0]) > scope.Absent:
+		var err error
+		if id, err = o.resolve(scopeByName(parts[0]), parts[1]); err != nil {
+			return cfgpath.Path{}, errors.Wrap(err, "[envflag] parseFlag")
+		}
+		route = parts[2]
+	}
+	p, err := cfgpath.NewByParts(route)
+	if err != nil {
+		return cfgpath.Path{}, errors.Wrap(err, "[envflag] parseFlag.NewByParts")
+	}
+	return p.Bind(id), nil
+}
+
+// Set forwards the value to the Next Storager. An overridden path still
+// returns the overridden value. Error behaviour: NotSupported if Next is nil.
+func (s *Storage) Set(key cfgpath.Path, value interface{}) error {
+	if s.Next == nil {
+		return errors.NewNotSupportedf("[envflag] Storage is read only. Key %q", key)
+	}
+	return errors.Wrap(s.Next.Set(key, value), "[envflag] Next.Set")
+}
+
+// Get returns the overridden value as a string or asks the Next Storager.
+// Error behaviour: NotFound.
+func (s *Storage) Get(key cfgpath.Path) (interface{}, error) {
+	fq, err := key.FQ()
+	if err != nil {
+		return nil, errors.Wrap(err, "[envflag] Get.FQ")
+	}
+	if o, ok := s.overrides[fq.String()]; ok {
+		return o.value, nil
+	}
+	if s.Next == nil {
+		return nil, errKeyNotFound
+	}
+	v, err := s.Next.Get(key)
+	return v, errors.Wrap(err, "[envflag] Next.Get")
+}
+
+// Overrides returns all overridden paths sorted.
+func (s *Storage) Overrides() cfgpath.PathSlice {
+	ret := make(cfgpath.PathSlice, 0, len(s.overrides))
+	for _, o := range s.overrides {
+		ret = append(ret, o.path)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].String() < ret[j].String()
+	})
+	return ret
+}
+
+// AllKeys returns all overridden paths and, if set, all keys of the Next
+// Storager without duplicates.
+func (s *Storage) AllKeys() (cfgpath.PathSlice, error) {
+	ret := s.Overrides()
+	if s.Next == nil {
+		return ret, nil
+	}
+	keys, err := s.Next.AllKeys()
+	if err != nil {
+		return nil, errors.Wrap(err, "[envflag] Next.AllKeys")
+	}
+	for _, p := range keys {
+		fq, err := p.FQ()
+		if err != nil {
+			return nil, errors.Wrap(err, "[envflag] AllKeys.FQ")
+		}
+		if _, ok := s.overrides[fq.String()]; !ok {
+			ret = append(ret, p)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envflag_test
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgfile"
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/storage/envflag"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ config.Storager = (*envflag.Storage)(nil)
+
+func newResolver() envflag.CodeResolver {
+	return cfgfile.NewStoreResolver(storemock.NewEurozzyService(cfgmock.NewService()))
+}
+
+func pathStrings(ps cfgpath.PathSlice) []string {
+	ret := make([]string, len(ps))
+	for i, p := range ps {
+		ret[i] = p.String()
+	}
+	return ret
+}
+
+func TestNew_Environ(t *testing.T) {
+	s, err := envflag.New(
+		envflag.WithCodeResolver(newResolver()),
+		envflag.WithEnviron([]string{
+			"PATH=/usr/bin",
+			"CS_CONFIG__WEB__SECURE__BASE_URL=https://x.com/",
+			"CS_CONFIG__WEB__SECURE__BASE_URL__WEBSITE__EURO=https://x.eu/",
+			"CS_CONFIG__WEB__SECURE__BASE_URL__STORE__DE=https://x.de/",
+			"CS_CONFIG__WEB__SECURE__BASE_URL__STORES__2=https://x.at/",
+			"CS_CONFIG__GENERAL__LOCALE__CODE__STORE__UK=en_GB=x",
+		}),
+	)
+	assert.NoError(t, err)
+
+	assert.Exactly(t, []string{
+		"default/0/web/secure/base_url",
+		"stores/1/web/secure/base_url",
+		"stores/2/web/secure/base_url",
+		"stores/4/general/locale/code",
+		"websites/1/web/secure/base_url",
+	}, pathStrings(s.Overrides()))
+
+	tests := []struct {
+		p    cfgpath.Path
+		want string
+	}{
+		{cfgpath.MustNewByParts("web/secure/base_url"), "https://x.com/"},
+		{cfgpath.MustNewByParts("web/secure/base_url").BindWebsite(1), "https://x.eu/"},
+		{cfgpath.MustNewByParts("web/secure/base_url").BindStore(1), "https://x.de/"},
+		{cfgpath.MustNewByParts("web/secure/base_url").BindStore(2), "https://x.at/"},
+		{cfgpath.MustNewByParts("general/locale/code").BindStore(4), "en_GB=x"},
+	}
+	for i, test := range tests {
+		v, err := s.Get(test.p)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, v, "Index %d", i)
+	}
+
+	_, err = s.Get(cfgpath.MustNewByParts("web/secure/base_url").BindStore(5))
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	assert.True(t, errors.IsNotSupported(s.Set(cfgpath.MustNewByParts("aa/bb/cc"), 1)))
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := envflag.New(
+		envflag.WithEnviron([]string{"CS_CONFIG__WEB__SECURE__BASE_URL__STORE__DE=https://x.de/"}),
+	)
+	assert.True(t, errors.IsNotValid(err), "Missing resolver; Error: %s", err)
+
+	_, err = envflag.New(
+		envflag.WithCodeResolver(newResolver()),
+		envflag.WithEnviron([]string{"CS_CONFIG__WEB__SECURE__BASE_URL__STORE__XX=https://x.de/"}),
+	)
+	assert.True(t, errors.IsNotFound(err), "Unknown code; Error: %s", err)
+
+	_, err = envflag.New(
+		envflag.WithEnviron([]string{"CS_CONFIG__WEB=https://x.de/"}),
+	)
+	assert.True(t, errors.IsNotValid(err), "Route too short; Error: %s", err)
+}
+
+func TestNew_FlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("v", false, "verbose")
+	fs.String("web/secure/base_url", "https://default.com/", "base URL")
+	fs.String("stores/de/web/secure/base_url", "", "base URL for store de")
+	fs.String("websites/1/web/secure/base_url", "", "base URL for website 1")
+	assert.NoError(t, fs.Parse([]string{"-v", "-stores/de/web/secure/base_url=https://flag.de/"}))
+
+	s, err := envflag.New(
+		envflag.WithCodeResolver(newResolver()),
+		envflag.WithPrefix("APP__"),
+		envflag.WithEnviron([]string{
+			"APP__WEB__SECURE__BASE_URL__STORE__DE=https://env.de/",
+			"APP__WEB__UNSECURE__BASE_URL=http://env.com/",
+			"CS_CONFIG__WEB__SECURE__BASE_URL=https://ignored.com/",
+		}),
+		envflag.WithFlagSet(fs),
+	)
+	assert.NoError(t, err)
+
+	// unset flags are not overrides and flags win over environment variables
+	assert.Exactly(t, []string{
+		"default/0/web/unsecure/base_url",
+		"stores/1/web/secure/base_url",
+	}, pathStrings(s.Overrides()))
+	v, err := s.Get(cfgpath.MustNewByParts("web/secure/base_url").BindStore(1))
+	assert.NoError(t, err)
+	assert.Exactly(t, "https://flag.de/", v)
+}
+
+func TestStorage_Next(t *testing.T) {
+	next := config.NewInMemoryStore()
+	pOver := cfgpath.MustNewByParts("web/secure/base_url")
+	pOther := cfgpath.MustNewByParts("web/unsecure/base_url")
+	assert.NoError(t, next.Set(pOver, "https://db.com/"))
+	assert.NoError(t, next.Set(pOther, "http://db.com/"))
+
+	s := envflag.MustNew(
+		envflag.WithEnviron([]string{"CS_CONFIG__WEB__SECURE__BASE_URL=https://env.com/"}),
+		envflag.WithNext(next),
+	)
+	srv := config.MustNewService(s)
+
+	have, err := srv.String(pOver)
+	assert.NoError(t, err)
+	assert.Exactly(t, "https://env.com/", have)
+	have, err = srv.String(pOther)
+	assert.NoError(t, err)
+	assert.Exactly(t, "http://db.com/", have)
+
+	// writes get forwarded, the override still wins
+	assert.NoError(t, srv.Write(pOver, "https://new.com/"))
+	have, err = srv.String(pOver)
+	assert.NoError(t, err)
+	assert.Exactly(t, "https://env.com/", have)
+	v, err := next.Get(pOver)
+	assert.NoError(t, err)
+	assert.Exactly(t, "https://new.com/", v)
+
+	keys, err := s.AllKeys()
+	assert.NoError(t, err)
+	allKeys := pathStrings(keys)
+	assert.Len(t, allKeys, 3) // including the path written by config.NewService
+	assert.Contains(t, allKeys, pOver.String())
+	assert.Contains(t, allKeys, pOther.String())
+}
@@ -0,0 +1,68 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envflag
+
+import (
+	"flag"
+
+	"github.com/corestoreio/csfw/config"
+)
+
+// Option applies options to the New function. All options get applied before
+// the environment variables and flags get parsed.
+type Option func(*options) error
+
+// WithEnviron sets the environment variables in the form "key=value", mostly
+// os.Environ().
+func WithEnviron(environ []string) Option {
+	return func(o *options) error {
+		o.environ = append(o.environ, environ...)
+		return nil
+	}
+}
+
+// WithPrefix changes the DefaultPrefix of the environment variables.
+func WithPrefix(prefix string) Option {
+	return func(o *options) error {
+		o.prefix = prefix
+		return nil
+	}
+}
+
+// WithFlagSet ingests all flags of a parsed FlagSet whose names contain a
+// path. Unset flags get ignored.
+func WithFlagSet(fs *flag.FlagSet) Option {
+	return func(o *options) error {
+		o.flagSets = append(o.flagSets, fs)
+		return nil
+	}
+}
+
+// WithCodeResolver resolves the codes of websites and stores, see
+// cfgfile.NewStoreResolver. Only needed if codes instead of IDs are used.
+func WithCodeResolver(cr CodeResolver) Option {
+	return func(o *options) error {
+		o.resolver = cr
+		return nil
+	}
+}
+
+// WithNext stacks the Storage in front of another Storager.
+func WithNext(next config.Storager) Option {
+	return func(o *options) error {
+		o.next = next
+		return nil
+	}
+}
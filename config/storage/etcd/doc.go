@@ -14,7 +14,23 @@
 
 // Package etcd uses etcd service for reading and writing configuration paths.
 //
-// todo(CS): https://github.com/coreos/etcd/tree/master/client
+// All nodes connected to the same etcd cluster share the configuration
+// values. Each fully qualified path gets stored below a common key prefix, for
+// example:
+//		csfw/config/stores/2/web/secure/base_url
+// Values are converted to byte slices.
+//
+// The function Storage.Watch listens for changes from other nodes and
+// forwards them to a config.Publisher, which is the config.Service with
+// enabled pub/sub. Therefore all MessageReceivers on every node get notified
+// when a value changes.
+//		es := etcd.New(etcdClient)
+//		cfgSrv := config.MustNewService(es, config.WithPubSub())
+//		go func() {
+//			if err := es.Watch(ctx, cfgSrv); err != nil {
+//				// handle error
+//			}
+//		}()
 //
 // Maybe implements synchronization with MySQL core_config_data table.
 package etcd
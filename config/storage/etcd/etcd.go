@@ -0,0 +1,173 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/log"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// DefaultPrefix gets prepended to all fully qualified paths.
+const DefaultPrefix = "csfw/config/"
+
+// DefaultTimeout defines the maximum duration of a single request to the etcd
+// cluster.
+const DefaultTimeout = 5 * time.Second
+
+var errKeyNotFound = errors.NewNotFoundf(`[etcd] Key not found`)
+
+// Storage connects the etcd key-value API with the config.Service type.
+// Implements interface config.Storager.
+type Storage struct {
+	// Prefix gets prepended to each fully qualified path. Changing the prefix
+	// allows to run several independent shops on the same etcd cluster.
+	// Default: DefaultPrefix.
+	Prefix string
+	// Timeout applies to each request to the etcd cluster. Default:
+	// DefaultTimeout.
+	Timeout time.Duration
+	// Log can be set for debugging purpose. Default log.BlackHole.
+	Log log.Logger
+	// KV reads and writes the keys. Mostly a *clientv3.Client.
+	KV clientv3.KV
+	// Watcher observes the keys below Prefix. Mostly a *clientv3.Client.
+	Watcher clientv3.Watcher
+
+	// watching gets set to one while the Watch function runs.
+	watching int32
+	mu       sync.Mutex
+	// ownRevs contains the revisions of the keys written by this Storage
+	// while Watch runs. Those revisions must not be published again because
+	// config.Service.Write already notified the MessageReceivers.
+	ownRevs map[int64]struct{}
+	// lastRev contains the last revision seen by the Watch function.
+	lastRev int64
+}
+
+// New creates a new etcd backed config.Storager. The client gets used for the
+// key-value and the watch API.
+func New(c *clientv3.Client) *Storage {
+	return &Storage{
+		Prefix:  DefaultPrefix,
+		Timeout: DefaultTimeout,
+		Log:     log.BlackHole{}, // disabled debug and info logging.
+		KV:      c,
+		Watcher: c,
+		ownRevs: make(map[int64]struct{}),
+	}
+}
+
+// key creates the etcd key from a path. For example:
+//		csfw/config/stores/2/aa/bb/cc
+func (s *Storage) key(p cfgpath.Path) (string, error) {
+	fq, err := p.FQ()
+	if err != nil {
+		return "", errors.Wrap(err, "[etcd] Path.FQ")
+	}
+	return s.Prefix + fq.String(), nil
+}
+
+// path reverses the function key() and creates a path from an etcd key.
+func (s *Storage) path(key []byte) (cfgpath.Path, error) {
+	fq := strings.TrimPrefix(string(key), s.Prefix)
+	p, err := cfgpath.SplitFQ(fq)
+	if err != nil {
+		return cfgpath.Path{}, errors.Wrapf(err, "[etcd] cfgpath.SplitFQ Key: %q", key)
+	}
+	return p, nil
+}
+
+func (s *Storage) newContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.Timeout)
+}
+
+// Set writes a key with its value into etcd. The value gets converted to a
+// byte slice.
+func (s *Storage) Set(key cfgpath.Path, value interface{}) error {
+	k, err := s.key(key)
+	if err != nil {
+		return errors.Wrap(err, "[etcd] Set.key")
+	}
+	b, err := conv.ToByteE(value)
+	if err != nil {
+		return errors.Wrapf(err, "[etcd] Set.conv.ToByteE Key: %q Value: %v", k, value)
+	}
+
+	ctx, cancel := s.newContext()
+	resp, err := s.KV.Put(ctx, k, string(b))
+	cancel()
+	if err != nil {
+		return errors.Wrapf(err, "[etcd] Set.KV.Put Key: %q", k)
+	}
+	if atomic.LoadInt32(&s.watching) == 1 && resp.Header != nil {
+		s.addOwnRevision(resp.Header.Revision)
+	}
+	if s.Log.IsDebug() {
+		s.Log.Debug("etcd.Storage.Set.KV.Put", log.String("key", k), log.Object("value", value))
+	}
+	return nil
+}
+
+// Get returns a byte slice from etcd. Error behaviour: NotFound.
+func (s *Storage) Get(key cfgpath.Path) (interface{}, error) {
+	k, err := s.key(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "[etcd] Get.key")
+	}
+
+	ctx, cancel := s.newContext()
+	resp, err := s.KV.Get(ctx, k)
+	cancel()
+	if err != nil {
+		return nil, errors.Wrapf(err, "[etcd] Get.KV.Get Key: %q", k)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, errKeyNotFound
+	}
+	return resp.Kvs[0].Value, nil
+}
+
+// AllKeys returns all keys below the Prefix sorted ascending.
+func (s *Storage) AllKeys() (cfgpath.PathSlice, error) {
+	ctx, cancel := s.newContext()
+	resp, err := s.KV.Get(ctx, s.Prefix,
+		clientv3.WithPrefix(),
+		clientv3.WithKeysOnly(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
+	)
+	cancel()
+	if err != nil {
+		return nil, errors.Wrapf(err, "[etcd] AllKeys.KV.Get Prefix: %q", s.Prefix)
+	}
+
+	var ret = make(cfgpath.PathSlice, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		p, err := s.path(kv.Key)
+		if err != nil {
+			return ret, errors.Wrap(err, "[etcd] AllKeys.path")
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
@@ -0,0 +1,227 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/embed"
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/storage/etcd"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ config.Storager = (*etcd.Storage)(nil)
+
+// testClient connects to the embedded etcd server started in TestMain.
+var testClient *clientv3.Client
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "csfw_etcd")
+	if err != nil {
+		panic(err)
+	}
+
+	// ports chosen to not collide with a locally running etcd
+	lcURL, _ := url.Parse("http://127.0.0.1:22379")
+	lpURL, _ := url.Parse("http://127.0.0.1:22380")
+	cfg := embed.NewConfig()
+	cfg.Dir = dir
+	cfg.LCUrls, cfg.ACUrls = []url.URL{*lcURL}, []url.URL{*lcURL}
+	cfg.LPUrls, cfg.APUrls = []url.URL{*lpURL}, []url.URL{*lpURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		panic(err)
+	}
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(20 * time.Second):
+		e.Server.Stop()
+		panic("embedded etcd server took too long to start")
+	}
+
+	testClient, err = clientv3.New(clientv3.Config{
+		Endpoints:   []string{lcURL.String()},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	_ = testClient.Close()
+	e.Close()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newStorage creates a Storage with a unique prefix to isolate the tests.
+func newStorage(t *testing.T) *etcd.Storage {
+	s := etcd.New(testClient)
+	s.Prefix = "csfw_test/" + t.Name() + "/"
+	return s
+}
+
+func TestStorage_SetGet(t *testing.T) {
+	s := newStorage(t)
+
+	tests := []struct {
+		key cfgpath.Path
+		val interface{}
+	}{
+		{cfgpath.MustNewByParts("aa/bb/cc"), 12345},
+		{cfgpath.MustNewByParts("aa/bb/cc").BindWebsite(3), "Gopher"},
+		{cfgpath.MustNewByParts("aa/bb/cc").BindStore(4), 3.14159},
+	}
+	for idx, test := range tests {
+		assert.NoError(t, s.Set(test.key, test.val), "Index %d", idx)
+
+		haveVal, haveErr := s.Get(test.key)
+		assert.NoError(t, haveErr, "Index %d", idx)
+		assert.Exactly(t, conv.ToString(test.val), conv.ToString(haveVal), "Index %d", idx)
+	}
+}
+
+func TestStorage_GetNotFound(t *testing.T) {
+	s := newStorage(t)
+	haveVal, haveErr := s.Get(cfgpath.MustNewByParts("xx/yy/zz").BindStore(5))
+	assert.True(t, errors.IsNotFound(haveErr), "Error: %s", haveErr)
+	assert.Nil(t, haveVal)
+}
+
+func TestStorage_EmptyPath(t *testing.T) {
+	s := newStorage(t)
+	assert.True(t, errors.IsEmpty(s.Set(cfgpath.Path{}, 1)))
+	_, err := s.Get(cfgpath.Path{})
+	assert.True(t, errors.IsEmpty(err), "Error: %s", err)
+}
+
+func TestStorage_AllKeys(t *testing.T) {
+	s := newStorage(t)
+
+	want := cfgpath.PathSlice{
+		cfgpath.MustNewByParts("aa/bb/cc"),
+		cfgpath.MustNewByParts("aa/bb/cc").BindWebsite(1),
+		cfgpath.MustNewByParts("xx/yy/zz").BindStore(2),
+	}
+	for _, p := range want {
+		assert.NoError(t, s.Set(p, "value"))
+	}
+
+	have, err := s.AllKeys()
+	assert.NoError(t, err)
+	have.Sort()
+	want.Sort()
+	if assert.Len(t, have, len(want)) {
+		for i, p := range want {
+			assert.Exactly(t, p.String(), have[i].String(), "Index %d", i)
+		}
+	}
+}
+
+type testSubscriber struct {
+	mu    sync.Mutex
+	paths []string
+	recv  chan struct{}
+}
+
+func (ts *testSubscriber) MessageConfig(p cfgpath.Path) error {
+	ts.mu.Lock()
+	ts.paths = append(ts.paths, p.String())
+	ts.mu.Unlock()
+	ts.recv <- struct{}{}
+	return nil
+}
+
+func (ts *testSubscriber) Paths() []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return append([]string(nil), ts.paths...)
+}
+
+func TestStorage_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// two nodes sharing the same etcd cluster
+	nodeA := newStorage(t)
+	nodeB := newStorage(t)
+	srvA := config.MustNewService(nodeA, config.WithPubSub())
+	srvB := config.MustNewService(nodeB, config.WithPubSub())
+
+	subA := &testSubscriber{recv: make(chan struct{}, 10)}
+	subB := &testSubscriber{recv: make(chan struct{}, 10)}
+	_, err := srvA.Subscribe(cfgpath.NewRoute("payment/checkmo"), subA)
+	assert.NoError(t, err)
+	_, err = srvB.Subscribe(cfgpath.NewRoute("payment/checkmo"), subB)
+	assert.NoError(t, err)
+
+	watchErr := make(chan error, 2)
+	go func() { watchErr <- nodeA.Watch(ctx, srvA) }()
+	go func() { watchErr <- nodeB.Watch(ctx, srvB) }()
+	time.Sleep(200 * time.Millisecond) // wait until the watchers have been registered
+
+	p := cfgpath.MustNewByParts("payment/checkmo/active").BindStore(2)
+	assert.NoError(t, srvA.Write(p, 1))
+
+	for _, sub := range []*testSubscriber{subA, subB} {
+		select {
+		case <-sub.recv:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timeout waiting for the MessageReceiver")
+		}
+	}
+	time.Sleep(200 * time.Millisecond) // a duplicate message would arrive now
+
+	// nodeA receives the message only once, from its own Write
+	assert.Exactly(t, []string{p.String()}, subA.Paths())
+	assert.Exactly(t, []string{p.String()}, subB.Paths())
+
+	v, err := srvB.String(p)
+	assert.NoError(t, err)
+	assert.Exactly(t, "1", v)
+
+	cancel()
+	assert.NoError(t, <-watchErr)
+	assert.NoError(t, <-watchErr)
+	assert.NoError(t, srvA.Close())
+	assert.NoError(t, srvB.Close())
+}
+
+func TestStorage_Watch_AlreadyRunning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := newStorage(t)
+	srv := config.MustNewService(s)
+	go func() { _ = s.Watch(ctx, srv) }()
+	time.Sleep(50 * time.Millisecond)
+
+	err := s.Watch(ctx, srv)
+	assert.True(t, errors.IsAlreadyExists(err), "Error: %s", err)
+}
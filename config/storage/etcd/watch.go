@@ -0,0 +1,101 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/log"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// Watch listens for changes of all keys below the Prefix and forwards each
+// changed path to the Publisher. Mostly the Publisher is the config.Service
+// with an enabled pub/sub service, so all MessageReceivers of the current
+// process get notified whenever another node writes a value. Changes written
+// by this Storage get skipped because config.Service.Write already published
+// them, unless the watch event arrives before Set has recorded the revision.
+// Then the path gets published twice. Watch blocks until the context gets
+// cancelled, then it returns nil. Only one Watch per Storage can run at the
+// same time. Error behaviour: AlreadyExists or Fatal.
+func (s *Storage) Watch(ctx context.Context, pub config.Publisher) error {
+	if !atomic.CompareAndSwapInt32(&s.watching, 0, 1) {
+		return errors.NewAlreadyExistsf("[etcd] Watch already running for prefix %q", s.Prefix)
+	}
+	defer func() {
+		atomic.StoreInt32(&s.watching, 0)
+		s.mu.Lock()
+		s.ownRevs = make(map[int64]struct{})
+		s.lastRev = 0
+		s.mu.Unlock()
+	}()
+
+	wch := s.Watcher.Watch(ctx, s.Prefix, clientv3.WithPrefix())
+	for wr := range wch {
+		if err := wr.Err(); err != nil {
+			return errors.NewFatal(err, "[etcd] Watch.WatchResponse")
+		}
+		for _, ev := range wr.Events {
+			if s.isOwnRevision(ev.Kv.ModRevision) {
+				continue
+			}
+			p, err := s.path(ev.Kv.Key)
+			if err != nil {
+				if s.Log.IsDebug() {
+					s.Log.Debug("etcd.Storage.Watch.path", log.Err(err), log.String("key", string(ev.Kv.Key)))
+				}
+				continue
+			}
+			if s.Log.IsDebug() {
+				s.Log.Debug("etcd.Storage.Watch.Publish", log.Stringer("path", p), log.Int64("revision", ev.Kv.ModRevision), log.Bool("isDelete", ev.Type == clientv3.EventTypeDelete))
+			}
+			pub.Publish(p)
+		}
+	}
+	return nil
+}
+
+// addOwnRevision records a revision written by this Storage. If the Watch
+// function has already passed the revision, the change has been published
+// twice and the revision gets not recorded.
+func (s *Storage) addOwnRevision(rev int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rev > s.lastRev {
+		s.ownRevs[rev] = struct{}{}
+	}
+}
+
+// isOwnRevision checks if the revision has been created by this Storage. The
+// watch events arrive with ascending revisions, so all recorded revisions at
+// or below rev get removed. Revisions lost due to a compaction do not stay
+// in the list.
+func (s *Storage) isOwnRevision(rev int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.ownRevs[rev]
+	if rev > s.lastRev {
+		s.lastRev = rev
+	}
+	for r := range s.ownRevs {
+		if r <= s.lastRev {
+			delete(s.ownRevs, r)
+		}
+	}
+	return ok
+}
@@ -0,0 +1,76 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layered
+
+import (
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// defaults read only storage of the element default values.
+type defaults struct {
+	dm element.DefaultMap
+}
+
+// NewDefaults creates a read only config.Storager from the default values of
+// all fields, mostly an element.SectionSlice. The default values belong to the
+// default scope, so a key bound to a website or store scope cannot be found.
+// Fields without a default value cannot be found either. Set returns an error
+// of behaviour NotSupported.
+func NewDefaults(sec element.Sectioner) (config.Storager, error) {
+	dm, err := sec.Defaults()
+	if err != nil {
+		return nil, errors.Wrap(err, "[layered] NewDefaults.Defaults")
+	}
+	return defaults{dm: dm}, nil
+}
+
+// Set returns always a NotSupported error.
+func (d defaults) Set(key cfgpath.Path, _ interface{}) error {
+	return errors.NewNotSupportedf("[layered] Defaults are read only. Key: %q", key)
+}
+
+// Get returns the default value. Error behaviour: NotFound or NotValid.
+func (d defaults) Get(key cfgpath.Path) (interface{}, error) {
+	if err := key.IsValid(); err != nil {
+		return nil, errors.Wrap(err, "[layered] Defaults.Get")
+	}
+	if scp := key.ScopeID.Type(); scp == scope.Website || scp == scope.Store {
+		return nil, errKeyNotFound
+	}
+	if v, ok := d.dm[key.Route.String()]; ok && v != nil {
+		return v, nil
+	}
+	return nil, errKeyNotFound
+}
+
+// AllKeys returns all keys with a default value bound to the default scope.
+func (d defaults) AllKeys() (cfgpath.PathSlice, error) {
+	var ret = make(cfgpath.PathSlice, 0, len(d.dm))
+	for k, v := range d.dm {
+		if v == nil {
+			continue
+		}
+		p, err := cfgpath.NewByParts(k)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[layered] Defaults.AllKeys Path %q", k)
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package layered chains several configuration storage engines into one
+// config.Storager.
+//
+// A typical chain contains a fast in-memory cache in front, the MySQL table
+// core_config_data as the authoritative layer behind it and the default
+// values of the element.SectionSlice as the last fallback:
+//		bc, _ := cfgbigcache.New(bigcache.Config{Shards: 256})
+//		ls, err := layered.New(ccd.MustNewDBStorage(db),
+//			layered.WithCache(bc),
+//			layered.WithDefaults(backend.ConfigStructure),
+//			layered.WithRepopulate(),
+//		)
+//		cfgSrv := config.MustNewService(ls)
+//
+// Reads ask the caches first, then the authoritative layer and at last the
+// fallbacks. A layer which returns an error of behaviour NotFound gets
+// skipped. With enabled repopulation a found value gets written back into all
+// caches in front of the layer which returned the value.
+//
+// Writes go only into the authoritative layer. Afterwards all caches get
+// invalidated. A cache which implements the Deleter interface gets the key
+// removed, all other caches receive the new value.
+package layered
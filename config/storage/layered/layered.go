@@ -0,0 +1,182 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layered
+
+import (
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/log"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+var errKeyNotFound = errors.NewNotFoundf(`[layered] Key not found`)
+
+// Deleter removes a key from a storage engine. Cache layers should implement
+// this interface to get invalidated on writes.
+type Deleter interface {
+	Delete(key cfgpath.Path) error
+}
+
+// Storage chains several config.Storager. Implements interface
+// config.Storager. Please use the New() function.
+type Storage struct {
+	// Authority receives all writes and gets asked after the caches. Mostly
+	// the ccd.DBStorage.
+	Authority config.Storager
+	// Caches get asked first in the order of the slice and invalidated after
+	// a write.
+	Caches []config.Storager
+	// Fallbacks are read only and get asked last in the order of the slice.
+	Fallbacks []config.Storager
+	// Repopulate writes a value found in a lower layer into all caches in
+	// front of that layer.
+	Repopulate bool
+	// Log can be set for debugging purpose. Default log.BlackHole.
+	Log log.Logger
+}
+
+// New creates a new layered Storage. Argument authority receives all writes.
+func New(authority config.Storager, opts ...Option) (*Storage, error) {
+	if authority == nil {
+		return nil, errors.NewEmptyf("[layered] Authority Storager cannot be nil")
+	}
+	s := &Storage{
+		Authority: authority,
+		Log:       log.BlackHole{}, // disabled debug and info logging.
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt(s); err != nil {
+				return nil, errors.Wrap(err, "[layered] New.Option")
+			}
+		}
+	}
+	return s, nil
+}
+
+// MustNew same as New but panics on error.
+func MustNew(authority config.Storager, opts ...Option) *Storage {
+	s, err := New(authority, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Set writes the value into the authoritative layer and invalidates all
+// caches afterwards.
+func (s *Storage) Set(key cfgpath.Path, value interface{}) error {
+	if err := s.Authority.Set(key, value); err != nil {
+		return errors.Wrapf(err, "[layered] Set.Authority.Set Key: %q", key)
+	}
+	for i, c := range s.Caches {
+		var err error
+		if d, ok := c.(Deleter); ok {
+			err = d.Delete(key)
+		} else {
+			err = c.Set(key, value)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "[layered] Set.invalidate Cache %d Key: %q", i, key)
+		}
+	}
+	return nil
+}
+
+// Get asks the caches, the authority and the fallbacks in this order for the
+// key. Errors of the caches get ignored, so a broken cache cannot prevent
+// reading from the authority. Error behaviour: NotFound.
+func (s *Storage) Get(key cfgpath.Path) (interface{}, error) {
+	for i, c := range s.Caches {
+		v, err := c.Get(key)
+		if err == nil {
+			s.repopulate(i, key, v)
+			return v, nil
+		}
+		if !errors.IsNotFound(err) && s.Log.IsDebug() {
+			s.Log.Debug("layered.Storage.Get.Cache", log.Err(err), log.Int("cache", i), log.Stringer("key", key))
+		}
+	}
+
+	v, err := s.Authority.Get(key)
+	switch {
+	case err == nil:
+		s.repopulate(len(s.Caches), key, v)
+		return v, nil
+	case !errors.IsNotFound(err):
+		return nil, errors.Wrapf(err, "[layered] Get.Authority.Get Key: %q", key)
+	}
+
+	for i, f := range s.Fallbacks {
+		v, err := f.Get(key)
+		switch {
+		case err == nil:
+			s.repopulate(len(s.Caches), key, v)
+			return v, nil
+		case !errors.IsNotFound(err):
+			return nil, errors.Wrapf(err, "[layered] Get.Fallback %d Key: %q", i, key)
+		}
+	}
+	return nil, errKeyNotFound
+}
+
+// repopulate writes the value into the first n caches.
+func (s *Storage) repopulate(n int, key cfgpath.Path, value interface{}) {
+	if !s.Repopulate {
+		return
+	}
+	for i := 0; i < n; i++ {
+		if err := s.Caches[i].Set(key, value); err != nil && s.Log.IsDebug() {
+			s.Log.Debug("layered.Storage.repopulate.Cache.Set", log.Err(err), log.Int("cache", i), log.Stringer("key", key))
+		}
+	}
+}
+
+// AllKeys returns the unique keys of all layers.
+func (s *Storage) AllKeys() (cfgpath.PathSlice, error) {
+	var ret cfgpath.PathSlice
+	seen := make(map[string]struct{})
+
+	add := func(st config.Storager) error {
+		keys, err := st.AllKeys()
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			ks := k.String()
+			if _, ok := seen[ks]; ok {
+				continue
+			}
+			seen[ks] = struct{}{}
+			ret = append(ret, k)
+		}
+		return nil
+	}
+
+	for i, c := range s.Caches {
+		if err := add(c); err != nil {
+			return nil, errors.Wrapf(err, "[layered] AllKeys.Cache %d", i)
+		}
+	}
+	if err := add(s.Authority); err != nil {
+		return nil, errors.Wrap(err, "[layered] AllKeys.Authority")
+	}
+	for i, f := range s.Fallbacks {
+		if err := add(f); err != nil {
+			return nil, errors.Wrapf(err, "[layered] AllKeys.Fallback %d", i)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,247 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layered_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/config/storage/layered"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ config.Storager = (*layered.Storage)(nil)
+var _ layered.Deleter = (*deleteCache)(nil)
+
+// deleteCache is a cache layer which can be invalidated.
+type deleteCache struct {
+	mu sync.Mutex
+	m  map[string]interface{}
+}
+
+func newDeleteCache() *deleteCache {
+	return &deleteCache{m: make(map[string]interface{})}
+}
+
+func (dc *deleteCache) Set(key cfgpath.Path, value interface{}) error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.m[key.String()] = value
+	return nil
+}
+
+func (dc *deleteCache) Get(key cfgpath.Path) (interface{}, error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if v, ok := dc.m[key.String()]; ok {
+		return v, nil
+	}
+	return nil, errors.NewNotFoundf("[layered_test] Key %q not found", key)
+}
+
+func (dc *deleteCache) Delete(key cfgpath.Path) error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	delete(dc.m, key.String())
+	return nil
+}
+
+func (dc *deleteCache) AllKeys() (cfgpath.PathSlice, error) { return nil, nil }
+
+// errStorage returns always the same error.
+type errStorage struct {
+	err error
+}
+
+func (es errStorage) Set(_ cfgpath.Path, _ interface{}) error { return es.err }
+func (es errStorage) Get(_ cfgpath.Path) (interface{}, error) { return nil, es.err }
+func (es errStorage) AllKeys() (cfgpath.PathSlice, error)    { return nil, es.err }
+
+var testSections = element.MustNewConfiguration(
+	element.Section{
+		ID: cfgpath.NewRoute("contact"),
+		Groups: element.NewGroupSlice(
+			element.Group{
+				ID: cfgpath.NewRoute("email"),
+				Fields: element.NewFieldSlice(
+					element.Field{
+						// Path: `contact/email/recipient_email`,
+						ID:      cfgpath.NewRoute("recipient_email"),
+						Default: `hello@example.com`,
+					},
+					element.Field{
+						// Path: `contact/email/sender_email_identity`,
+						ID: cfgpath.NewRoute("sender_email_identity"),
+					},
+				),
+			},
+		),
+	},
+)
+
+func TestNew_Empty(t *testing.T) {
+	s, err := layered.New(nil)
+	assert.Nil(t, s)
+	assert.True(t, errors.IsEmpty(err), "Error: %s", err)
+}
+
+func TestStorage_GetFallThrough(t *testing.T) {
+	cache := newDeleteCache()
+	auth := config.NewInMemoryStore()
+	s := layered.MustNew(auth, layered.WithCache(cache), layered.WithDefaults(testSections))
+
+	pRecipient := cfgpath.MustNewByParts("contact/email/recipient_email")
+
+	// from the defaults
+	v, err := s.Get(pRecipient)
+	assert.NoError(t, err)
+	assert.Exactly(t, `hello@example.com`, v)
+
+	// defaults belong only to the default scope
+	_, err = s.Get(pRecipient.BindStore(1))
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+
+	// default value nil
+	_, err = s.Get(cfgpath.MustNewByParts("contact/email/sender_email_identity"))
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+
+	// from the authority
+	assert.NoError(t, auth.Set(pRecipient, `auth@example.com`))
+	v, err = s.Get(pRecipient)
+	assert.NoError(t, err)
+	assert.Exactly(t, `auth@example.com`, v)
+
+	// from the cache
+	assert.NoError(t, cache.Set(pRecipient, `cache@example.com`))
+	v, err = s.Get(pRecipient)
+	assert.NoError(t, err)
+	assert.Exactly(t, `cache@example.com`, v)
+}
+
+func TestStorage_Repopulate(t *testing.T) {
+	cache1 := newDeleteCache()
+	cache2 := newDeleteCache()
+	auth := config.NewInMemoryStore()
+	s := layered.MustNew(auth,
+		layered.WithCache(cache1, cache2),
+		layered.WithDefaults(testSections),
+		layered.WithRepopulate(),
+	)
+
+	pRecipient := cfgpath.MustNewByParts("contact/email/recipient_email")
+	v, err := s.Get(pRecipient)
+	assert.NoError(t, err)
+	assert.Exactly(t, `hello@example.com`, v)
+
+	for i, c := range []*deleteCache{cache1, cache2} {
+		v, err := c.Get(pRecipient)
+		assert.NoError(t, err, "Cache %d", i)
+		assert.Exactly(t, `hello@example.com`, v, "Cache %d", i)
+	}
+
+	// cache2 hit repopulates only cache1
+	pOther := cfgpath.MustNewByParts("aa/bb/cc").BindWebsite(3)
+	assert.NoError(t, cache2.Set(pOther, 42))
+	v, err = s.Get(pOther)
+	assert.NoError(t, err)
+	assert.Exactly(t, 42, v)
+	v, err = cache1.Get(pOther)
+	assert.NoError(t, err)
+	assert.Exactly(t, 42, v)
+	_, err = auth.Get(pOther)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+}
+
+func TestStorage_SetInvalidates(t *testing.T) {
+	delCache := newDeleteCache()
+	setCache := config.NewInMemoryStore() // cannot delete, gets the new value
+	auth := config.NewInMemoryStore()
+	s := layered.MustNew(auth, layered.WithCache(delCache, setCache))
+
+	p := cfgpath.MustNewByParts("aa/bb/cc").BindStore(2)
+	assert.NoError(t, delCache.Set(p, "stale"))
+	assert.NoError(t, setCache.Set(p, "stale"))
+
+	assert.NoError(t, s.Set(p, "fresh"))
+
+	_, err := delCache.Get(p)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	v, err := setCache.Get(p)
+	assert.NoError(t, err)
+	assert.Exactly(t, "fresh", v)
+	v, err = auth.Get(p)
+	assert.NoError(t, err)
+	assert.Exactly(t, "fresh", v)
+
+	v, err = s.Get(p)
+	assert.NoError(t, err)
+	assert.Exactly(t, "fresh", v)
+}
+
+func TestStorage_Errors(t *testing.T) {
+	p := cfgpath.MustNewByParts("aa/bb/cc")
+	fatal := errStorage{err: errors.NewFatalf("Database gone")}
+
+	// broken cache gets skipped
+	auth := config.NewInMemoryStore()
+	assert.NoError(t, auth.Set(p, 1))
+	s := layered.MustNew(auth, layered.WithCache(fatal))
+	v, err := s.Get(p)
+	assert.NoError(t, err)
+	assert.Exactly(t, 1, v)
+	// but cannot be invalidated
+	err = s.Set(p, 2)
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+
+	// broken authority
+	s = layered.MustNew(fatal, layered.WithDefaults(testSections))
+	_, err = s.Get(p)
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+	err = s.Set(p, 2)
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+	_, err = s.AllKeys()
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+
+	// read only defaults
+	d, err := layered.NewDefaults(testSections)
+	assert.NoError(t, err)
+	err = d.Set(p, 1)
+	assert.True(t, errors.IsNotSupported(err), "Error: %s", err)
+}
+
+func TestStorage_AllKeys(t *testing.T) {
+	auth := config.NewInMemoryStore()
+	cache := config.NewInMemoryStore()
+	s := layered.MustNew(auth, layered.WithCache(cache), layered.WithDefaults(testSections))
+
+	pRecipient := cfgpath.MustNewByParts("contact/email/recipient_email")
+	pStore := cfgpath.MustNewByParts("aa/bb/cc").BindStore(3)
+	assert.NoError(t, s.Set(pRecipient, "x"))
+	assert.NoError(t, s.Set(pStore, "y"))
+
+	keys, err := s.AllKeys()
+	assert.NoError(t, err)
+	have := make([]string, len(keys))
+	for i, k := range keys {
+		have[i] = k.String()
+	}
+	assert.Len(t, have, 2)
+	assert.Contains(t, have, pRecipient.String())
+	assert.Contains(t, have, pStore.String())
+}
@@ -0,0 +1,72 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layered
+
+import (
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/log"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// Option applies options to the New function.
+type Option func(*Storage) error
+
+// WithCache appends caches in front of the authoritative layer. The first
+// cache gets asked first.
+func WithCache(caches ...config.Storager) Option {
+	return func(s *Storage) error {
+		s.Caches = append(s.Caches, caches...)
+		return nil
+	}
+}
+
+// WithFallback appends read only fallbacks behind the authoritative layer.
+func WithFallback(fallbacks ...config.Storager) Option {
+	return func(s *Storage) error {
+		s.Fallbacks = append(s.Fallbacks, fallbacks...)
+		return nil
+	}
+}
+
+// WithDefaults appends the default values of the element.SectionSlice as a
+// read only fallback. See function NewDefaults.
+func WithDefaults(sec element.Sectioner) Option {
+	return func(s *Storage) error {
+		d, err := NewDefaults(sec)
+		if err != nil {
+			return errors.Wrap(err, "[layered] WithDefaults.NewDefaults")
+		}
+		s.Fallbacks = append(s.Fallbacks, d)
+		return nil
+	}
+}
+
+// WithRepopulate writes a value found in a lower layer into all caches in
+// front of that layer.
+func WithRepopulate() Option {
+	return func(s *Storage) error {
+		s.Repopulate = true
+		return nil
+	}
+}
+
+// WithLogger sets a logger for debugging purpose.
+func WithLogger(l log.Logger) Option {
+	return func(s *Storage) error {
+		s.Log = l
+		return nil
+	}
+}
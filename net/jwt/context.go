@@ -38,3 +38,14 @@ func FromContext(ctx context.Context) (csjwt.Token, bool) {
 	wrp, ok := ctx.Value(keyCtxToken{}).(ctxTokenWrapper)
 	return wrp.t, ok
 }
+
+// StoreCodeFromContext returns the store code from the claims of the token in
+// ctx. Uses the default claim key StoreCodeFieldName.
+func StoreCodeFromContext(ctx context.Context) (string, bool) {
+	t, ok := FromContext(ctx)
+	if !ok || t.Claims == nil {
+		return "", false
+	}
+	code := codeFromToken(t, "")
+	return code, code != ""
+}
@@ -0,0 +1,36 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package propagate transports the scope of a request to other services.
+//
+// The run mode, the website and store ID and the store code of the current
+// request get encoded into the HTTP request header X-Csfw-Scope or, via
+// package grpcpropagate, into the gRPC metadata of an outgoing call. The value gets signed with an HMAC in the
+// format of signed.ContentHMAC, so a client cannot spoof another store. The
+// receiving service validates the signature and the store with the
+// store.Service and restores the scope into the context, see functions
+// scope.FromContext and scope.FromContextRunMode.
+//
+// Both services must share the same key and hash. The hash must be registered
+// in package hashpool:
+//		hashpool.Register("sha256", sha256.New)
+//		pr, err := propagate.New("sha256", key)
+//		pr.StoreCodeFn = jwt.StoreCodeFromContext
+//
+//		// client
+//		hc := &http.Client{Transport: pr.RoundTripper(http.DefaultTransport)}
+//
+//		// server
+//		http.Handle("/", pr.WithScope(storeService)(myHandler))
+package propagate
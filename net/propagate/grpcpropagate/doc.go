@@ -0,0 +1,28 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+// Package grpcpropagate transports the signed scope of package propagate via
+// the gRPC metadata.
+//
+// The metadata keys are the lower case variants of propagate.HeaderScope and
+// propagate.HeaderScopeSignature. Package propagate does not depend on gRPC.
+//		pr, err := propagate.New("sha256", key)
+//
+//		// client interceptor
+//		ctx = grpcpropagate.NewOutgoingContext(ctx, pr)
+//
+//		// server interceptor
+//		ctx, err = grpcpropagate.FromIncomingContext(ctx, pr, storeService)
+package grpcpropagate
@@ -0,0 +1,59 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcpropagate
+
+import (
+	"context"
+	"strings"
+
+	"github.com/corestoreio/csfw/net/propagate"
+	"github.com/corestoreio/csfw/util/errors"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys of the scope and its signature.
+var (
+	KeyScope          = strings.ToLower(propagate.HeaderScope)
+	KeyScopeSignature = strings.ToLower(propagate.HeaderScopeSignature)
+)
+
+// NewOutgoingContext appends the signed Scope of the context to the outgoing
+// gRPC metadata. Use it in a gRPC client interceptor.
+func NewOutgoingContext(ctx context.Context, p *propagate.Propagator) context.Context {
+	s, ok := p.FromRequestContext(ctx)
+	if !ok {
+		return ctx
+	}
+	v, sig := p.Encode(s)
+	return metadata.AppendToOutgoingContext(ctx, KeyScope, v, KeyScopeSignature, sig)
+}
+
+// FromIncomingContext restores the signed Scope from the incoming gRPC
+// metadata into the context and validates the store. Use it in a gRPC server
+// interceptor. Error behaviour: NotFound, NotValid or Unauthorized.
+func FromIncomingContext(ctx context.Context, p *propagate.Propagator, sv propagate.StoreValidator) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if vs := md.Get(key); len(vs) > 0 {
+			return vs[0]
+		}
+		return ""
+	}
+	s, err := p.Verify(sv, first(KeyScope), first(KeyScopeSignature))
+	if err != nil {
+		return ctx, errors.Wrap(err, "[grpcpropagate] FromIncomingContext")
+	}
+	return propagate.WithContext(ctx, s), nil
+}
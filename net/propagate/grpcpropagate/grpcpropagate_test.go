@@ -0,0 +1,72 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcpropagate_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/propagate"
+	"github.com/corestoreio/csfw/net/propagate/grpcpropagate"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/corestoreio/csfw/util/hashpool"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func init() {
+	if err := hashpool.Register("sha256", sha256.New); err != nil && !errors.IsAlreadyExists(err) {
+		panic(err)
+	}
+}
+
+func TestNewOutgoingContext_FromIncomingContext(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+	p, err := propagate.New("sha256", []byte("secret"))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	ctx := scope.WithContextRunMode(context.Background(), scope.Store.Pack(0))
+	ctx = scope.WithContext(ctx, 2, 5)
+	outCtx := grpcpropagate.NewOutgoingContext(ctx, p)
+	md, ok := metadata.FromOutgoingContext(outCtx)
+	assert.True(t, ok)
+
+	inCtx, err := grpcpropagate.FromIncomingContext(metadata.NewIncomingContext(context.Background(), md), p, srv)
+	assert.NoError(t, err)
+	s, ok := propagate.FromContext(inCtx)
+	assert.True(t, ok)
+	assert.Exactly(t, "au", s.StoreCode)
+	websiteID, storeID, ok := scope.FromContext(inCtx)
+	assert.True(t, ok)
+	assert.Exactly(t, int64(2), websiteID)
+	assert.Exactly(t, int64(5), storeID)
+
+	// spoofed store
+	md.Set(grpcpropagate.KeyScope, strings.Replace(md.Get(grpcpropagate.KeyScope)[0], ";5;", ";6;", 1))
+	_, err = grpcpropagate.FromIncomingContext(metadata.NewIncomingContext(context.Background(), md), p, srv)
+	assert.True(t, errors.IsUnauthorized(err), "Error: %s", err)
+
+	// no metadata
+	assert.True(t, context.Background() == grpcpropagate.NewOutgoingContext(context.Background(), p))
+	_, err = grpcpropagate.FromIncomingContext(context.Background(), p, srv)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+}
@@ -0,0 +1,303 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package propagate
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corestoreio/csfw/log"
+	loghttp "github.com/corestoreio/csfw/log/http"
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/net/signed"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/corestoreio/csfw/util/hashpool"
+)
+
+// Header names of the scope and its signature. The gRPC metadata keys of
+// package grpcpropagate are the lower case variants.
+const (
+	HeaderScope          = "X-Csfw-Scope"
+	HeaderScopeSignature = "X-Csfw-Scope-Hmac"
+)
+
+// Scope contains the transported scope of a request.
+type Scope struct {
+	RunMode   scope.TypeID
+	WebsiteID int64
+	StoreID   int64
+	// StoreCode optional, for example the store code from a JWT.
+	StoreCode string
+	// Created gets set when encoding the Scope.
+	Created time.Time
+}
+
+// StoreValidator checks if a store is allowed within the run mode and
+// provides the website of the store. Implemented by *store.Service.
+type StoreValidator interface {
+	IsAllowedStoreID(runMode scope.TypeID, storeID int64) (isAllowed bool, storeCode string, _ error)
+	Store(id int64) (store.Store, error)
+}
+
+// Propagator encodes and decodes the signed Scope. Safe for concurrent use
+// after setting the fields.
+type Propagator struct {
+	// MaxAge defines how long a signed Scope is valid. Zero disables the
+	// check. Defaults to one minute.
+	MaxAge time.Duration
+	// StoreCodeFn optional, returns the store code of the current request, for
+	// example jwt.StoreCodeFromContext.
+	StoreCodeFn func(context.Context) (string, bool)
+	// Optional set to true to pass requests without a scope header through to
+	// the next handler. Defaults to false, which rejects such requests.
+	Optional bool
+	// ErrorHandler optional, defaults to status code 500.
+	ErrorHandler mw.ErrorHandler
+	// UnauthorizedHandler optional, gets called when the signature or the
+	// store is not valid. Defaults to status code 401.
+	UnauthorizedHandler mw.ErrorHandler
+	// Log defaults to log.BlackHole.
+	Log log.Logger
+
+	hmac *signed.ContentHMAC
+	tank hashpool.Tank
+}
+
+// New creates a new Propagator with a HMAC of the registered hash and the key.
+// Error behaviour: NotFound or Empty.
+func New(hashName string, key []byte) (*Propagator, error) {
+	if len(key) == 0 {
+		return nil, errors.NewEmptyf("[propagate] Empty key for hash %q", hashName)
+	}
+	tank, err := hashpool.FromRegistryHMAC(hashName, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "[propagate] New")
+	}
+	h := signed.NewContentHMAC(hashName)
+	h.HeaderName = HeaderScopeSignature
+	return &Propagator{
+		MaxAge:              time.Minute,
+		ErrorHandler:        mw.ErrorWithStatusCode(http.StatusInternalServerError),
+		UnauthorizedHandler: mw.ErrorWithStatusCode(http.StatusUnauthorized),
+		Log:                 log.BlackHole{},
+		hmac:                h,
+		tank:                tank,
+	}, nil
+}
+
+// FromRequestContext collects the Scope from the context of the current
+// request, see scope.FromContextRunMode, scope.FromContext and the
+// StoreCodeFn. Returns false if the context does not contain a website and
+// store.
+func (p *Propagator) FromRequestContext(ctx context.Context) (Scope, bool) {
+	if s, ok := FromContext(ctx); ok {
+		return s, true
+	}
+	websiteID, storeID, ok := scope.FromContext(ctx)
+	if !ok {
+		return Scope{}, false
+	}
+	s := Scope{
+		RunMode:   scope.FromContextRunMode(ctx),
+		WebsiteID: websiteID,
+		StoreID:   storeID,
+	}
+	if p.StoreCodeFn != nil {
+		s.StoreCode, _ = p.StoreCodeFn(ctx)
+	}
+	return s, true
+}
+
+// Encode creates the header value of the Scope and its signature.
+func (p *Propagator) Encode(s Scope) (value, signature string) {
+	if s.Created.IsZero() {
+		s.Created = time.Now()
+	}
+	value = strings.Join([]string{
+		strconv.FormatUint(uint64(s.RunMode), 10),
+		strconv.FormatInt(s.WebsiteID, 10),
+		strconv.FormatInt(s.StoreID, 10),
+		s.StoreCode,
+		strconv.FormatInt(s.Created.Unix(), 10),
+	}, ";")
+	return value, p.hmac.Value(p.tank.Sum([]byte(value), nil))
+}
+
+// Decode verifies the signature and the age and decodes the Scope. Error
+// behaviour: NotFound, NotValid or Unauthorized.
+func (p *Propagator) Decode(value, signature string) (Scope, error) {
+	if value == "" {
+		return Scope{}, errors.NewNotFoundf("[propagate] Scope not found")
+	}
+	mac, err := p.hmac.ParseValue(signature)
+	if err != nil {
+		return Scope{}, errors.NewUnauthorized(err, "[propagate] Decode.ParseValue")
+	}
+	if !p.tank.Equal([]byte(value), mac) {
+		return Scope{}, errors.NewUnauthorizedf("[propagate] Signature of Scope %q does not match", value)
+	}
+
+	parts := strings.Split(value, ";")
+	if len(parts) != 5 {
+		return Scope{}, errors.NewNotValidf("[propagate] Scope %q has an invalid format", value)
+	}
+	var s Scope
+	var nums [4]int64
+	for i, idx := range [...]int{0, 1, 2, 4} {
+		if nums[i], err = strconv.ParseInt(parts[idx], 10, 64); err != nil {
+			return Scope{}, errors.NewNotValidf("[propagate] Scope %q: %s", value, err)
+		}
+	}
+	s.RunMode = scope.TypeID(nums[0])
+	s.WebsiteID = nums[1]
+	s.StoreID = nums[2]
+	s.StoreCode = parts[3]
+	s.Created = time.Unix(nums[3], 0)
+
+	if p.MaxAge > 0 && time.Since(s.Created) > p.MaxAge {
+		return Scope{}, errors.NewUnauthorizedf("[propagate] Scope %q expired", value)
+	}
+	return s, nil
+}
+
+// Verify decodes the Scope, see Decode, and validates the store and the
+// website with the StoreValidator, mostly the store.Service. Error
+// behaviour: NotFound, NotValid or Unauthorized.
+func (p *Propagator) Verify(sv StoreValidator, value, signature string) (Scope, error) {
+	s, err := p.Decode(value, signature)
+	if err != nil {
+		return Scope{}, errors.Wrap(err, "[propagate] Verify")
+	}
+	s, err = validate(sv, s)
+	return s, errors.Wrap(err, "[propagate] Verify")
+}
+
+// validate checks the store and its website of the Scope. Error behaviour:
+// Unauthorized.
+func validate(sv StoreValidator, s Scope) (Scope, error) {
+	isAllowed, code, err := sv.IsAllowedStoreID(s.RunMode, s.StoreID)
+	if err != nil {
+		return Scope{}, errors.Wrapf(err, "[propagate] IsAllowedStoreID RunMode %s Store %d", s.RunMode, s.StoreID)
+	}
+	if !isAllowed {
+		return Scope{}, errors.NewUnauthorizedf("[propagate] Store %d not allowed in RunMode %s", s.StoreID, s.RunMode)
+	}
+	if s.StoreCode != "" && s.StoreCode != code {
+		return Scope{}, errors.NewUnauthorizedf("[propagate] Store code %q does not match code %q of Store %d", s.StoreCode, code, s.StoreID)
+	}
+	st, err := sv.Store(s.StoreID)
+	if err != nil {
+		return Scope{}, errors.Wrapf(err, "[propagate] Store %d", s.StoreID)
+	}
+	if st.WebsiteID() != s.WebsiteID {
+		return Scope{}, errors.NewUnauthorizedf("[propagate] Website %d does not match Website %d of Store %d", s.WebsiteID, st.WebsiteID(), s.StoreID)
+	}
+	s.StoreCode = code
+	return s, nil
+}
+
+type ctxScopeKey struct{}
+
+// WithContext adds the Scope to the context. Sets also the run mode and the
+// website and store IDs of package scope.
+func WithContext(ctx context.Context, s Scope) context.Context {
+	ctx = scope.WithContextRunMode(ctx, s.RunMode)
+	ctx = scope.WithContext(ctx, s.WebsiteID, s.StoreID)
+	return context.WithValue(ctx, ctxScopeKey{}, s)
+}
+
+// FromContext returns the Scope restored by the middleware WithScope or by
+// function grpcpropagate.FromIncomingContext.
+func FromContext(ctx context.Context) (Scope, bool) {
+	s, ok := ctx.Value(ctxScopeKey{}).(Scope)
+	return s, ok
+}
+
+// SetRequestHeader adds the signed Scope of the context to the headers of an
+// outgoing request. Does nothing if the context contains no scope.
+func (p *Propagator) SetRequestHeader(ctx context.Context, r *http.Request) {
+	s, ok := p.FromRequestContext(ctx)
+	if !ok {
+		return
+	}
+	v, sig := p.Encode(s)
+	r.Header.Set(HeaderScope, v)
+	r.Header.Set(HeaderScopeSignature, sig)
+}
+
+type roundTripper struct {
+	p    *Propagator
+	next http.RoundTripper
+}
+
+func (rt roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	if _, ok := rt.p.FromRequestContext(r.Context()); !ok {
+		return rt.next.RoundTrip(r)
+	}
+	// a RoundTripper must not modify the request
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Header = make(http.Header, len(r.Header)+2)
+	for k, v := range r.Header {
+		r2.Header[k] = v
+	}
+	rt.p.SetRequestHeader(r.Context(), r2)
+	return rt.next.RoundTrip(r2)
+}
+
+// RoundTripper wraps an http.RoundTripper and adds the signed Scope of the
+// request context to each outgoing request. A nil next falls back to
+// http.DefaultTransport.
+func (p *Propagator) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripper{p: p, next: next}
+}
+
+// WithScope is a middleware which restores the signed Scope from the request
+// header into the context. The store gets validated with the StoreValidator,
+// mostly the store.Service.
+func (p *Propagator) WithScope(sv StoreValidator) mw.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, err := p.Verify(sv, r.Header.Get(HeaderScope), r.Header.Get(HeaderScopeSignature))
+			switch {
+			case err == nil:
+				if p.Log.IsDebug() {
+					p.Log.Debug("propagate.Propagator.WithScope", log.Stringer("run_mode", s.RunMode), log.Int64("website_id", s.WebsiteID),
+						log.Int64("store_id", s.StoreID), log.String("store_code", s.StoreCode), loghttp.Request("request", r))
+				}
+				next.ServeHTTP(w, r.WithContext(WithContext(r.Context(), s)))
+			case errors.IsNotFound(err) && p.Optional:
+				next.ServeHTTP(w, r)
+			case errors.IsNotFound(err), errors.IsNotValid(err), errors.IsUnauthorized(err):
+				if p.Log.IsDebug() {
+					p.Log.Debug("propagate.Propagator.WithScope.Unauthorized", log.Err(err), loghttp.Request("request", r))
+				}
+				p.UnauthorizedHandler(err).ServeHTTP(w, r)
+			default:
+				p.ErrorHandler(err).ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+var _ StoreValidator = (*store.Service)(nil)
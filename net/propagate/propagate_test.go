@@ -0,0 +1,183 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package propagate_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/propagate"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/corestoreio/csfw/util/hashpool"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	if err := hashpool.Register("sha256", sha256.New); err != nil && !errors.IsAlreadyExists(err) {
+		panic(err)
+	}
+}
+
+func newPropagator(t *testing.T, key string) *propagate.Propagator {
+	p, err := propagate.New("sha256", []byte(key))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return p
+}
+
+func TestNew_Error(t *testing.T) {
+	_, err := propagate.New("sha256", nil)
+	assert.True(t, errors.IsEmpty(err), "Error: %s", err)
+	_, err = propagate.New("md4711", []byte("key"))
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+}
+
+func TestPropagator_EncodeDecode(t *testing.T) {
+	p := newPropagator(t, "secret")
+	want := propagate.Scope{
+		RunMode:   scope.Website.Pack(1),
+		WebsiteID: 1,
+		StoreID:   2,
+		StoreCode: "at",
+		Created:   time.Unix(time.Now().Unix(), 0),
+	}
+	v, sig := p.Encode(want)
+	assert.True(t, strings.HasPrefix(sig, "sha256 "), "Signature: %q", sig)
+
+	have, err := p.Decode(v, sig)
+	assert.NoError(t, err)
+	assert.Exactly(t, want, have)
+
+	tests := []struct {
+		value, sig string
+		wantErrBhf errors.BehaviourFunc
+	}{
+		{"", sig, errors.IsNotFound},
+		{v, "", errors.IsUnauthorized},
+		{v, "md5 " + sig[7:], errors.IsUnauthorized},
+		{strings.Replace(v, ";2;at;", ";1;de;", 1), sig, errors.IsUnauthorized},
+	}
+	for i, test := range tests {
+		_, err := p.Decode(test.value, test.sig)
+		assert.True(t, test.wantErrBhf(err), "Index %d Error: %s", i, err)
+	}
+
+	// another key
+	_, err = newPropagator(t, "other").Decode(v, sig)
+	assert.True(t, errors.IsUnauthorized(err), "Error: %s", err)
+
+	// expired
+	old := want
+	old.Created = time.Now().Add(-2 * time.Minute)
+	_, err = p.Decode(p.Encode(old))
+	assert.True(t, errors.IsUnauthorized(err), "Error: %s", err)
+	p.MaxAge = 0
+	_, err = p.Decode(p.Encode(old))
+	assert.NoError(t, err)
+
+	// valid signature but invalid format
+	_, err = p.Decode(p.Encode(propagate.Scope{StoreCode: "a;b"}))
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+}
+
+func TestPropagator_HTTP(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+	p := newPropagator(t, "secret")
+	p.StoreCodeFn = func(ctx context.Context) (string, bool) {
+		return "at", true
+	}
+
+	// the remote service
+	var haveScope propagate.Scope
+	remote := httptest.NewServer(p.WithScope(srv)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		haveScope, _ = propagate.FromContext(r.Context())
+		websiteID, storeID, ok := scope.FromContext(r.Context())
+		assert.True(t, ok)
+		assert.Exactly(t, int64(1), websiteID)
+		assert.Exactly(t, int64(2), storeID)
+		assert.Exactly(t, scope.Website.Pack(1), scope.FromContextRunMode(r.Context()))
+		w.WriteHeader(http.StatusAccepted)
+	})))
+	defer remote.Close()
+	hc := &http.Client{Transport: p.RoundTripper(nil)}
+
+	ctx := scope.WithContextRunMode(context.Background(), scope.Website.Pack(1))
+	ctx = scope.WithContext(ctx, 1, 2)
+	req, _ := http.NewRequest("GET", remote.URL, nil)
+	res, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Exactly(t, http.StatusAccepted, res.StatusCode)
+	assert.Exactly(t, "at", haveScope.StoreCode)
+	assert.Empty(t, req.Header.Get(propagate.HeaderScope), "Original request must not be modified")
+
+	// without scope in the context
+	res, err = hc.Get(remote.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Exactly(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestPropagator_WithScope(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+	p := newPropagator(t, "secret")
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	tests := []struct {
+		scp      propagate.Scope
+		optional bool
+		wantCode int
+	}{
+		{propagate.Scope{RunMode: scope.Store.Pack(0), WebsiteID: 1, StoreID: 4}, false, http.StatusAccepted},
+		{propagate.Scope{RunMode: scope.Store.Pack(0), WebsiteID: 1, StoreID: 4, StoreCode: "uk"}, false, http.StatusAccepted},
+		// store code does not match
+		{propagate.Scope{RunMode: scope.Store.Pack(0), WebsiteID: 1, StoreID: 4, StoreCode: "de"}, false, http.StatusUnauthorized},
+		// inactive store
+		{propagate.Scope{RunMode: scope.Store.Pack(0), WebsiteID: 1, StoreID: 3}, false, http.StatusUnauthorized},
+		// store not in website
+		{propagate.Scope{RunMode: scope.Website.Pack(2), WebsiteID: 1, StoreID: 4}, false, http.StatusUnauthorized},
+		// website does not belong to the store
+		{propagate.Scope{RunMode: scope.Store.Pack(0), WebsiteID: 2, StoreID: 4}, false, http.StatusUnauthorized},
+		// no scope
+		{propagate.Scope{}, false, http.StatusUnauthorized},
+		{propagate.Scope{}, true, http.StatusAccepted},
+	}
+	for i, test := range tests {
+		p.Optional = test.optional
+		req := httptest.NewRequest("GET", "http://service.local/", nil)
+		if test.scp.StoreID > 0 {
+			ctx := propagate.WithContext(req.Context(), test.scp)
+			p.SetRequestHeader(ctx, req)
+		}
+		rec := httptest.NewRecorder()
+		p.WithScope(srv)(final).ServeHTTP(rec, req)
+		assert.Exactly(t, test.wantCode, rec.Code, "Index %d", i)
+	}
+}
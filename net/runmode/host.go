@@ -0,0 +1,360 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runmode
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/csfw/log"
+	loghttp "github.com/corestoreio/csfw/log/http"
+	"github.com/corestoreio/csfw/net/httputil"
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// Configuration paths used by ProcessHostPath.
+const (
+	PathWebUnsecureBaseURL = "web/unsecure/base_url"
+	PathWebSecureBaseURL   = "web/secure/base_url"
+	PathWebURLUseStore     = "web/url/use_store"
+	PathWebSecureOffloader = "web/secure/offloader_header"
+)
+
+// hostPath maps a base URL, with an optional store code as first path
+// segment, to a store.
+type hostPath struct {
+	// path always starts and ends with a slash.
+	path      string
+	storeCode string
+	storeID   int64
+	// ambiguous gets set when more than one store uses the same host and
+	// path. Such an entry cannot select a store.
+	ambiguous bool
+}
+
+// baseURLs contains the canonical base URLs of a store.
+type baseURLs struct {
+	unsecure *url.URL
+	secure   *url.URL
+	// storeCode gets set when web/url/use_store has been enabled.
+	storeCode string
+	// offloaderHeader contains the name of the header which a TLS
+	// terminating proxy sets to https.
+	offloaderHeader string
+}
+
+// ProcessHostPath extracts the store code from the host name and the URL path
+// of an HTTP request. Each store provides its base URLs in the configuration
+// paths web/unsecure/base_url and web/secure/base_url, which allows multi
+// domain setups. If the configuration flag web/url/use_store has been enabled,
+// the first path segment after the base URL must contain the store code, for
+// example https://example.com/de/. Implements interface store.CodeProcessor.
+//
+// The function Compile creates a lookup table from the stores. Call it again
+// whenever stores or their base URLs change. The Router must strip the store
+// code from the path when web/url/use_store has been enabled.
+type ProcessHostPath struct {
+	// BaseURLUnsecure defaults to path web/unsecure/base_url with store scope.
+	BaseURLUnsecure cfgmodel.BaseURL
+	// BaseURLSecure defaults to path web/secure/base_url with store scope.
+	BaseURLSecure cfgmodel.BaseURL
+	// UseStoreInURL defaults to path web/url/use_store.
+	UseStoreInURL cfgmodel.Bool
+	// OffloaderHeader defaults to path web/secure/offloader_header.
+	OffloaderHeader cfgmodel.Str
+	// IsSecure optional custom function to detect if the request has been
+	// sent via HTTPS. Defaults to a check of the TLS connection and of the
+	// header configured in OffloaderHeader for the store found in the
+	// context, which must contain the value https. See
+	// app/code/Magento/Framework/HTTP/PhpEnvironment/Request.php::isSecure
+	IsSecure func(*http.Request) bool
+	// Log defaults to log.BlackHole.
+	Log log.Logger
+
+	mu sync.RWMutex
+	// hosts maps the lower case host name, including the port, to its paths
+	// sorted by the length of the path, longest first.
+	hosts map[string][]hostPath
+	// stores maps the store ID to its base URLs.
+	stores map[int64]baseURLs
+}
+
+// NewProcessHostPath creates a new ProcessHostPath with the default
+// configuration paths and compiles the lookup table from the stores.
+func NewProcessHostPath(ss store.StoreSlice) (*ProcessHostPath, error) {
+	p := &ProcessHostPath{
+		BaseURLUnsecure: cfgmodel.NewBaseURL(PathWebUnsecureBaseURL, cfgmodel.WithScopeStore()),
+		BaseURLSecure:   cfgmodel.NewBaseURL(PathWebSecureBaseURL, cfgmodel.WithScopeStore()),
+		UseStoreInURL:   cfgmodel.NewBool(PathWebURLUseStore),
+		OffloaderHeader: cfgmodel.NewStr(PathWebSecureOffloader),
+		Log:             log.BlackHole{},
+	}
+	if err := p.Compile(ss); err != nil {
+		return nil, errors.Wrap(err, "[runmode] NewProcessHostPath.Compile")
+	}
+	return p, nil
+}
+
+func parseBaseURL(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.NewNotValidf("[runmode] Base URL %q: %s", raw, err)
+	}
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, errors.NewNotValidf("[runmode] Base URL %q requires a scheme http or https and a host", raw)
+	}
+	u.Host = strings.ToLower(u.Host)
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u, nil
+}
+
+// Compile creates the lookup table from the base URLs of all active stores.
+// Stores without a base URL get skipped. The admin store 0 gets always
+// skipped. The new table replaces the old one atomically. Error behaviour:
+// NotValid or the errors of the configuration.
+func (p *ProcessHostPath) Compile(ss store.StoreSlice) error {
+	hosts := make(map[string][]hostPath)
+	stores := make(map[int64]baseURLs)
+
+	add := func(u *url.URL, st store.Store, useStore bool) {
+		hp := hostPath{path: u.Path, storeCode: st.Code(), storeID: st.ID()}
+		if useStore {
+			hp.path += st.Code() + "/"
+		}
+		for i, have := range hosts[u.Host] {
+			if have.path == hp.path {
+				if have.storeID != hp.storeID {
+					hosts[u.Host][i].ambiguous = true
+				}
+				return
+			}
+		}
+		hosts[u.Host] = append(hosts[u.Host], hp)
+	}
+
+	for _, st := range ss {
+		if st.ID() == 0 || !st.IsActive() {
+			continue
+		}
+		var bu baseURLs
+		for _, m := range [...]struct {
+			model cfgmodel.BaseURL
+			u     **url.URL
+		}{
+			{p.BaseURLUnsecure, &bu.unsecure},
+			{p.BaseURLSecure, &bu.secure},
+		} {
+			raw, err := m.model.Get(st.Config)
+			if err != nil {
+				return errors.Wrapf(err, "[runmode] Compile Store %d Route %q", st.ID(), m.model.String())
+			}
+			if *m.u, err = parseBaseURL(raw); err != nil {
+				return errors.Wrapf(err, "[runmode] Compile Store %d", st.ID())
+			}
+		}
+		if bu.unsecure == nil && bu.secure == nil {
+			continue
+		}
+		useStore, err := p.UseStoreInURL.Get(st.Config)
+		if err != nil {
+			return errors.Wrapf(err, "[runmode] Compile Store %d Route %q", st.ID(), p.UseStoreInURL.String())
+		}
+		if useStore {
+			bu.storeCode = st.Code()
+		}
+		if bu.offloaderHeader, err = p.OffloaderHeader.Get(st.Config); err != nil {
+			return errors.Wrapf(err, "[runmode] Compile Store %d Route %q", st.ID(), p.OffloaderHeader.String())
+		}
+		if bu.unsecure != nil {
+			add(bu.unsecure, st, useStore)
+		}
+		if bu.secure != nil {
+			add(bu.secure, st, useStore)
+		}
+		stores[st.ID()] = bu
+	}
+
+	for _, hps := range hosts {
+		sort.SliceStable(hps, func(i, j int) bool { return len(hps[i].path) > len(hps[j].path) })
+	}
+
+	p.mu.Lock()
+	p.hosts = hosts
+	p.stores = stores
+	p.mu.Unlock()
+	return nil
+}
+
+// FromRequest returns the code of the store whose base URL matches the host
+// and the longest prefix of the path of the request. Returns an empty code if
+// no store or more than one store can be found. Implements interface
+// store.CodeProcessor.
+func (p *ProcessHostPath) FromRequest(_ scope.TypeID, req *http.Request) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	reqPath := req.URL.Path
+	if !strings.HasSuffix(reqPath, "/") {
+		reqPath += "/" // /de equals /de/
+	}
+	for _, hp := range p.hosts[strings.ToLower(req.Host)] {
+		if strings.HasPrefix(reqPath, hp.path) {
+			if hp.ambiguous {
+				return ""
+			}
+			return hp.storeCode
+		}
+	}
+	return ""
+}
+
+// ProcessDenied does nothing. Implements interface store.CodeProcessor.
+func (p *ProcessHostPath) ProcessDenied(_ scope.TypeID, _, _ int64, _ http.ResponseWriter, _ *http.Request) {
+}
+
+// ProcessAllowed does nothing, redirects to the canonical base URL get
+// handled in the middleware WithValidateBaseURL. Implements interface
+// store.CodeProcessor.
+func (p *ProcessHostPath) ProcessAllowed(_ scope.TypeID, _, _ int64, _ string, _ http.ResponseWriter, _ *http.Request) {
+}
+
+// BaseURL returns the compiled base URL of a store. The secure base URL falls
+// back to the unsecure one and vice versa. Returns nil if the store has no
+// base URL.
+func (p *ProcessHostPath) BaseURL(storeID int64, isSecure bool) *url.URL {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	bu := p.stores[storeID]
+	u := bu.unsecure
+	if isSecure && bu.secure != nil || u == nil {
+		u = bu.secure
+	}
+	return u
+}
+
+// StoreURL returns the base URL of a store including the store code as first
+// path segment, if web/url/use_store has been enabled. Returns nil if the
+// store has no base URL.
+func (p *ProcessHostPath) StoreURL(storeID int64, isSecure bool) *url.URL {
+	u := p.BaseURL(storeID, isSecure)
+	if u == nil {
+		return nil
+	}
+	p.mu.RLock()
+	code := p.stores[storeID].storeCode
+	p.mu.RUnlock()
+	su := *u
+	if code != "" {
+		su.Path += code + "/"
+	}
+	return &su
+}
+
+// isSecure checks the TLS connection and the offloader header of the store
+// found in the context.
+func (p *ProcessHostPath) isSecure(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	_, storeID, ok := scope.FromContext(r.Context())
+	if !ok {
+		return false
+	}
+	p.mu.RLock()
+	oh := p.stores[storeID].offloaderHeader
+	p.mu.RUnlock()
+	if oh == "" {
+		return false
+	}
+	return strings.EqualFold(r.Header.Get(oh), "https") || strings.EqualFold(r.Header.Get("HTTP_"+oh), "https")
+}
+
+// WithValidateBaseURL is a middleware which redirects GET and HEAD requests
+// to the canonical base URL of the store found in the context, for example
+// from http://example.com/de/ to https://www.example.com/de/. Requests of
+// stores without a base URL pass through. The middleware must be added after
+// WithRunMode. The redirectCode should be either http.StatusMovedPermanently
+// or http.StatusFound, zero disables the middleware. Behind a TLS terminating
+// proxy the scheme gets detected with the function IsSecure. See
+// app/code/Magento/Store/App/FrontController/Plugin/RequestPreprocessor.php
+func (p *ProcessHostPath) WithValidateBaseURL(redirectCode int) mw.Middleware {
+	return func(next http.Handler) http.Handler {
+		if redirectCode == 0 {
+			return next
+		}
+		isSecure := p.IsSecure
+		if isSecure == nil {
+			isSecure = p.isSecure
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "GET" && r.Method != "HEAD" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			_, storeID, ok := scope.FromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			secure := isSecure(r)
+			baseURL := p.BaseURL(storeID, secure)
+			if baseURL == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// the server side request URL contains neither host nor scheme.
+			reqURL := *r.URL
+			reqURL.Host = r.Host
+			reqURL.Scheme = "http"
+			if secure {
+				reqURL.Scheme = "https"
+			}
+			r2 := *r
+			r2.URL = &reqURL
+			if err := httputil.IsBaseURLCorrect(&r2, baseURL); err == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			target := *baseURL
+			if !strings.HasPrefix(r.URL.Path, baseURL.Path) {
+				target.Path = baseURL.Path + strings.TrimPrefix(r.URL.Path, "/")
+			} else {
+				target.Path = r.URL.Path
+			}
+			target.RawQuery = r.URL.RawQuery
+			if p.Log.IsDebug() {
+				p.Log.Debug("runmode.ProcessHostPath.WithValidateBaseURL.Redirect", log.Int64("store_id", storeID),
+					log.Stringer("base_url", baseURL), log.Stringer("target", &target), loghttp.Request("request", r))
+			}
+			http.Redirect(w, r, target.String(), redirectCode)
+		})
+	}
+}
+
+var _ store.CodeProcessor = (*ProcessHostPath)(nil)
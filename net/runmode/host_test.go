@@ -0,0 +1,186 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runmode_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/runmode"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProcessHostPath(t *testing.T, pv cfgmock.PathValue) *runmode.ProcessHostPath {
+	srv := storemock.NewEurozzyService(cfgmock.NewService(pv))
+	p, err := runmode.NewProcessHostPath(srv.Stores())
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return p
+}
+
+func TestProcessHostPath_FromRequest_MultiDomain(t *testing.T) {
+	p := newProcessHostPath(t, cfgmock.PathValue{
+		"stores/1/web/unsecure/base_url": "http://www.example.de/",
+		"stores/1/web/secure/base_url":   "https://www.example.de/",
+		"stores/2/web/unsecure/base_url": "http://www.example.at",
+		"stores/3/web/unsecure/base_url": "http://www.example.ch/", // inactive
+		"stores/4/web/unsecure/base_url": "http://WWW.example.co.uk:8080/shop/",
+		"stores/5/web/unsecure/base_url": "http://www.example.com.au/",
+		"stores/6/web/unsecure/base_url": "http://www.example.com.au/", // ambiguous
+	})
+
+	tests := []struct {
+		target   string
+		wantCode string
+	}{
+		{"http://www.example.de/", "de"},
+		{"https://www.example.de/checkout/cart", "de"},
+		{"http://www.example.at/catalog/product.html", "at"},
+		{"http://www.example.ch/", ""},
+		{"http://www.example.co.uk:8080/shop/product.html", "uk"},
+		{"http://www.example.co.uk:8080/shop", "uk"},
+		{"http://www.example.co.uk:8080/", ""},
+		{"http://www.example.co.uk/shop/", ""},
+		{"http://www.example.com.au/", ""},
+		{"http://www.example.fr/", ""},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest("GET", test.target, nil)
+		assert.Exactly(t, test.wantCode, p.FromRequest(scope.DefaultTypeID, req), "Index %d", i)
+	}
+}
+
+func TestProcessHostPath_FromRequest_UseStore(t *testing.T) {
+	p := newProcessHostPath(t, cfgmock.PathValue{
+		"default/0/web/url/use_store":      1,
+		"websites/1/web/unsecure/base_url": "http://www.example.eu/",
+		"stores/5/web/unsecure/base_url":   "http://www.example.com.au/",
+		"stores/6/web/unsecure/base_url":   "http://www.example.com.au/nz/",
+	})
+
+	tests := []struct {
+		target   string
+		wantCode string
+	}{
+		{"http://www.example.eu/de/", "de"},
+		{"http://www.example.eu/de", "de"},
+		{"http://www.example.eu/at/catalog/product.html", "at"},
+		{"http://www.example.eu/uk/", "uk"},
+		{"http://www.example.eu/design/", ""},
+		{"http://www.example.eu/", ""},
+		{"http://www.example.com.au/au/", "au"},
+		{"http://www.example.com.au/nz/nz/", "nz"},
+		{"http://www.example.com.au/nz/", ""},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest("GET", test.target, nil)
+		assert.Exactly(t, test.wantCode, p.FromRequest(scope.DefaultTypeID, req), "Index %d", i)
+	}
+}
+
+func TestProcessHostPath_Compile_Error(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService(cfgmock.PathValue{
+		"stores/1/web/unsecure/base_url": "www.example.de/",
+	}))
+	p, err := runmode.NewProcessHostPath(srv.Stores())
+	assert.Nil(t, p)
+	assert.True(t, errors.IsNotValid(err), "Error: %+v", err)
+}
+
+func TestProcessHostPath_WithValidateBaseURL(t *testing.T) {
+	p := newProcessHostPath(t, cfgmock.PathValue{
+		"stores/1/web/unsecure/base_url": "http://www.example.de/",
+		"stores/1/web/secure/base_url":   "https://www.example.de/",
+		"stores/4/web/unsecure/base_url": "http://www.example.co.uk/shop/",
+	})
+
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	h := p.WithValidateBaseURL(http.StatusMovedPermanently)(final)
+
+	tests := []struct {
+		method       string
+		target       string
+		isTLS        bool
+		storeID      int64
+		wantCode     int
+		wantLocation string
+	}{
+		{"GET", "http://www.example.de/catalog", false, 1, http.StatusAccepted, ""},
+		{"GET", "https://www.example.de/catalog", true, 1, http.StatusAccepted, ""},
+		{"GET", "http://example.de/catalog?a=b", false, 1, http.StatusMovedPermanently, "http://www.example.de/catalog?a=b"},
+		{"GET", "https://example.de/catalog", true, 1, http.StatusMovedPermanently, "https://www.example.de/catalog"},
+		{"HEAD", "http://www.example.co.uk/product.html", false, 4, http.StatusMovedPermanently, "http://www.example.co.uk/shop/product.html"},
+		{"POST", "http://example.de/catalog", false, 1, http.StatusAccepted, ""},
+		{"GET", "http://example.at/catalog", false, 2, http.StatusAccepted, ""}, // no base URL
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest(test.method, test.target, nil)
+		if test.isTLS {
+			req.TLS = new(tls.ConnectionState)
+		}
+		req = req.WithContext(scope.WithContext(req.Context(), 1, test.storeID))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Exactly(t, test.wantCode, rec.Code, "Index %d", i)
+		assert.Exactly(t, test.wantLocation, rec.Header().Get("Location"), "Index %d", i)
+	}
+}
+
+func TestProcessHostPath_WithValidateBaseURL_Offloader(t *testing.T) {
+	p := newProcessHostPath(t, cfgmock.PathValue{
+		"default/0/web/secure/offloader_header": "X-Forwarded-Proto",
+		"stores/1/web/unsecure/base_url":        "https://www.example.de/",
+		"stores/1/web/secure/base_url":          "https://www.example.de/",
+	})
+
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	serve := func(h http.Handler, header string) *httptest.ResponseRecorder {
+		// the TLS terminating proxy forwards the request via plain HTTP
+		req := httptest.NewRequest("GET", "http://www.example.de/catalog", nil)
+		if header != "" {
+			req.Header.Set(header, "https")
+		}
+		req = req.WithContext(scope.WithContext(req.Context(), 1, 1))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	h := p.WithValidateBaseURL(http.StatusFound)(final)
+	assert.Exactly(t, http.StatusAccepted, serve(h, "X-Forwarded-Proto").Code)
+	assert.Exactly(t, http.StatusAccepted, serve(h, "HTTP_X-Forwarded-Proto").Code)
+	rec := serve(h, "")
+	assert.Exactly(t, http.StatusFound, rec.Code)
+	assert.Exactly(t, "https://www.example.de/catalog", rec.Header().Get("Location"))
+
+	p.IsSecure = func(r *http.Request) bool {
+		return r.Header.Get("X-Custom-Secure") == "https"
+	}
+	h = p.WithValidateBaseURL(http.StatusFound)(final)
+	assert.Exactly(t, http.StatusAccepted, serve(h, "X-Custom-Secure").Code)
+	assert.Exactly(t, http.StatusFound, serve(h, "X-Forwarded-Proto").Code)
+}
@@ -25,71 +25,6 @@ import (
 	"github.com/corestoreio/csfw/util/errors"
 )
 
-// WithValidateBaseURL is a middleware which checks if the request base URL is
-// equal to the one defined in the configuration, if not i.e. redirect from
-// http://example.com/store/ to http://www.example.com/store/ @see
-// app/code/Magento/Store/App/FrontController/Plugin/RequestPreprocessor.php
-// @todo refactor this whole function as BaseURL must be bound to a store type
-//func WithValidateBaseURL(cg config.GetterPubSuber, l log.Logger) mw.Middleware {
-//
-//	// Having the GetBool command here, means you must restart the app to take
-//	// changes in effect. @todo refactor and use pub/sub to automatically change
-//	// the isRedirectToBase value.
-//
-//	// <todo check logic!>
-//	cgDefaultScope := cg.NewScoped(0, 0)
-//	configRedirectCode, err := backend.Backend.WebURLRedirectToBase.Get(cgDefaultScope) // remove dependency
-//	if err != nil {
-//		panic(err) // we can panic here because during app start up
-//	}
-//
-//	redirectCode := http.StatusMovedPermanently
-//	if configRedirectCode != redirectCode {
-//		redirectCode = http.StatusFound
-//	}
-//	// </todo check logic>
-//
-//	return func(h http.Handler) http.Handler {
-//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-//
-//			if configRedirectCode > 0 && r.Method != "POST" {
-//
-//				requestedStore, err := store.FromContextRequestedStore(r.Context())
-//				if err != nil {
-//					if l.IsDebug() {
-//						l.Debug("http.WithValidateBaseUrl.FromContextServiceReader", log.Err(err), log.Object("request", r))
-//					}
-//					serveError(h, w, r, errors.Wrap(err, "[runmode] Context"))
-//					return
-//				}
-//
-//				baseURL, err := requestedStore.BaseURL(config.URLTypeWeb, requestedStore.IsCurrentlySecure(r))
-//				if err != nil {
-//					if l.IsDebug() {
-//						l.Debug("http.WithValidateBaseUrl.requestedStore.BaseURL", log.Err(err), log.Object("request", r))
-//					}
-//					serveError(h, w, r, errors.Wrap(err, "[runmode] BaseURL"))
-//					return
-//				}
-//
-//				if err := httputil.IsBaseURLCorrect(r, &baseURL); err != nil {
-//					if l.IsDebug() {
-//						l.Debug("store.WithValidateBaseUrl.IsBaseUrlCorrect.error", log.Err(err), log.Object("request", r), log.Stringer("baseURL", &baseURL))
-//					}
-//
-//					baseURL.Path = r.URL.Path
-//					baseURL.RawPath = r.URL.RawPath
-//					baseURL.RawQuery = r.URL.RawQuery
-//					baseURL.Fragment = r.URL.Fragment
-//					http.Redirect(w, r, (&baseURL).String(), redirectCode)
-//					return
-//				}
-//			}
-//			h.ServeHTTP(w, r)
-//		})
-//	}
-//}
-
 // Options additional customizations for the runMode middleware.
 type Options struct {
 	// ErrorHandler optional custom error handler. Defaults to sending an HTTP
@@ -106,7 +41,8 @@ type Options struct {
 	// store. To use the admin area enable scope.Store and ID 0.
 	scope.RunModeCalculater
 	// StoreCodeProcessor extracts the store code from an HTTP requests.
-	// Optional. Defaults to type ProcessStoreCodeCookie.
+	// Optional. Defaults to type ProcessStoreCodeCookie. Use type
+	// ProcessHostPath to find the store by host name and URL path.
 	store.CodeProcessor
 	// DisableStoreCodeProcessor set to true and set StoreCodeProcessor to nil
 	// to disable store code handling
@@ -123,8 +59,6 @@ type Options struct {
 //	4. Check if the website/store ID
 func WithRunMode(sf store.Finder, o Options) mw.Middleware {
 
-	// todo: code/Magento/Store/App/Request/PathInfoProcessor.php => req.isDirectAccessFrontendName()
-
 	lg := o.Log
 	if lg == nil {
 		lg = log.BlackHole{} // disabled debug and info logging
@@ -0,0 +1,295 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runmode
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/corestoreio/csfw/log"
+	loghttp "github.com/corestoreio/csfw/log/http"
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// Query parameters used by SwitchStore. The target store code gets read from
+// store.CodeURLFieldName.
+const (
+	// SwitchFromStoreFieldName contains the code of the current store.
+	SwitchFromStoreFieldName = `___from_store`
+	// SwitchReturnURLFieldName contains the base64 URL encoded current URL,
+	// same as the uenc parameter in Magento.
+	SwitchReturnURLFieldName = `uenc`
+)
+
+// StoreChecker finds and validates stores. Implemented by *store.Service.
+type StoreChecker interface {
+	store.Finder
+	// IsAllowedStoreID checks if the store ID is allowed within the runMode.
+	IsAllowedStoreID(runMode scope.TypeID, storeID int64) (isAllowed bool, storeCode string, _ error)
+}
+
+// PathRewriter translates the URL path of a store into the equivalent path
+// of another store. Paths have no leading slash and are relative to the base
+// URL of the store. A path which is not a rewrite, for example checkout/cart,
+// gets returned unchanged. Error behaviour: NotFound if the target store does
+// not have an equivalent path.
+type PathRewriter interface {
+	RewritePath(fromStoreID, toStoreID int64, path string) (string, error)
+}
+
+// SwitchStore is an http.Handler which switches to another store and
+// redirects to the equivalent URL of the current page in the target store.
+// A request looks like:
+//		/store/switch?___store=fr&___from_store=de&uenc=aHR0cDovL2V4YW1wbGUuY29tL2RlL3NjaHVoZS5odG1sP2NvbG9yPXJlZA
+// The target store gets validated with IsAllowedStoreID in the runMode of the
+// request. The current store falls back to the store in the context, see
+// WithRunMode, and the current URL falls back to the Referer. Query
+// parameters of the current URL are preserved. If the current path cannot be
+// found in the target store, the redirect goes to the home page of the target
+// store. See app/code/Magento/Store/Controller/Store/SwitchAction.php
+type SwitchStore struct {
+	// Stores validates the target store, mostly *store.Service. Required.
+	Stores StoreChecker
+	// Rewriter optional, translates the URL path, for example URLRewrite.
+	// If nil the path stays the same.
+	Rewriter PathRewriter
+	// StoreURL optional, returns the base URL of a store, for example
+	// ProcessHostPath.StoreURL. If nil or returns nil, the redirect stays on
+	// the same host and the Cookie must store the new store code.
+	StoreURL func(storeID int64, isSecure bool) *url.URL
+	// Cookie optional, sets the store code cookie of the target store.
+	Cookie *ProcessStoreCodeCookie
+	// RedirectCode defaults to http.StatusFound.
+	RedirectCode int
+	// ErrorHandler optional, defaults to status code 500.
+	ErrorHandler mw.ErrorHandler
+	// UnauthorizedHandler optional, gets called when the target store is not
+	// allowed. Defaults to status code 401.
+	UnauthorizedHandler mw.ErrorHandler
+	// Log defaults to log.BlackHole.
+	Log log.Logger
+}
+
+// NewSwitchStore creates a new store switcher with the default handlers.
+func NewSwitchStore(sc StoreChecker) *SwitchStore {
+	return &SwitchStore{
+		Stores:              sc,
+		RedirectCode:        http.StatusFound,
+		ErrorHandler:        mw.ErrorWithStatusCode(http.StatusInternalServerError),
+		UnauthorizedHandler: mw.ErrorWithStatusCode(http.StatusUnauthorized),
+		Log:                 log.BlackHole{},
+	}
+}
+
+// returnURL extracts the current URL from the query or the Referer.
+func returnURL(r *http.Request) (*url.URL, error) {
+	raw := r.Referer()
+	if enc := r.URL.Query().Get(SwitchReturnURLFieldName); enc != "" {
+		b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(enc, "=,"))
+		if err != nil {
+			return nil, errors.NewNotValidf("[runmode] SwitchStore return URL %q: %s", enc, err)
+		}
+		raw = string(b)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.NewNotValidf("[runmode] SwitchStore return URL %q: %s", raw, err)
+	}
+	return u, nil
+}
+
+// fromStoreID returns the ID of the current store.
+func (s *SwitchStore) fromStoreID(runMode scope.TypeID, r *http.Request) (int64, error) {
+	if code := r.URL.Query().Get(SwitchFromStoreFieldName); code != "" {
+		id, _, err := s.Stores.StoreIDbyCode(runMode, code)
+		return id, errors.Wrapf(err, "[runmode] SwitchStore.StoreIDbyCode %q", code)
+	}
+	if _, id, ok := scope.FromContext(r.Context()); ok {
+		return id, nil
+	}
+	id, _, err := s.Stores.DefaultStoreID(runMode)
+	return id, errors.Wrap(err, "[runmode] SwitchStore.DefaultStoreID")
+}
+
+// relativePath strips the base URL of the store and all leading slashes from
+// the path. A path like "//evil.com/x" must not become a protocol relative
+// URL in the redirect.
+func (s *SwitchStore) relativePath(storeID int64, isSecure bool, p string) string {
+	if s.StoreURL != nil {
+		if bu := s.StoreURL(storeID, isSecure); bu != nil && strings.HasPrefix(p, bu.Path) {
+			p = p[len(bu.Path):]
+		}
+	}
+	return strings.TrimLeft(p, "/")
+}
+
+// targetURL creates the URL of the path in the target store.
+func (s *SwitchStore) targetURL(storeID int64, isSecure bool, p string, query url.Values) *url.URL {
+	u := &url.URL{Path: "/"}
+	if s.StoreURL != nil {
+		if bu := s.StoreURL(storeID, isSecure); bu != nil {
+			cu := *bu
+			u = &cu
+		}
+	}
+	u.Path += p
+	query.Del(store.CodeURLFieldName)
+	query.Del(SwitchFromStoreFieldName)
+	query.Del(SwitchReturnURLFieldName)
+	u.RawQuery = query.Encode()
+	return u
+}
+
+// ServeHTTP switches the store and redirects. Implements http.Handler.
+func (s *SwitchStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	runMode := scope.FromContextRunMode(r.Context())
+	toCode := r.URL.Query().Get(store.CodeURLFieldName)
+
+	var toStoreID int64
+	err := store.CodeIsValid(toCode)
+	if err == nil {
+		toStoreID, _, err = s.Stores.StoreIDbyCode(runMode, toCode)
+	}
+	if err != nil && !errors.IsNotFound(err) && !errors.IsNotValid(err) {
+		s.ErrorHandler(errors.Wrapf(err, "[runmode] SwitchStore.StoreIDbyCode %q", toCode)).ServeHTTP(w, r)
+		return
+	}
+	var isAllowed bool
+	if err == nil {
+		isAllowed, toCode, err = s.Stores.IsAllowedStoreID(runMode, toStoreID)
+		if err != nil {
+			s.ErrorHandler(errors.Wrapf(err, "[runmode] SwitchStore.IsAllowedStoreID %d", toStoreID)).ServeHTTP(w, r)
+			return
+		}
+	}
+	if !isAllowed {
+		if s.Log.IsDebug() {
+			s.Log.Debug("runmode.SwitchStore.StoreNotAllowed", log.String("store_code", toCode), log.Int64("store_id", toStoreID),
+				log.Stringer("run_mode", runMode), loghttp.Request("request", r))
+		}
+		s.UnauthorizedHandler(errors.NewUnauthorizedf("[runmode] RunMode %s with requested Store %q cannot be authorized", runMode, toCode)).ServeHTTP(w, r)
+		return
+	}
+
+	fromStoreID, err := s.fromStoreID(runMode, r)
+	if err != nil {
+		s.ErrorHandler(err).ServeHTTP(w, r)
+		return
+	}
+	retURL, err := returnURL(r)
+	if err != nil {
+		s.ErrorHandler(err).ServeHTTP(w, r)
+		return
+	}
+
+	isSecure := r.TLS != nil || retURL.Scheme == "https"
+	p := s.relativePath(fromStoreID, isSecure, retURL.Path)
+	if s.Rewriter != nil && fromStoreID != toStoreID {
+		np, err := s.Rewriter.RewritePath(fromStoreID, toStoreID, p)
+		switch {
+		case errors.IsNotFound(err):
+			np = "" // home page
+		case err != nil:
+			s.ErrorHandler(errors.Wrapf(err, "[runmode] SwitchStore.RewritePath %q", p)).ServeHTTP(w, r)
+			return
+		}
+		p = np
+	}
+	target := s.targetURL(toStoreID, isSecure, p, retURL.Query())
+
+	if s.Cookie != nil {
+		s.Cookie.setStoreCookie(toCode, w, r)
+	}
+	if s.Log.IsDebug() {
+		s.Log.Debug("runmode.SwitchStore.Redirect", log.Int64("from_store_id", fromStoreID), log.Int64("store_id", toStoreID),
+			log.Stringer("target", target), loghttp.Request("request", r))
+	}
+	code := s.RedirectCode
+	if code == 0 {
+		code = http.StatusFound
+	}
+	http.Redirect(w, r, target.String(), code)
+}
+
+// URLRewrite translates paths with the Magento 2 table url_rewrite.
+// Implements interface PathRewriter.
+type URLRewrite struct {
+	// DB a session to the database. Required.
+	DB *dbr.Session
+	// TableName defaults to url_rewrite.
+	TableName string
+}
+
+func (ur URLRewrite) tableName() string {
+	if ur.TableName == "" {
+		return "url_rewrite"
+	}
+	return ur.TableName
+}
+
+// RewritePath finds the entity of the request path in the source store and
+// returns the request path of the same entity in the target store. Paths
+// which do not belong to an entity, like custom rewrites or no rewrites at
+// all, get returned unchanged. Error behaviour: NotFound.
+func (ur URLRewrite) RewritePath(fromStoreID, toStoreID int64, path string) (string, error) {
+	rows, err := ur.DB.Select("entity_type", "entity_id").From(ur.tableName()).Where(
+		dbr.ConditionRaw("request_path = ?", path),
+		dbr.ConditionRaw("store_id = ?", fromStoreID),
+	).Limit(1).Rows()
+	if err != nil {
+		return "", errors.Wrap(err, "[runmode] URLRewrite.RewritePath.Rows")
+	}
+	var entityType string
+	var entityID int64
+	var found bool
+	for rows.Next() {
+		if err := rows.Scan(&entityType, &entityID); err != nil {
+			_ = rows.Close()
+			return "", errors.Wrap(err, "[runmode] URLRewrite.RewritePath.Scan")
+		}
+		found = true
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return "", errors.Wrap(err, "[runmode] URLRewrite.RewritePath.Rows.Err")
+	}
+	if err := rows.Close(); err != nil {
+		return "", errors.Wrap(err, "[runmode] URLRewrite.RewritePath.Rows.Close")
+	}
+	if !found || entityID == 0 {
+		return path, nil
+	}
+
+	var toPath string
+	err = ur.DB.Select("request_path").From(ur.tableName()).Where(
+		dbr.ConditionRaw("entity_type = ?", entityType),
+		dbr.ConditionRaw("entity_id = ?", entityID),
+		dbr.ConditionRaw("store_id = ?", toStoreID),
+		dbr.ConditionRaw("redirect_type = ?", 0),
+	).Limit(1).LoadValue(&toPath)
+	if errors.IsNotFound(err) {
+		return "", errors.NewNotFoundf("[runmode] URLRewrite %s %d of path %q not found in Store %d", entityType, entityID, path, toStoreID)
+	}
+	return toPath, errors.Wrap(err, "[runmode] URLRewrite.RewritePath.LoadValue")
+}
+
+var _ PathRewriter = (*URLRewrite)(nil)
+var _ StoreChecker = (*store.Service)(nil)
@@ -0,0 +1,181 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runmode_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/runmode"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mapRewriter translates paths from store 1 (de) into store 2 (at).
+type mapRewriter map[string]string
+
+func (mr mapRewriter) RewritePath(fromStoreID, toStoreID int64, path string) (string, error) {
+	if path == "broken.html" {
+		return "", errors.NewFatalf("Database gone")
+	}
+	if fromStoreID != 1 || toStoreID != 2 {
+		return path, nil
+	}
+	p, ok := mr[path]
+	if !ok {
+		return "", errors.NewNotFoundf("Path %q not found", path)
+	}
+	return p, nil
+}
+
+func uenc(u string) string {
+	return base64.URLEncoding.EncodeToString([]byte(u))
+}
+
+func TestSwitchStore_ServeHTTP(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService(cfgmock.PathValue{
+		"default/0/web/url/use_store":      1,
+		"websites/1/web/unsecure/base_url": "http://www.example.eu/",
+		"websites/1/web/secure/base_url":   "https://www.example.eu/",
+		"stores/5/web/unsecure/base_url":   "http://www.example.com.au/",
+	}))
+	hp, err := runmode.NewProcessHostPath(srv.Stores())
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	sw := runmode.NewSwitchStore(srv)
+	sw.StoreURL = hp.StoreURL
+	sw.Rewriter = mapRewriter{
+		"schuhe.html":          "schuhe-at.html",
+		"herren/schuhe.html":   "herren/schuhe-at.html",
+		"checkout/cart":        "checkout/cart",
+		"damen/kleider-1.html": "",
+	}
+	sw.Cookie = &runmode.ProcessStoreCodeCookie{}
+
+	tests := []struct {
+		target       string
+		referer      string
+		ctxStoreID   int64
+		wantCode     int
+		wantLocation string
+	}{
+		{"/store/switch?___store=at&___from_store=de&uenc=" + uenc("http://www.example.eu/de/schuhe.html?color=red&___store=de"), "", -1,
+			http.StatusFound, "http://www.example.eu/at/schuhe-at.html?color=red"},
+		{"/store/switch?___store=at&___from_store=de&uenc=" + uenc("https://www.example.eu/de/herren/schuhe.html"), "", -1,
+			http.StatusFound, "https://www.example.eu/at/herren/schuhe-at.html"},
+		// current store from the context and current URL from the Referer
+		{"/store/switch?___store=at", "http://www.example.eu/de/schuhe.html?size=42", 1,
+			http.StatusFound, "http://www.example.eu/at/schuhe-at.html?size=42"},
+		// path not available in target store redirects to the home page
+		{"/store/switch?___store=at&___from_store=de&uenc=" + uenc("http://www.example.eu/de/hosen.html?a=b"), "", -1,
+			http.StatusFound, "http://www.example.eu/at/?a=b"},
+		// different website, without rewrites
+		{"/store/switch?___store=au&___from_store=de&uenc=" + uenc("http://www.example.eu/de/checkout/cart"), "", -1,
+			http.StatusFound, "http://www.example.com.au/au/checkout/cart"},
+		// store without base URL stays on the same host
+		{"/store/switch?___store=nz&___from_store=au&uenc=" + uenc("http://www.example.com.au/au/catalog.html"), "", -1,
+			http.StatusFound, "/catalog.html"},
+		// a path with leading slashes must not redirect to another host
+		{"/store/switch?___store=nz&___from_store=au&uenc=" + uenc("http://www.example.com.au//evil.com/x"), "", -1,
+			http.StatusFound, "/evil.com/x"},
+		// inactive and unknown stores
+		{"/store/switch?___store=ch&___from_store=de", "", -1, http.StatusUnauthorized, ""},
+		{"/store/switch?___store=xx&___from_store=de", "", -1, http.StatusUnauthorized, ""},
+		{"/store/switch", "", -1, http.StatusUnauthorized, ""},
+		// errors
+		{"/store/switch?___store=at&___from_store=de&uenc=!!", "", -1, http.StatusInternalServerError, ""},
+		{"/store/switch?___store=at&___from_store=de&uenc=" + uenc("http://www.example.eu/de/broken.html"), "", -1, http.StatusInternalServerError, ""},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest("GET", test.target, nil)
+		if test.referer != "" {
+			req.Header.Set("Referer", test.referer)
+		}
+		if test.ctxStoreID >= 0 {
+			req = req.WithContext(scope.WithContext(req.Context(), 1, test.ctxStoreID))
+		}
+		req = req.WithContext(scope.WithContextRunMode(req.Context(), scope.Store.Pack(0)))
+		rec := httptest.NewRecorder()
+		sw.ServeHTTP(rec, req)
+
+		assert.Exactly(t, test.wantCode, rec.Code, "Index %d", i)
+		assert.Exactly(t, test.wantLocation, rec.Header().Get("Location"), "Index %d", i)
+		if test.wantCode == http.StatusFound {
+			assert.Contains(t, rec.Header().Get("Set-Cookie"), "store=", "Index %d", i)
+		}
+	}
+
+	// website oz cannot be switched to within the run mode of website euro
+	req := httptest.NewRequest("GET", "/store/switch?___store=au&___from_store=de", nil)
+	req = req.WithContext(scope.WithContextRunMode(req.Context(), scope.Website.Pack(1)))
+	rec := httptest.NewRecorder()
+	sw.ServeHTTP(rec, req)
+	assert.Exactly(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestURLRewrite_RewritePath(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}()
+	ur := runmode.URLRewrite{DB: dbc.NewSession()}
+
+	// product found in both stores
+	dbMock.ExpectQuery("SELECT entity_type, entity_id FROM `url_rewrite` WHERE \\(request_path = \\?\\) AND \\(store_id = \\?\\) LIMIT 1").
+		WithArgs("schuhe.html", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"entity_type", "entity_id"}).AddRow("product", 33))
+	dbMock.ExpectQuery("SELECT request_path FROM `url_rewrite` WHERE \\(entity_type = 'product'\\) AND \\(entity_id = 33\\) AND \\(store_id = 2\\) AND \\(redirect_type = 0\\) LIMIT 1").
+		WillReturnRows(sqlmock.NewRows([]string{"request_path"}).AddRow("schuhe-at.html"))
+	p, err := ur.RewritePath(1, 2, "schuhe.html")
+	assert.NoError(t, err)
+	assert.Exactly(t, "schuhe-at.html", p)
+
+	// no rewrite
+	dbMock.ExpectQuery("SELECT entity_type, entity_id FROM `url_rewrite`").
+		WithArgs("checkout/cart", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"entity_type", "entity_id"}))
+	p, err = ur.RewritePath(1, 2, "checkout/cart")
+	assert.NoError(t, err)
+	assert.Exactly(t, "checkout/cart", p)
+
+	// category not available in target store
+	dbMock.ExpectQuery("SELECT entity_type, entity_id FROM `url_rewrite`").
+		WithArgs("damen.html", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"entity_type", "entity_id"}).AddRow("category", 4))
+	dbMock.ExpectQuery("SELECT request_path FROM `url_rewrite`").
+		WillReturnRows(sqlmock.NewRows([]string{"request_path"}))
+	p, err = ur.RewritePath(1, 2, "damen.html")
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	assert.Empty(t, p)
+
+	// database error
+	dbMock.ExpectQuery("SELECT entity_type, entity_id FROM `url_rewrite`").
+		WillReturnError(errors.NewFatalf("Database gone"))
+	_, err = ur.RewritePath(1, 2, "damen.html")
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+}
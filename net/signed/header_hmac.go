@@ -61,11 +61,18 @@ func (h *ContentHMAC) HeaderKey() string {
 // Content-HMAC: <hash mechanism> <encoded binary HMAC>
 // Content-HMAC: sha1 f1wOnLLwcTexwCSRCNXEAKPDm+U=
 func (h *ContentHMAC) Write(w http.ResponseWriter, signature []byte) {
+	w.Header().Set(h.HeaderKey(), h.Value(signature))
+}
+
+// Value encodes the signature into the header value. Useful for transports
+// other than an HTTP response, like request headers or gRPC metadata.
+//		sha1 f1wOnLLwcTexwCSRCNXEAKPDm+U=
+func (h *ContentHMAC) Value(signature []byte) string {
 	encFn := h.EncodeFn
 	if encFn == nil {
 		encFn = hex.EncodeToString
 	}
-	w.Header().Set(h.HeaderKey(), h.Algorithm+" "+encFn(signature))
+	return h.Algorithm + " " + encFn(signature)
 }
 
 // Parse looks up the header or trailer for the HeaderKey Content-HMAC in an
@@ -77,6 +84,13 @@ func (h *ContentHMAC) Parse(r *http.Request) (signature []byte, _ error) {
 	if hv == "" {
 		hv = r.Trailer.Get(hk)
 	}
+	return h.ParseValue(hv)
+}
+
+// ParseValue extracts the raw decoded signature from a header value created
+// by function Value. Errors can have the behaviour: NotFound or NotValid.
+func (h *ContentHMAC) ParseValue(hv string) (signature []byte, _ error) {
+	hk := h.HeaderKey()
 	if hv == "" {
 		return nil, errors.NewNotFoundf(errHMACParseNotFound)
 	}
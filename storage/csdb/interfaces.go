@@ -40,3 +40,10 @@ type Querier interface {
 	// args are for any placeholder parameters in the query.
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
+
+// Execer can execute a non-returning query.
+type Execer interface {
+	// ExecContext executes a query that doesn't return rows. For example: an
+	// INSERT and UPDATE.
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
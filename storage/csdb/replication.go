@@ -16,9 +16,11 @@ package csdb
 
 import (
 	"context"
+	"database/sql"
 	"strconv"
 	"strings"
 
+	"github.com/corestoreio/csfw/storage/dbr"
 	"github.com/corestoreio/csfw/util/errors"
 )
 
@@ -84,3 +86,53 @@ func (ms *MasterStatus) FromString(str string) error {
 	ms.Position = uint(pos)
 	return nil
 }
+
+// ReplicaMasterStatus loads the binlog file and position of the master up to
+// which the replica has executed the events. The values are the columns
+// Relay_Master_Log_File and Exec_Master_Log_Pos of SHOW SLAVE STATUS. The
+// returned MasterStatus can be compared with the MasterStatus of the master.
+func ReplicaMasterStatus(ctx context.Context, replica *sql.DB) (MasterStatus, error) {
+	st, err := dbr.ShowSlaveStatus(ctx, replica)
+	if err != nil {
+		return MasterStatus{}, errors.Wrap(err, "[csdb] ReplicaMasterStatus")
+	}
+	pos, err := strconv.ParseUint(st["Exec_Master_Log_Pos"], 10, 32)
+	if err != nil {
+		return MasterStatus{}, errors.NewNotValidf("[csdb] ReplicaMasterStatus.ParseUint Exec_Master_Log_Pos %q: %s", st["Exec_Master_Log_Pos"], err)
+	}
+	return MasterStatus{
+		File:              st["Relay_Master_Log_File"],
+		Position:          uint(pos),
+		Executed_Gtid_Set: st["Executed_Gtid_Set"],
+	}, nil
+}
+
+// BinlogLagCheck creates a dbr.LagCheckFunc which compares the MasterStatus of
+// the master with the ReplicaMasterStatus of the replica. A replica lags if it
+// still executes an older binlog file or if its position is more than
+// maxBytes behind the position of the master.
+//		dbc, err := dbr.NewConnection(
+//			dbr.WithDSN(masterDSN),
+//			dbr.WithReplicaDSN("replica1", replicaDSN),
+//			dbr.WithReplicaLagCheck(csdb.BinlogLagCheck(1 << 20)),
+//		)
+//		go dbc.RunReplicaCheck(ctx, 5*time.Second)
+func BinlogLagCheck(maxBytes uint) dbr.LagCheckFunc {
+	return func(ctx context.Context, master, replica *sql.DB) (bool, error) {
+		var ms MasterStatus
+		if err := ms.Load(ctx, master); err != nil {
+			return true, errors.Wrap(err, "[csdb] BinlogLagCheck.Master")
+		}
+		rs, err := ReplicaMasterStatus(ctx, replica)
+		if err != nil {
+			return true, errors.Wrap(err, "[csdb] BinlogLagCheck.Replica")
+		}
+		if ms.Compare(rs) <= 0 {
+			return false, nil
+		}
+		if ms.File != rs.File {
+			return true, nil
+		}
+		return ms.Position-rs.Position > maxBytes, nil
+	}
+}
@@ -65,3 +65,39 @@ func TestShowMasterStatus(t *testing.T) {
 	assert.Exactly(t, uint(3581378), v.Position)
 	assert.Exactly(t, "123-456-789", v.Executed_Gtid_Set)
 }
+
+func TestBinlogLagCheck(t *testing.T) {
+	master, masterMock := cstesting.MockDB(t)
+	replica, replicaMock := cstesting.MockDB(t)
+
+	tests := []struct {
+		replicaFile string
+		replicaPos  string
+		maxBytes    uint
+		want        bool
+	}{
+		{"mysql-bin.000002", "3000", 1024, false},
+		{"mysql-bin.000002", "1000", 1024, true},
+		{"mysql-bin.000001", "3000", 1024, true},
+	}
+	for i, test := range tests {
+		masterMock.ExpectQuery("SHOW MASTER STATUS").WillReturnRows(
+			sqlmock.NewRows([]string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB", "Executed_Gtid_Set"}).
+				FromCSVString("mysql-bin.000002,3000,,,"))
+		replicaMock.ExpectQuery("SHOW SLAVE STATUS").WillReturnRows(
+			sqlmock.NewRows([]string{"Relay_Master_Log_File", "Exec_Master_Log_Pos", "Seconds_Behind_Master"}).
+				AddRow(test.replicaFile, test.replicaPos, "0"))
+
+		isLagging, err := csdb.BinlogLagCheck(test.maxBytes)(context.TODO(), master.DB, replica.DB)
+		if err != nil {
+			t.Fatalf("Index %d: %+v", i, err)
+		}
+		assert.Exactly(t, test.want, isLagging, "Index %d", i)
+	}
+	if err := masterMock.ExpectationsWereMet(); err != nil {
+		t.Error("there were unfulfilled expections", err)
+	}
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Error("there were unfulfilled expections", err)
+	}
+}
@@ -1,8 +1,13 @@
 package dbr
 
+import "github.com/corestoreio/csfw/util/errors"
+
 type alias struct {
 	Expression string
 	Alias      string
+	// Derived contains a subquery which gets used instead of the Expression.
+	// The Alias is required for a derived table.
+	Derived QueryBuilder
 }
 
 // MakeAlias creates a new alias expression
@@ -16,6 +21,16 @@ func MakeAlias(as ...string) alias {
 	return a
 }
 
+// MakeAliasDerived creates a new alias for a derived table, which is a
+// subquery in the FROM or JOIN clause:
+//		(SELECT ...) AS `alias`
+func MakeAliasDerived(sub QueryBuilder, as string) alias {
+	return alias{
+		Derived: sub,
+		Alias:   as,
+	}
+}
+
 func (t alias) String() string {
 	return Quoter.Alias(t.Expression, t.Alias)
 }
@@ -23,3 +38,31 @@ func (t alias) String() string {
 func (t alias) QuoteAs() string {
 	return Quoter.QuoteAs(t.Expression, t.Alias)
 }
+
+// isEmpty returns true if neither a table name nor a derived table has been
+// set.
+func (t alias) isEmpty() bool {
+	return len(t.Expression) == 0 && t.Derived == nil
+}
+
+// writeQuoted writes the quoted table name with its alias or the derived
+// table and appends its arguments.
+func (t alias) writeQuoted(w QueryWriter, args *[]interface{}) error {
+	if t.Derived == nil {
+		w.WriteString(t.QuoteAs())
+		return nil
+	}
+	if t.Alias == "" {
+		return errors.NewEmptyf("[dbr] Alias for derived table is missing")
+	}
+	sqlStr, subArgs, err := t.Derived.ToSQL()
+	if err != nil {
+		return errors.Wrap(err, "[dbr] alias.Derived.ToSQL")
+	}
+	w.WriteRune('(')
+	w.WriteString(sqlStr)
+	w.WriteString(") AS ")
+	w.WriteString(quote + Quoter.unQuote(t.Alias) + quote)
+	*args = append(*args, subArgs...)
+	return nil
+}
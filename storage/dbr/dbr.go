@@ -24,12 +24,27 @@ type Connection struct {
 	// DatabaseName contains the database name to which this connection has been
 	// bound to. It will only be set when a DSN has been parsed.
 	DatabaseName string
+	// Events gets notified after each database operation. Optional.
+	Events EventReceiver
+	// Replicas contains the read only connections for the Selects of a
+	// Session. Optional.
+	Replicas []*Replica
+	// LagCheck detects replicas which lag behind the master. Optional.
+	LagCheck LagCheckFunc
+	// replicaIdx round robin counter for the Replicas.
+	replicaIdx uint32
 }
 
 // Session represents a business unit of execution for some connection
 type Session struct {
 	cxn *Connection
 	log.Logger
+	// Events gets notified after each database operation. Optional.
+	Events EventReceiver
+	// readYourWrites enables the pinning to the master after a write.
+	readYourWrites bool
+	// pinned set to 1 once the session reads from the master.
+	pinned int32
 }
 
 type wrapContext struct {
@@ -137,6 +152,51 @@ func WrapQueryRowContext(ctx context.Context, db interface {
 	}
 }
 
+// querierContext wraps the context around the Querier if it supports
+// QueryContext, for example *sql.DB or *sql.Tx. Otherwise the Querier gets
+// returned unchanged.
+func querierContext(ctx context.Context, q Querier) Querier {
+	if qc, ok := q.(interface {
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	}); ok {
+		return WrapQueryContext(ctx, qc)
+	}
+	return q
+}
+
+// queryRowerContext wraps the context around the QueryRower if it supports
+// QueryRowContext. Otherwise the QueryRower gets returned unchanged.
+func queryRowerContext(ctx context.Context, q QueryRower) QueryRower {
+	if qrc, ok := q.(interface {
+		QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	}); ok {
+		return WrapQueryRowContext(ctx, qrc)
+	}
+	return q
+}
+
+// preparerContext wraps the context around the Preparer if it supports
+// PrepareContext. Otherwise the Preparer gets returned unchanged.
+func preparerContext(ctx context.Context, p Preparer) Preparer {
+	if pc, ok := p.(interface {
+		PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	}); ok {
+		return WrapPrepareContext(ctx, pc)
+	}
+	return p
+}
+
+// execerContext wraps the context around the Execer if it supports
+// ExecContext. Otherwise the Execer gets returned unchanged.
+func execerContext(ctx context.Context, e Execer) Execer {
+	if ec, ok := e.(interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	}); ok {
+		return WrapExecContext(ctx, ec)
+	}
+	return e
+}
+
 // ConnectionOption can be used as an argument in NewConnection to configure a
 // connection.
 type ConnectionOption func(*Connection) error
@@ -223,14 +283,22 @@ func (c *Connection) NewSession(opts ...SessionOption) *Session {
 	s := &Session{
 		cxn:    c,
 		Logger: c.Logger,
+		Events: c.Events,
 	}
 	s.Options(opts...)
 	return s
 }
 
-// Close closes the database, releasing any open resources.
+// Close closes the database and all replicas, releasing any open resources.
+// Returns the first error.
 func (c *Connection) Close() error {
-	return errors.Wrap(c.DB.Close(), "[dbr] connection.close")
+	err := errors.Wrap(c.DB.Close(), "[dbr] connection.close")
+	for _, r := range c.Replicas {
+		if rErr := r.DB.Close(); rErr != nil && err == nil {
+			err = errors.Wrapf(rErr, "[dbr] connection.close Replica %q", r.Name)
+		}
+	}
+	return err
 }
 
 // Ping verifies a connection to the database is still alive, establishing a connection if necessary.
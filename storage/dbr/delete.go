@@ -1,9 +1,11 @@
 package dbr
 
 import (
+	"context"
 	"database/sql"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/corestoreio/csfw/log"
 	"github.com/corestoreio/csfw/util/bufferpool"
@@ -16,6 +18,8 @@ type Delete struct {
 
 	Execer
 	Preparer
+	// Events gets notified after each database operation. Optional.
+	Events EventReceiver
 
 	From alias
 	WhereFragments
@@ -39,10 +43,12 @@ func NewDelete(from ...string) *Delete {
 
 // DeleteFrom creates a new Delete for the given table
 func (sess *Session) DeleteFrom(from ...string) *Delete {
+	db := sess.writer()
 	return &Delete{
 		Logger:         sess.Logger,
-		Execer:         sess.cxn.DB,
-		Preparer:       sess.cxn.DB,
+		Events:         sess.Events,
+		Execer:         db,
+		Preparer:       db,
 		From:           MakeAlias(from...),
 		WhereFragments: make(WhereFragments, 0, 2),
 	}
@@ -53,6 +59,7 @@ func (sess *Session) DeleteFrom(from ...string) *Delete {
 func (tx *Tx) DeleteFrom(from ...string) *Delete {
 	return &Delete{
 		Logger:         tx.Logger,
+		Events:         tx.Events,
 		Execer:         tx.Tx,
 		Preparer:       tx.Tx,
 		From:           MakeAlias(from...),
@@ -125,7 +132,9 @@ func (b *Delete) ToSQL() (string, []interface{}, error) {
 	// Write WHERE clause if we have any fragments
 	if len(b.WhereFragments) > 0 {
 		buf.WriteString(" WHERE ")
-		writeWhereFragmentsToSql(b.WhereFragments, buf, &args)
+		if err := writeWhereFragmentsToSql(b.WhereFragments, buf, &args); err != nil {
+			return "", nil, errors.Wrap(err, "[dbr] Delete.ToSQL.writeWhereFragmentsToSql")
+		}
 	}
 
 	// Ordering and limiting
@@ -154,6 +163,11 @@ func (b *Delete) ToSQL() (string, []interface{}, error) {
 // Exec executes the statement represented by the Delete
 // It returns the raw database/sql Result and an error if there was one
 func (b *Delete) Exec() (sql.Result, error) {
+	return b.ExecContext(context.Background())
+}
+
+// ExecContext same as Exec but applies the context to the database call.
+func (b *Delete) ExecContext(ctx context.Context) (sql.Result, error) {
 	sqlStr, args, err := b.ToSQL()
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Delete.Exec.ToSQL")
@@ -168,7 +182,9 @@ func (b *Delete) Exec() (sql.Result, error) {
 		defer log.WhenDone(b.Logger).Info("dbr.Delete.Exec.Timing", log.String("sql", fullSQL))
 	}
 
-	result, err := b.Execer.Exec(fullSQL)
+	start := time.Now()
+	result, err := execerContext(ctx, b.Execer).Exec(fullSQL)
+	emitEvent(ctx, b.Events, EventOpExec, sqlStr, args, start, resultRows(result), err)
 	if err != nil {
 		return result, errors.Wrap(err, "[dbr] delete.exec.Exec")
 	}
@@ -180,6 +196,11 @@ func (b *Delete) Exec() (sql.Result, error) {
 // database/sql Statement and an error if there was one. Provided arguments in
 // the Delete are getting ignored. It panics when field Preparer is nil.
 func (b *Delete) Prepare() (*sql.Stmt, error) {
+	return b.PrepareContext(context.Background())
+}
+
+// PrepareContext same as Prepare but applies the context to the database call.
+func (b *Delete) PrepareContext(ctx context.Context) (*sql.Stmt, error) {
 	sqlStr, _, err := b.ToSQL() // TODO create a ToSQL version without any arguments
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Delete.Prepare.ToSQL")
@@ -189,6 +210,8 @@ func (b *Delete) Prepare() (*sql.Stmt, error) {
 		defer log.WhenDone(b.Logger).Info("dbr.Delete.Prepare.Timing", log.String("sql", sqlStr))
 	}
 
-	stmt, err := b.Preparer.Prepare(sqlStr)
+	start := time.Now()
+	stmt, err := preparerContext(ctx, b.Preparer).Prepare(sqlStr)
+	emitEvent(ctx, b.Events, EventOpPrepare, sqlStr, nil, start, -1, err)
 	return stmt, errors.Wrap(err, "[dbr] Delete.Prepare.Prepare")
 }
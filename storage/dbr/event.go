@@ -0,0 +1,224 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/corestoreio/csfw/log"
+)
+
+// Operations of an Event.
+const (
+	EventOpExec     = "exec"
+	EventOpQuery    = "query"
+	EventOpQueryRow = "query_row"
+	EventOpPrepare  = "prepare"
+	EventOpBegin    = "begin"
+	EventOpCommit   = "commit"
+	EventOpRollback = "rollback"
+)
+
+// Event describes an executed database operation. An Event gets created after
+// the operation has finished.
+type Event struct {
+	// Op defines the kind of operation, see the EventOp* constants.
+	Op string
+	// SQL contains the statement with its placeholders. Empty for the
+	// transaction operations.
+	SQL string
+	// Args contains the arguments for the placeholders of SQL. The arguments
+	// can be redacted, see function WithRedaction.
+	Args []interface{}
+	// Duration how long the operation took. For the Load* functions of a
+	// Select it includes the scanning of the rows.
+	Duration time.Duration
+	// Rows contains for an Exec the number of affected rows and for the Load*
+	// functions the number of loaded rows. -1 if unknown.
+	Rows int64
+	// Err contains the error of the operation, if any.
+	Err error
+}
+
+// EventReceiver gets notified after each Exec, Query, Prepare and transaction
+// operation. An EventReceiver must be safe for concurrent use.
+type EventReceiver interface {
+	Event(ctx context.Context, ev *Event)
+}
+
+// EventReceiverFunc is an adapter to allow the use of ordinary functions as
+// EventReceiver.
+type EventReceiverFunc func(ctx context.Context, ev *Event)
+
+// Event calls erf(ctx, ev).
+func (erf EventReceiverFunc) Event(ctx context.Context, ev *Event) {
+	erf(ctx, ev)
+}
+
+// EventReceivers forwards an Event to all receivers in the order of the
+// slice.
+type EventReceivers []EventReceiver
+
+// Event implements interface EventReceiver.
+func (ers EventReceivers) Event(ctx context.Context, ev *Event) {
+	for _, er := range ers {
+		er.Event(ctx, ev)
+	}
+}
+
+// ArgRedactor removes sensitive data from the arguments of a statement. It
+// must return a new slice and must not modify the provided one.
+type ArgRedactor func(sqlStr string, args []interface{}) []interface{}
+
+// RedactAll replaces all arguments with the string "[redacted]".
+func RedactAll(_ string, args []interface{}) []interface{} {
+	if len(args) == 0 {
+		return args
+	}
+	ret := make([]interface{}, len(args))
+	for i := range args {
+		ret[i] = "[redacted]"
+	}
+	return ret
+}
+
+// WithRedaction applies the ArgRedactor to the arguments of each Event before
+// forwarding it to the EventReceiver.
+func WithRedaction(er EventReceiver, ar ArgRedactor) EventReceiver {
+	return EventReceiverFunc(func(ctx context.Context, ev *Event) {
+		evr := *ev
+		evr.Args = ar(ev.SQL, ev.Args)
+		er.Event(ctx, &evr)
+	})
+}
+
+// SlowQueryLog logs all operations which take longer than or equal to the
+// Threshold. Failed operations get always logged. Log level must be info.
+type SlowQueryLog struct {
+	Log       log.Logger
+	Threshold time.Duration
+}
+
+// NewSlowQueryLog creates a new slow query logger.
+func NewSlowQueryLog(l log.Logger, threshold time.Duration) *SlowQueryLog {
+	return &SlowQueryLog{
+		Log:       l,
+		Threshold: threshold,
+	}
+}
+
+// Event implements interface EventReceiver.
+func (sl *SlowQueryLog) Event(_ context.Context, ev *Event) {
+	if (ev.Duration < sl.Threshold && ev.Err == nil) || !sl.Log.IsInfo() {
+		return
+	}
+	msg := "dbr.SlowQueryLog"
+	if ev.Err != nil {
+		msg = "dbr.SlowQueryLog.Error"
+	}
+	sl.Log.Info(msg,
+		log.String("op", ev.Op),
+		log.String("sql", ev.SQL),
+		log.Object("args", ev.Args),
+		log.Duration("duration", ev.Duration),
+		log.Int64("rows", ev.Rows),
+		log.Err(ev.Err),
+	)
+}
+
+// Stater defines the functions to collect metrics. The interface is a subset
+// of github.com/rs/xstats.XStater, so any XStater can be used, like in package
+// net/accesslog.
+type Stater interface {
+	// Count adds count to the metric stat.
+	Count(stat string, count float64, tags ...string)
+	// Histogram adds the value to the histogram stat.
+	Histogram(stat string, value float64, tags ...string)
+	// Timing adds the duration to the timing stat.
+	Timing(stat string, duration time.Duration, tags ...string)
+}
+
+// StatsReceiver writes for each Event the following metrics with the tags
+// "op:<operation>" and "status:ok|error": the counter "dbr.operations", the
+// timing "dbr.duration" and the histogram "dbr.rows", if the number of rows
+// is known.
+type StatsReceiver struct {
+	Stats Stater
+}
+
+// NewStatsReceiver creates a new StatsReceiver.
+func NewStatsReceiver(s Stater) *StatsReceiver {
+	return &StatsReceiver{
+		Stats: s,
+	}
+}
+
+// Event implements interface EventReceiver.
+func (sr *StatsReceiver) Event(_ context.Context, ev *Event) {
+	status := "status:ok"
+	if ev.Err != nil {
+		status = "status:error"
+	}
+	op := "op:" + ev.Op
+	sr.Stats.Count("dbr.operations", 1, op, status)
+	sr.Stats.Timing("dbr.duration", ev.Duration, op, status)
+	if ev.Rows >= 0 {
+		sr.Stats.Histogram("dbr.rows", float64(ev.Rows), op, status)
+	}
+}
+
+// WithEventReceiver sets one or more EventReceivers to a connection. All
+// sessions, statements and transactions created from the connection notify
+// the receivers.
+func WithEventReceiver(ers ...EventReceiver) ConnectionOption {
+	return func(c *Connection) error {
+		if len(ers) == 1 {
+			c.Events = ers[0]
+		} else {
+			c.Events = EventReceivers(ers)
+		}
+		return nil
+	}
+}
+
+// emitEvent sends the Event to the EventReceiver, if set. start defines the
+// beginning of the operation.
+func emitEvent(ctx context.Context, er EventReceiver, op, sqlStr string, args []interface{}, start time.Time, rows int64, err error) {
+	if er == nil {
+		return
+	}
+	er.Event(ctx, &Event{
+		Op:       op,
+		SQL:      sqlStr,
+		Args:     args,
+		Duration: time.Since(start),
+		Rows:     rows,
+		Err:      err,
+	})
+}
+
+// resultRows returns the number of affected rows or -1 if unknown.
+func resultRows(res sql.Result) int64 {
+	if res == nil {
+		return -1
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
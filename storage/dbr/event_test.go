@@ -0,0 +1,176 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/log/logw"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ EventReceiver = (*SlowQueryLog)(nil)
+var _ EventReceiver = (*StatsReceiver)(nil)
+var _ EventReceiver = (EventReceivers)(nil)
+
+type recordEvents struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (re *recordEvents) Event(_ context.Context, ev *Event) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.events = append(re.events, *ev)
+}
+
+func TestEventReceiver_Session(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	re := &recordEvents{}
+	cxn, err := NewConnection(WithDB(db), WithEventReceiver(re))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := cxn.NewSession()
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("UPDATE `a` SET `b` = 'secret' WHERE \\(c = 3\\)").WillReturnResult(sqlmock.NewResult(0, 2))
+	dbMock.ExpectQuery("SELECT a FROM `b`").WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1).AddRow(2).AddRow(3))
+	dbMock.ExpectCommit()
+	dbMock.ExpectExec("DELETE FROM `a`").WillReturnError(errors.NewFatalf("Database gone"))
+
+	tx, err := sess.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tx.Update("a").Set("b", "secret").Where(ConditionRaw("c = ?", 3)).Exec()
+	assert.NoError(t, err)
+	var vals []int64
+	_, err = tx.Select("a").From("b").LoadValues(&vals)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+
+	_, err = sess.DeleteFrom("a").Exec()
+	assert.True(t, errors.IsFatal(err), "%+v", err)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+
+	if !assert.Len(t, re.events, 5) {
+		t.FailNow()
+	}
+	tests := []struct {
+		op   string
+		sql  string
+		args []interface{}
+		rows int64
+		err  bool
+	}{
+		{EventOpBegin, "", nil, -1, false},
+		{EventOpExec, "UPDATE `a` SET `b` = ? WHERE (c = ?)", []interface{}{"secret", 3}, 2, false},
+		{EventOpQuery, "SELECT a FROM `b`", nil, 3, false},
+		{EventOpCommit, "", nil, -1, false},
+		{EventOpExec, "DELETE FROM `a`", nil, -1, true},
+	}
+	for i, test := range tests {
+		ev := re.events[i]
+		assert.Exactly(t, test.op, ev.Op, "Index %d", i)
+		assert.Exactly(t, test.sql, ev.SQL, "Index %d", i)
+		assert.Exactly(t, test.args, ev.Args, "Index %d", i)
+		assert.Exactly(t, test.rows, ev.Rows, "Index %d", i)
+		assert.Exactly(t, test.err, ev.Err != nil, "Index %d", i)
+		assert.True(t, ev.Duration > 0, "Index %d", i)
+	}
+}
+
+func TestWithRedaction(t *testing.T) {
+	re := &recordEvents{}
+	er := WithRedaction(re, RedactAll)
+	args := []interface{}{"secret", 3}
+	er.Event(context.Background(), &Event{Op: EventOpExec, SQL: "UPDATE a SET b=? WHERE c=?", Args: args, Rows: 1})
+
+	assert.Exactly(t, []interface{}{"[redacted]", "[redacted]"}, re.events[0].Args)
+	assert.Exactly(t, []interface{}{"secret", 3}, args, "original args must not be modified")
+	assert.Exactly(t, "UPDATE a SET b=? WHERE c=?", re.events[0].SQL)
+}
+
+func TestWithRedaction_InsertChunks(t *testing.T) {
+	re := &recordEvents{}
+	ins := &Insert{Into: "a", Execer: &recordExecer{}, Events: WithRedaction(re, RedactAll)}
+	ins.Columns("b", "c").Values("secret1", 1).Values("secret2", 2).Values("secret3", 3).
+		OnDuplicateKey("c", 4).
+		Chunk(len("INSERT INTO a (`b`,`c`) VALUES ('secret1',1),('secret2',2) ON DUPLICATE KEY UPDATE `c`=4"), 1)
+	_, err := ins.Exec()
+	assert.NoError(t, err)
+
+	if !assert.Len(t, re.events, 2) {
+		t.FailNow()
+	}
+	assert.Exactly(t, "INSERT INTO a (`b`,`c`) VALUES (?,?),(?,?) ON DUPLICATE KEY UPDATE `c`=?", re.events[0].SQL)
+	assert.Exactly(t, []interface{}{"[redacted]", "[redacted]", "[redacted]", "[redacted]", "[redacted]"}, re.events[0].Args)
+	assert.Exactly(t, "INSERT INTO a (`b`,`c`) VALUES (?,?) ON DUPLICATE KEY UPDATE `c`=?", re.events[1].SQL)
+	assert.Exactly(t, []interface{}{"[redacted]", "[redacted]", "[redacted]"}, re.events[1].Args)
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sl := NewSlowQueryLog(logw.NewLog(logw.WithLevel(logw.LevelInfo), logw.WithWriter(buf)), 50*time.Millisecond)
+
+	sl.Event(context.Background(), &Event{Op: EventOpQuery, SQL: "SELECT fast", Duration: time.Millisecond, Rows: -1})
+	assert.Empty(t, buf.String())
+
+	sl.Event(context.Background(), &Event{Op: EventOpQuery, SQL: "SELECT slow", Duration: time.Second, Rows: 4})
+	assert.Contains(t, buf.String(), "dbr.SlowQueryLog")
+	assert.Contains(t, buf.String(), "SELECT slow")
+
+	buf.Reset()
+	sl.Event(context.Background(), &Event{Op: EventOpExec, SQL: "DELETE failed", Duration: time.Millisecond, Rows: -1, Err: errors.New("Ups")})
+	assert.Contains(t, buf.String(), "dbr.SlowQueryLog.Error")
+	assert.Contains(t, buf.String(), "DELETE failed")
+}
+
+type recordStats struct {
+	calls []string
+}
+
+func (rs *recordStats) Count(stat string, count float64, tags ...string) {
+	rs.calls = append(rs.calls, "count:"+stat)
+}
+func (rs *recordStats) Histogram(stat string, value float64, tags ...string) {
+	rs.calls = append(rs.calls, "histogram:"+stat)
+}
+func (rs *recordStats) Timing(stat string, duration time.Duration, tags ...string) {
+	rs.calls = append(rs.calls, "timing:"+stat)
+}
+
+func TestStatsReceiver(t *testing.T) {
+	rs := &recordStats{}
+	sr := NewStatsReceiver(rs)
+	sr.Event(context.Background(), &Event{Op: EventOpExec, Rows: 3})
+	sr.Event(context.Background(), &Event{Op: EventOpPrepare, Rows: -1})
+	assert.Exactly(t, []string{
+		"count:dbr.operations", "timing:dbr.duration", "histogram:dbr.rows",
+		"count:dbr.operations", "timing:dbr.duration",
+	}, rs.calls)
+}
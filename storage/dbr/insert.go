@@ -1,9 +1,12 @@
 package dbr
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/corestoreio/csfw/log"
 	"github.com/corestoreio/csfw/util/bufferpool"
@@ -15,20 +18,38 @@ type Insert struct {
 	log.Logger // optional
 	Execer
 	Preparer
+	// Events gets notified after each database operation. Optional.
+	Events EventReceiver
 
 	Into string
 	Cols []string
 	Vals [][]interface{}
 	Recs []interface{}
 	Maps map[string]interface{}
+
+	// OnDuplicateKeys contains the column/value pairs of the ON DUPLICATE KEY
+	// UPDATE clause.
+	OnDuplicateKeys []*setClause
+	// MaxAllowedPacket defines the maximum size in bytes of one statement.
+	// If greater zero, Exec splits the rows into several statements. See
+	// function MaxAllowedPacket.
+	MaxAllowedPacket int
+	// Parallel defines the number of statements Exec runs at the same time
+	// when the rows have been split. Zero or one runs them sequentially.
+	Parallel int
+
+	// previousErr gets set by a builder function and returned by ToSQL.
+	previousErr error
 }
 
 // InsertInto instantiates a Insert for the given table
 func (sess *Session) InsertInto(into string) *Insert {
+	db := sess.writer()
 	return &Insert{
 		Logger:   sess.Logger,
-		Execer:   sess.cxn.DB,
-		Preparer: sess.cxn.DB,
+		Events:   sess.Events,
+		Execer:   db,
+		Preparer: db,
 		Into:     into,
 	}
 }
@@ -37,6 +58,7 @@ func (sess *Session) InsertInto(into string) *Insert {
 func (tx *Tx) InsertInto(into string) *Insert {
 	return &Insert{
 		Logger:   tx.Logger,
+		Events:   tx.Events,
 		Execer:   tx.Tx,
 		Preparer: tx.Tx,
 		Into:     into,
@@ -62,7 +84,8 @@ func (b *Insert) Values(vals ...interface{}) *Insert {
 	return b
 }
 
-// Record pulls in values to match Columns from the record. Uses reflection.
+// Record pulls in values to match Columns from the record. Uses reflection
+// unless the record implements interface ArgumentAssembler.
 func (b *Insert) Record(record interface{}) *Insert {
 	b.Recs = append(b.Recs, record)
 	return b
@@ -97,22 +120,131 @@ func (b *Insert) Pair(column string, value interface{}) *Insert {
 	return b
 }
 
-// ToSQL serialized the Insert to a SQL string
-// It returns the string with placeholders and a slice of query arguments
-func (b *Insert) ToSQL() (string, []interface{}, error) {
+// OnDuplicateKey appends a column/value pair to the ON DUPLICATE KEY UPDATE
+// clause. The error of a driver.Valuer gets returned by ToSQL. The value can
+// be an expression created with function Expr, for example to refer to the
+// inserted value of a column:
+//		OnDuplicateKey("qty", dbr.Expr("`qty`+VALUES(`qty`)"))
+func (b *Insert) OnDuplicateKey(column string, value interface{}) *Insert {
+	if dbVal, ok := value.(driver.Valuer); ok {
+		val, err := dbVal.Value()
+		if err != nil {
+			if b.previousErr == nil {
+				b.previousErr = errors.Wrapf(err, "[dbr] Insert.OnDuplicateKey Column %q", column)
+			}
+			return b
+		}
+		value = val
+	}
+	b.OnDuplicateKeys = append(b.OnDuplicateKeys, &setClause{column: column, value: value})
+	return b
+}
+
+// OnDuplicateKeyValues updates the columns with their inserted values in the
+// ON DUPLICATE KEY UPDATE clause:
+//		`value`=VALUES(`value`)
+func (b *Insert) OnDuplicateKeyValues(columns ...string) *Insert {
+	for _, c := range columns {
+		b.OnDuplicateKeys = append(b.OnDuplicateKeys, &setClause{
+			column: c,
+			value:  Expr("VALUES(" + Quoter.QuoteAs(c) + ")"),
+		})
+	}
+	return b
+}
+
+// Chunk splits the rows in Exec into several statements which are not larger
+// than maxAllowedPacket bytes. Up to parallel statements run at the same
+// time. Within a Tx the statements always run sequentially. Without a Tx a
+// failed statement leaves the rows of the already executed statements
+// inserted.
+func (b *Insert) Chunk(maxAllowedPacket, parallel int) *Insert {
+	b.MaxAllowedPacket = maxAllowedPacket
+	b.Parallel = parallel
+	return b
+}
+
+// writeOnDuplicateKey writes the ON DUPLICATE KEY UPDATE clause, if any.
+func (b *Insert) writeOnDuplicateKey(w QueryWriter, args *[]interface{}) {
+	if len(b.OnDuplicateKeys) == 0 {
+		return
+	}
+	w.WriteString(" ON DUPLICATE KEY UPDATE ")
+	for i, c := range b.OnDuplicateKeys {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		Quoter.writeQuotedColumn(c.column, w)
+		if e, ok := c.value.(*expr); ok {
+			w.WriteRune('=')
+			w.WriteString(e.SQL)
+			*args = append(*args, e.Values...)
+		} else {
+			w.WriteString("=?")
+			*args = append(*args, c.value)
+		}
+	}
+}
+
+// isValid checks the required fields.
+func (b *Insert) isValid() error {
+	if b.previousErr != nil {
+		return b.previousErr
+	}
 	if len(b.Into) == 0 {
-		return "", nil, errors.NewEmptyf(errTableMissing)
+		return errors.NewEmptyf(errTableMissing)
 	}
 	if len(b.Cols) == 0 && len(b.Maps) == 0 {
-		return "", nil, errors.NewEmptyf(errColumnsMissing)
+		return errors.NewEmptyf(errColumnsMissing)
 	} else if len(b.Maps) == 0 {
 		if len(b.Vals) == 0 && len(b.Recs) == 0 {
-			return "", nil, errors.NewEmptyf(errRecordsMissing)
+			return errors.NewEmptyf(errRecordsMissing)
 		}
 		if len(b.Cols) == 0 && (len(b.Vals) > 0 || len(b.Recs) > 0) {
-			return "", nil, errors.NewEmptyf(errColumnsMissing)
+			return errors.NewEmptyf(errColumnsMissing)
 		}
 	}
+	return nil
+}
+
+// rows returns the arguments of each row of the Values and Records. The
+// arguments of all ArgumentAssembler share one backing array.
+func (b *Insert) rows() ([][]interface{}, error) {
+	rows := make([][]interface{}, 0, len(b.Vals)+len(b.Recs))
+	rows = append(rows, b.Vals...)
+	var recArgs []interface{}
+	for i, rec := range b.Recs {
+		if aa, ok := rec.(ArgumentAssembler); ok {
+			if recArgs == nil {
+				recArgs = make([]interface{}, 0, len(b.Recs)*len(b.Cols))
+			}
+			start := len(recArgs)
+			var err error
+			if recArgs, err = aa.AssembleArguments(b.Cols, recArgs); err != nil {
+				return nil, errors.Wrapf(err, "[dbr] ArgumentAssembler Record %d", i)
+			}
+			if l := len(recArgs) - start; l != len(b.Cols) {
+				return nil, errors.NewNotValidf("[dbr] ArgumentAssembler Record %d returned %d arguments for %d columns", i, l, len(b.Cols))
+			}
+			rows = append(rows, recArgs[start:len(recArgs):len(recArgs)])
+			continue
+		}
+		ind := reflect.Indirect(reflect.ValueOf(rec))
+		vals, err := valuesFor(ind.Type(), ind, b.Cols)
+		if err != nil {
+			return nil, errors.Wrap(err, "[dbr] valuesFor")
+		}
+		rows = append(rows, vals)
+	}
+	return rows, nil
+}
+
+// ToSQL serialized the Insert to a SQL string
+// It returns the string with placeholders and a slice of query arguments
+func (b *Insert) ToSQL() (string, []interface{}, error) {
+	if err := b.isValid(); err != nil {
+		return "", nil, errors.Wrap(err, "[dbr] Insert.ToSQL")
+	}
 
 	var buf = bufferpool.Get()
 	defer bufferpool.Put(buf)
@@ -143,32 +275,141 @@ func (b *Insert) ToSQL() (string, []interface{}, error) {
 	placeholder.WriteRune(')')
 	placeholderStr := placeholder.String()
 
-	// Go thru each value we want to insert. Write the placeholders, and collect args
-	for i, row := range b.Vals {
+	// Go thru each value and record we want to insert. Write the
+	// placeholders, and collect args. Records need reflection.
+	rows, err := b.rows()
+	if err != nil {
+		return "", nil, errors.Wrap(err, "[dbr] Insert.ToSQL")
+	}
+	for i, row := range rows {
 		if i > 0 {
 			buf.WriteRune(',')
 		}
 		buf.WriteString(placeholderStr)
 		args = append(args, row...)
 	}
-	anyVals := len(b.Vals) > 0
+	b.writeOnDuplicateKey(buf, &args)
 
-	// Go thru the records. Write the placeholders, and do reflection on the records to extract args
-	for i, rec := range b.Recs {
-		if i > 0 || anyVals {
+	return buf.String(), args, nil
+}
+
+// ToSQLChunks serializes the rows of the Insert into several interpolated SQL
+// statements. Each statement is not larger than maxAllowedPacket bytes. A
+// maxAllowedPacket of zero returns one statement. Error behaviour: NotValid
+// if a single row exceeds maxAllowedPacket.
+func (b *Insert) ToSQLChunks(maxAllowedPacket int) ([]string, error) {
+	chunks, err := b.toSQLChunks(maxAllowedPacket)
+	if err != nil {
+		return nil, err
+	}
+	stmts := make([]string, len(chunks))
+	for i, c := range chunks {
+		stmts[i] = c.fullSQL
+	}
+	return stmts, nil
+}
+
+// insertChunk contains one statement of ToSQLChunks.
+type insertChunk struct {
+	// fullSQL contains the interpolated statement which gets executed.
+	fullSQL string
+	// rawSQL contains the statement with its placeholders and args the
+	// arguments. Both get passed to the EventReceiver, so the arguments can
+	// be redacted.
+	rawSQL string
+	args   []interface{}
+}
+
+func (b *Insert) toSQLChunks(maxAllowedPacket int) ([]insertChunk, error) {
+	if err := b.isValid(); err != nil {
+		return nil, errors.Wrap(err, "[dbr] Insert.ToSQLChunks")
+	}
+	if len(b.Maps) != 0 || maxAllowedPacket <= 0 {
+		sqlStr, args, err := b.ToSQL()
+		if err != nil {
+			return nil, errors.Wrap(err, "[dbr] Insert.ToSQLChunks.ToSQL")
+		}
+		fullSQL, err := Preprocess(sqlStr, args)
+		if err != nil {
+			return nil, errors.Wrap(err, "[dbr] Insert.ToSQLChunks.Preprocess")
+		}
+		return []insertChunk{{fullSQL: fullSQL, rawSQL: sqlStr, args: args}}, nil
+	}
+
+	var buf = bufferpool.Get()
+	defer bufferpool.Put(buf)
+
+	// prefix contains INSERT INTO and the columns, suffix the ON DUPLICATE
+	// KEY UPDATE clause.
+	buf.WriteString("INSERT INTO ")
+	buf.WriteString(b.Into)
+	buf.WriteString(" (")
+	var placeholder = bufferpool.Get()
+	defer bufferpool.Put(placeholder)
+	placeholder.WriteRune('(')
+	for i, c := range b.Cols {
+		if i > 0 {
 			buf.WriteRune(',')
+			placeholder.WriteRune(',')
 		}
-		buf.WriteString(placeholderStr)
+		Quoter.writeQuotedColumn(c, buf)
+		placeholder.WriteRune('?')
+	}
+	buf.WriteString(") VALUES ")
+	placeholder.WriteRune(')')
+	prefix := buf.String()
 
-		ind := reflect.Indirect(reflect.ValueOf(rec))
-		vals, err := valuesFor(ind.Type(), ind, b.Cols)
+	buf.Reset()
+	var dupArgs []interface{}
+	b.writeOnDuplicateKey(buf, &dupArgs)
+	rawSuffix := buf.String()
+	suffix, err := Preprocess(rawSuffix, dupArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] Insert.ToSQLChunks.Preprocess")
+	}
+
+	rows, err := b.rows()
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] Insert.ToSQLChunks")
+	}
+
+	// buf contains the interpolated rows and rawBuf their placeholders.
+	var rawBuf = bufferpool.Get()
+	defer bufferpool.Put(rawBuf)
+	var chunks []insertChunk
+	var args []interface{}
+	flush := func() {
+		chunks = append(chunks, insertChunk{
+			fullSQL: prefix + buf.String() + suffix,
+			rawSQL:  prefix + rawBuf.String() + rawSuffix,
+			args:    append(args, dupArgs...),
+		})
+		buf.Reset()
+		rawBuf.Reset()
+		args = nil
+	}
+	buf.Reset()
+	for i, row := range rows {
+		rowSQL, err := Preprocess(placeholder.String(), row)
 		if err != nil {
-			return "", nil, errors.Wrap(err, "[dbr] valuesFor")
+			return nil, errors.Wrapf(err, "[dbr] Insert.ToSQLChunks.Preprocess Row %d", i)
+		}
+		if len(prefix)+len(rowSQL)+len(suffix) > maxAllowedPacket {
+			return nil, errors.NewNotValidf("[dbr] Insert.ToSQLChunks Row %d with %d bytes exceeds max_allowed_packet %d", i, len(rowSQL), maxAllowedPacket)
+		}
+		if buf.Len() > 0 && len(prefix)+buf.Len()+1+len(rowSQL)+len(suffix) > maxAllowedPacket {
+			flush()
+		}
+		if buf.Len() > 0 {
+			buf.WriteRune(',')
+			rawBuf.WriteRune(',')
 		}
-		args = append(args, vals...)
+		buf.WriteString(rowSQL)
+		rawBuf.WriteString(placeholder.String())
+		args = append(args, row...)
 	}
-
-	return buf.String(), args, nil
+	flush()
+	return chunks, nil
 }
 
 // MapToSql serialized the Insert to a SQL string
@@ -210,6 +451,7 @@ func (b *Insert) MapToSql(w QueryWriter) (string, []interface{}, error) {
 	w.WriteString(placeholder.String())
 
 	args = append(args, vals...)
+	b.writeOnDuplicateKey(w, &args)
 
 	return w.String(), args, nil
 }
@@ -220,7 +462,19 @@ func (b *Insert) MapToSql(w QueryWriter) (string, []interface{}, error) {
 // INSERT statement, LAST_INSERT_ID() returns the value generated for
 // the first inserted row only. The reason for this is to make it possible to
 // reproduce easily the same INSERT statement against some other server.
+// If MaxAllowedPacket has been set, the rows get inserted with several
+// statements. The returned Result sums up the affected rows and returns the
+// LastInsertId of the first statement. Without a Tx a failed statement
+// leaves the rows of the already executed statements inserted.
 func (b *Insert) Exec() (sql.Result, error) {
+	return b.ExecContext(context.Background())
+}
+
+// ExecContext same as Exec but applies the context to the database call.
+func (b *Insert) ExecContext(ctx context.Context) (sql.Result, error) {
+	if b.MaxAllowedPacket > 0 {
+		return b.execChunks(ctx)
+	}
 	sql, args, err := b.ToSQL()
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Insert.Exec.ToSQL")
@@ -235,7 +489,9 @@ func (b *Insert) Exec() (sql.Result, error) {
 		defer log.WhenDone(b.Logger).Info("dbr.Insert.Exec.Timing", log.String("sql", fullSql))
 	}
 
-	result, err := b.Execer.Exec(fullSql)
+	start := time.Now()
+	result, err := execerContext(ctx, b.Execer).Exec(fullSql)
+	emitEvent(ctx, b.Events, EventOpExec, sql, args, start, resultRows(result), err)
 	if err != nil {
 		return result, errors.Wrap(err, "[dbr] Insert.Exec.Exec")
 	}
@@ -243,8 +499,113 @@ func (b *Insert) Exec() (sql.Result, error) {
 	return result, nil
 }
 
+// execChunks executes the statements of ToSQLChunks, optionally in parallel.
+// Within a Tx the statements run sequentially. The first error cancels the
+// context of the running statements, no further statements get started and
+// the error gets returned. Without a Tx the rows of the already executed
+// statements stay inserted. The events contain the SQL with placeholders and
+// the arguments of each statement, so the arguments can be redacted.
+func (b *Insert) execChunks(ctx context.Context) (sql.Result, error) {
+	stmts, err := b.toSQLChunks(b.MaxAllowedPacket)
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] Insert.Exec.ToSQLChunks")
+	}
+	if b.Logger != nil && b.Logger.IsInfo() {
+		defer log.WhenDone(b.Logger).Info("dbr.Insert.Exec.Chunks.Timing", log.Int("statements", len(stmts)), log.String("table", b.Into))
+	}
+
+	res := make(batchResult, len(stmts))
+	parallel := b.Parallel
+	if _, ok := b.Execer.(*sql.Tx); ok || parallel < 1 {
+		parallel = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	sem := make(chan struct{}, parallel)
+	for i, stmt := range stmts {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int, stmt insertChunk) {
+			defer func() { <-sem; wg.Done() }()
+			start := time.Now()
+			r, err := execerContext(ctx, b.Execer).Exec(stmt.fullSQL)
+			emitEvent(ctx, b.Events, EventOpExec, stmt.rawSQL, stmt.args, start, resultRows(r), err)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = errors.Wrapf(err, "[dbr] Insert.Exec.Chunk %d", i)
+					cancel()
+				})
+				return
+			}
+			res[i] = r
+		}(i, stmt)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "[dbr] Insert.Exec.Chunks")
+	}
+	return res, nil
+}
+
+// batchResult combines the results of several statements.
+type batchResult []sql.Result
+
+// LastInsertId returns the ID of the first statement.
+func (br batchResult) LastInsertId() (int64, error) {
+	if len(br) == 0 {
+		return 0, nil
+	}
+	return br[0].LastInsertId()
+}
+
+// RowsAffected returns the sum of the affected rows of all statements.
+func (br batchResult) RowsAffected() (int64, error) {
+	var sum int64
+	for _, r := range br {
+		n, err := r.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		sum += n
+	}
+	return sum, nil
+}
+
+// MaxAllowedPacket queries the maximum size in bytes of a statement from the
+// server. Use it with Insert.Chunk.
+func MaxAllowedPacket(q Querier) (int, error) {
+	rows, err := q.Query("SELECT @@max_allowed_packet")
+	if err != nil {
+		return 0, errors.Wrap(err, "[dbr] MaxAllowedPacket.Query")
+	}
+	defer rows.Close()
+	var size int
+	if rows.Next() {
+		if err := rows.Scan(&size); err != nil {
+			return 0, errors.Wrap(err, "[dbr] MaxAllowedPacket.Scan")
+		}
+	}
+	return size, errors.Wrap(rows.Err(), "[dbr] MaxAllowedPacket.Rows")
+}
+
 // Prepare creates a prepared statement
 func (b *Insert) Prepare() (*sql.Stmt, error) {
+	return b.PrepareContext(context.Background())
+}
+
+// PrepareContext same as Prepare but applies the context to the database call.
+func (b *Insert) PrepareContext(ctx context.Context) (*sql.Stmt, error) {
 	rawSQL, _, err := b.ToSQL() // TODO create a ToSQL version without any arguments
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Insert.Exec.ToSQL")
@@ -254,6 +615,8 @@ func (b *Insert) Prepare() (*sql.Stmt, error) {
 		defer log.WhenDone(b.Logger).Info("dbr.Insert.Prepare.Timing", log.String("sql", rawSQL))
 	}
 
-	stmt, err := b.Preparer.Prepare(rawSQL)
+	start := time.Now()
+	stmt, err := preparerContext(ctx, b.Preparer).Prepare(rawSQL)
+	emitEvent(ctx, b.Events, EventOpPrepare, rawSQL, nil, start, -1, err)
 	return stmt, errors.Wrap(err, "[dbr] Insert.Prepare.Prepare")
 }
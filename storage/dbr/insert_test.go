@@ -2,9 +2,13 @@ package dbr
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"sort"
+	"sync"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/corestoreio/csfw/util/errors"
 	"github.com/stretchr/testify/assert"
 )
@@ -142,3 +146,166 @@ func TestInsert_Prepare(t *testing.T) {
 		assert.True(t, errors.IsAlreadyClosed(err), "%+v", err)
 	})
 }
+
+func TestInsert_OnDuplicateKey(t *testing.T) {
+	s := createFakeSession()
+
+	t.Run("Values and Expr", func(t *testing.T) {
+		sqlStr, args, err := s.InsertInto("a").Columns("b", "c").Values(1, 2).
+			OnDuplicateKeyValues("c").
+			OnDuplicateKey("d", Expr("`d`+VALUES(`c`)*?", 3)).
+			OnDuplicateKey("e", "x").
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "INSERT INTO a (`b`,`c`) VALUES (?,?) ON DUPLICATE KEY UPDATE `c`=VALUES(`c`), `d`=`d`+VALUES(`c`)*?, `e`=?", sqlStr)
+		assert.Exactly(t, []interface{}{1, 2, 3, "x"}, args)
+	})
+
+	t.Run("Map", func(t *testing.T) {
+		sqlStr, args, err := s.InsertInto("a").Map(map[string]interface{}{"b": 1}).
+			OnDuplicateKeyValues("b").ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "INSERT INTO a (`b`) VALUES (?) ON DUPLICATE KEY UPDATE `b`=VALUES(`b`)", sqlStr)
+		assert.Exactly(t, []interface{}{1}, args)
+	})
+
+	t.Run("Valuer error", func(t *testing.T) {
+		ins := s.InsertInto("a").Columns("b").Values(1).OnDuplicateKey("b", errValuer{})
+		sqlStr, args, err := ins.ToSQL()
+		assert.True(t, errors.IsFatal(err), "%+v", err)
+		assert.Empty(t, sqlStr)
+		assert.Nil(t, args)
+		_, err = ins.ToSQLChunks(1024)
+		assert.True(t, errors.IsFatal(err), "%+v", err)
+	})
+}
+
+type errValuer struct{}
+
+func (errValuer) Value() (driver.Value, error) {
+	return nil, errors.NewFatalf("Valuer broken")
+}
+
+func TestInsert_ToSQLChunks(t *testing.T) {
+	s := createFakeSession()
+
+	// Values get written before the Records
+	newIns := func() *Insert {
+		return s.InsertInto("a").Columns("something_id", "user_id").
+			Values(1, "x").
+			Record(someRecord{SomethingId: 2, UserId: 3}).
+			Values(4, "yy").
+			OnDuplicateKeyValues("user_id")
+	}
+	const prefix = "INSERT INTO a (`something_id`,`user_id`) VALUES "
+	const suffix = " ON DUPLICATE KEY UPDATE `user_id`=VALUES(`user_id`)"
+
+	tests := []struct {
+		maxPacket int
+		want      []string
+	}{
+		{0, []string{prefix + "(1,'x'),(4,'yy'),(2,3)" + suffix}},
+		{len(prefix) + len(suffix) + 100, []string{prefix + "(1,'x'),(4,'yy'),(2,3)" + suffix}},
+		{len(prefix) + len(suffix) + 16, []string{prefix + "(1,'x'),(4,'yy')" + suffix, prefix + "(2,3)" + suffix}},
+		{len(prefix) + len(suffix) + 8, []string{prefix + "(1,'x')" + suffix, prefix + "(4,'yy')" + suffix, prefix + "(2,3)" + suffix}},
+	}
+	for i, test := range tests {
+		have, err := newIns().ToSQLChunks(test.maxPacket)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+
+	_, err := newIns().ToSQLChunks(len(prefix) + len(suffix) + 2)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+// recordExecer records all executed statements and returns for each one
+// affected row.
+type recordExecer struct {
+	mu    sync.Mutex
+	stmts []string
+	calls int
+	err   error
+}
+
+func (re *recordExecer) Exec(query string, args ...interface{}) (sql.Result, error) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.calls++
+	if re.err != nil {
+		return nil, re.err
+	}
+	re.stmts = append(re.stmts, query)
+	return driver.RowsAffected(1), nil
+}
+
+func TestInsert_Exec_Chunks(t *testing.T) {
+	for _, parallel := range []int{0, 1, 3} {
+		re := &recordExecer{}
+		ins := &Insert{Into: "a", Execer: re}
+		ins.Columns("b").Chunk(len("INSERT INTO a (`b`) VALUES (1),(2)"), parallel)
+		for i := 1; i <= 9; i++ {
+			ins.Values(i)
+		}
+		res, err := ins.Exec()
+		assert.NoError(t, err, "Parallel %d", parallel)
+		ra, err := res.RowsAffected()
+		assert.NoError(t, err)
+		assert.Exactly(t, int64(5), ra, "Parallel %d", parallel)
+		sort.Strings(re.stmts)
+		assert.Exactly(t, []string{
+			"INSERT INTO a (`b`) VALUES (1),(2)",
+			"INSERT INTO a (`b`) VALUES (3),(4)",
+			"INSERT INTO a (`b`) VALUES (5),(6)",
+			"INSERT INTO a (`b`) VALUES (7),(8)",
+			"INSERT INTO a (`b`) VALUES (9)",
+		}, re.stmts, "Parallel %d", parallel)
+	}
+
+	ins := &Insert{Into: "a", Execer: &recordExecer{err: errors.NewFatalf("Database gone")}}
+	ins.Columns("b").Values(1).Values(2).Chunk(1024, 2)
+	res, err := ins.Exec()
+	assert.Nil(t, res)
+	assert.True(t, errors.IsFatal(err), "%+v", err)
+
+	// the first error stops all further statements
+	re := &recordExecer{err: errors.NewFatalf("Database gone")}
+	ins = &Insert{Into: "a", Execer: re}
+	ins.Columns("b").Values(1).Values(2).Values(3).Chunk(len("INSERT INTO a (`b`) VALUES (1)"), 1)
+	_, err = ins.Exec()
+	assert.True(t, errors.IsFatal(err), "%+v", err)
+	assert.Exactly(t, 1, re.calls)
+}
+
+func TestInsert_Exec_Chunks_Tx(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	dbMock.ExpectBegin()
+	for i := 1; i <= 4; i++ {
+		dbMock.ExpectExec(fmt.Sprintf("INSERT INTO a \\(`b`\\) VALUES \\(%d\\)", i)).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	dbMock.ExpectCommit()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the statements must run in order on the connection of the Tx
+	ins := &Insert{Into: "a", Execer: tx}
+	ins.Columns("b").Values(1).Values(2).Values(3).Values(4).Chunk(len("INSERT INTO a (`b`) VALUES (1)"), 4)
+	res, err := ins.Exec()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	ra, err := res.RowsAffected()
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(4), ra)
+	assert.NoError(t, tx.Commit())
+	if err := dbMock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -26,8 +26,10 @@ var _ fmt.Stringer = (*dbr.Delete)(nil)
 var _ fmt.Stringer = (*dbr.Insert)(nil)
 var _ fmt.Stringer = (*dbr.Update)(nil)
 var _ fmt.Stringer = (*dbr.Select)(nil)
+var _ fmt.Stringer = (*dbr.Union)(nil)
 
 var _ dbr.QueryBuilder = (*dbr.Select)(nil)
 var _ dbr.QueryBuilder = (*dbr.Delete)(nil)
 var _ dbr.QueryBuilder = (*dbr.Update)(nil)
 var _ dbr.QueryBuilder = (*dbr.Insert)(nil)
+var _ dbr.QueryBuilder = (*dbr.Union)(nil)
@@ -0,0 +1,252 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/corestoreio/csfw/log"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// Replica represents a read only connection to a MySQL slave server.
+type Replica struct {
+	// Name identifies the replica in log messages, for example the host name.
+	Name string
+	DB   *sql.DB
+	// lagging set to 1 if the replica lags too far behind the master or
+	// cannot be reached.
+	lagging int32
+}
+
+// IsLagging returns true if the last lag check has excluded the replica.
+func (r *Replica) IsLagging() bool {
+	return atomic.LoadInt32(&r.lagging) == 1
+}
+
+// LagCheckFunc reports whether a replica lags too far behind the master. An
+// error excludes the replica like a lag.
+type LagCheckFunc func(ctx context.Context, master, replica *sql.DB) (isLagging bool, err error)
+
+// WithReplica adds a read only connection to a replica. Selects created by a
+// Session get routed to the replicas in a round robin fashion. Inserts,
+// updates, deletes and transactions always use the master.
+func WithReplica(name string, db *sql.DB) ConnectionOption {
+	return func(c *Connection) error {
+		c.Replicas = append(c.Replicas, &Replica{Name: name, DB: db})
+		return nil
+	}
+}
+
+// WithReplicaDSN opens a new read only connection to a replica with the data
+// source name.
+func WithReplicaDSN(name, dsn string) ConnectionOption {
+	return func(c *Connection) error {
+		db, err := sql.Open(DriverNameMySQL, dsn)
+		if err != nil {
+			return errors.Wrapf(err, "[dbr] WithReplicaDSN.sql.Open Name %q", name)
+		}
+		c.Replicas = append(c.Replicas, &Replica{Name: name, DB: db})
+		return nil
+	}
+}
+
+// WithReplicaLagCheck sets the function to detect replicas which lag behind
+// the master. See CheckReplicas.
+func WithReplicaLagCheck(lc LagCheckFunc) ConnectionOption {
+	return func(c *Connection) error {
+		c.LagCheck = lc
+		return nil
+	}
+}
+
+// CheckReplicas runs the LagCheck against all replicas and excludes the
+// lagging ones from the routing. A replica with a failing check gets also
+// excluded. Returns the first error.
+func (c *Connection) CheckReplicas(ctx context.Context) error {
+	if c.LagCheck == nil {
+		return nil
+	}
+	var firstErr error
+	for _, r := range c.Replicas {
+		isLagging, err := c.LagCheck(ctx, c.DB, r.DB)
+		if err != nil && firstErr == nil {
+			firstErr = errors.Wrapf(err, "[dbr] CheckReplicas Name %q", r.Name)
+		}
+		var lagging int32
+		if isLagging || err != nil {
+			lagging = 1
+		}
+		if old := atomic.SwapInt32(&r.lagging, lagging); old != lagging && c.Logger != nil && c.Logger.IsDebug() {
+			c.Logger.Debug("dbr.Connection.CheckReplicas", log.String("name", r.Name), log.Bool("isLagging", lagging == 1), log.Err(err))
+		}
+	}
+	return firstErr
+}
+
+// RunReplicaCheck calls CheckReplicas in the interval until the context gets
+// cancelled. Errors get logged as info. Blocking.
+func (c *Connection) RunReplicaCheck(ctx context.Context, interval time.Duration) {
+	tkr := time.NewTicker(interval)
+	defer tkr.Stop()
+	for {
+		select {
+		case <-tkr.C:
+			if err := c.CheckReplicas(ctx); err != nil && c.Logger != nil && c.Logger.IsInfo() {
+				c.Logger.Info("dbr.Connection.RunReplicaCheck", log.Err(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reader returns the next non-lagging replica or the master if no replica is
+// available.
+func (c *Connection) reader() *sql.DB {
+	lr := uint32(len(c.Replicas))
+	if lr == 0 {
+		return c.DB
+	}
+	next := atomic.AddUint32(&c.replicaIdx, 1)
+	for i := uint32(0); i < lr; i++ {
+		if r := c.Replicas[(next+i)%lr]; !r.IsLagging() {
+			return r.DB
+		}
+	}
+	return c.DB
+}
+
+// WithReadYourWrites pins the Session to the master once it has executed a
+// write or started a transaction. All later Selects of the Session read from
+// the master and see their own changes despite a lagging replica.
+func WithReadYourWrites() SessionOption {
+	return func(_ *Connection, s *Session) error {
+		s.readYourWrites = true
+		return nil
+	}
+}
+
+// IsPinned returns true if the Session reads from the master because of a
+// previous write.
+func (sess *Session) IsPinned() bool {
+	return atomic.LoadInt32(&sess.pinned) == 1
+}
+
+// pin marks the Session to read from the master.
+func (sess *Session) pin() {
+	if sess.readYourWrites {
+		atomic.StoreInt32(&sess.pinned, 1)
+	}
+}
+
+// reader returns the connection for the Selects.
+func (sess *Session) reader() *sql.DB {
+	if sess.IsPinned() {
+		return sess.cxn.DB
+	}
+	return sess.cxn.reader()
+}
+
+// writer returns the database handle for the writing statements. With
+// enabled read your writes mode the Session gets pinned on execution.
+func (sess *Session) writer() pinDB {
+	return pinDB{DB: sess.cxn.DB, sess: sess}
+}
+
+// pinDB pins a Session to the master on each executed write.
+type pinDB struct {
+	*sql.DB
+	sess *Session
+}
+
+func (pd pinDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	pd.sess.pin()
+	return pd.DB.Exec(query, args...)
+}
+
+func (pd pinDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	pd.sess.pin()
+	return pd.DB.ExecContext(ctx, query, args...)
+}
+
+func (pd pinDB) Prepare(query string) (*sql.Stmt, error) {
+	pd.sess.pin()
+	return pd.DB.Prepare(query)
+}
+
+func (pd pinDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	pd.sess.pin()
+	return pd.DB.PrepareContext(ctx, query)
+}
+
+// ShowSlaveStatus returns the columns and their values of the statement SHOW
+// SLAVE STATUS. Error behaviour: NotFound if the server is not a replica.
+func ShowSlaveStatus(ctx context.Context, replica *sql.DB) (map[string]string, error) {
+	rows, err := replica.QueryContext(ctx, "SHOW SLAVE STATUS")
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] ShowSlaveStatus.Query")
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] ShowSlaveStatus.Columns")
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, errors.Wrap(err, "[dbr] ShowSlaveStatus.Rows")
+		}
+		return nil, errors.NewNotFoundf("[dbr] ShowSlaveStatus: Server is not a replica")
+	}
+	vals := make([]sql.NullString, len(cols))
+	scanArgs := make([]interface{}, len(cols))
+	for i := range vals {
+		scanArgs[i] = &vals[i]
+	}
+	if err := rows.Scan(scanArgs...); err != nil {
+		return nil, errors.Wrap(err, "[dbr] ShowSlaveStatus.Scan")
+	}
+	ret := make(map[string]string, len(cols))
+	for i, c := range cols {
+		ret[c] = vals[i].String
+	}
+	return ret, errors.Wrap(rows.Err(), "[dbr] ShowSlaveStatus.Rows")
+}
+
+// SecondsBehindMaster creates a LagCheckFunc which excludes a replica if the
+// column Seconds_Behind_Master of SHOW SLAVE STATUS exceeds the maximum. A
+// stopped replication (NULL) counts as lag.
+func SecondsBehindMaster(max time.Duration) LagCheckFunc {
+	return func(ctx context.Context, _, replica *sql.DB) (bool, error) {
+		st, err := ShowSlaveStatus(ctx, replica)
+		if err != nil {
+			return true, errors.Wrap(err, "[dbr] SecondsBehindMaster")
+		}
+		sbm := st["Seconds_Behind_Master"]
+		if sbm == "" {
+			return true, nil
+		}
+		secs, err := strconv.ParseInt(sbm, 10, 64)
+		if err != nil {
+			return true, errors.NewNotValidf("[dbr] SecondsBehindMaster: Cannot parse %q: %s", sbm, err)
+		}
+		return time.Duration(secs)*time.Second > max, nil
+	}
+}
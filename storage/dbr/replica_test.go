@@ -0,0 +1,134 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newReplicaConnection(t *testing.T, opts ...ConnectionOption) (*Connection, sqlmock.Sqlmock, sqlmock.Sqlmock) {
+	master, masterMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	replica, replicaMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewConnection(append([]ConnectionOption{WithDB(master), WithReplica("replica1", replica)}, opts...)...)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return c, masterMock, replicaMock
+}
+
+func assertReplicaMocks(t *testing.T, c *Connection, masterMock, replicaMock sqlmock.Sqlmock) {
+	masterMock.ExpectClose()
+	replicaMock.ExpectClose()
+	assert.NoError(t, c.Close())
+	if err := masterMock.ExpectationsWereMet(); err != nil {
+		t.Error("master: there were unfulfilled expections", err)
+	}
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Error("replica: there were unfulfilled expections", err)
+	}
+}
+
+func TestReplica_Routing(t *testing.T) {
+	c, masterMock, replicaMock := newReplicaConnection(t)
+	defer assertReplicaMocks(t, c, masterMock, replicaMock)
+
+	replicaMock.ExpectQuery("SELECT a FROM `tableA`").WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1))
+	masterMock.ExpectExec("UPDATE `tableA` SET `a` = 2").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	sess := c.NewSession()
+	var a int64
+	err := sess.Select("a").From("tableA").LoadValue(&a)
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(1), a)
+
+	_, err = sess.Update("tableA").Set("a", 2).Exec()
+	assert.NoError(t, err)
+	assert.False(t, sess.IsPinned(), "Session must not be pinned without read your writes")
+}
+
+func TestReplica_SecondsBehindMaster(t *testing.T) {
+	c, masterMock, replicaMock := newReplicaConnection(t, WithReplicaLagCheck(SecondsBehindMaster(10*time.Second)))
+	defer assertReplicaMocks(t, c, masterMock, replicaMock)
+
+	replicaMock.ExpectQuery("SHOW SLAVE STATUS").WillReturnRows(
+		sqlmock.NewRows([]string{"Slave_IO_State", "Seconds_Behind_Master"}).AddRow("Waiting for master to send event", "30"))
+	masterMock.ExpectQuery("SELECT a FROM `tableA`").WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1))
+	replicaMock.ExpectQuery("SHOW SLAVE STATUS").WillReturnRows(
+		sqlmock.NewRows([]string{"Slave_IO_State", "Seconds_Behind_Master"}).AddRow("Waiting for master to send event", "3"))
+	replicaMock.ExpectQuery("SELECT a FROM `tableA`").WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(2))
+
+	sess := c.NewSession()
+
+	assert.NoError(t, c.CheckReplicas(context.TODO()))
+	assert.True(t, c.Replicas[0].IsLagging())
+	var a int64
+	err := sess.Select("a").From("tableA").LoadValue(&a)
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(1), a, "Lagging replica must fall back to the master")
+
+	assert.NoError(t, c.CheckReplicas(context.TODO()))
+	assert.False(t, c.Replicas[0].IsLagging())
+	err = sess.Select("a").From("tableA").LoadValue(&a)
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(2), a)
+}
+
+func TestReplica_CheckError(t *testing.T) {
+	c, masterMock, replicaMock := newReplicaConnection(t, WithReplicaLagCheck(SecondsBehindMaster(time.Second)))
+	defer assertReplicaMocks(t, c, masterMock, replicaMock)
+
+	replicaMock.ExpectQuery("SHOW SLAVE STATUS").WillReturnRows(
+		sqlmock.NewRows([]string{"Slave_IO_State", "Seconds_Behind_Master"}))
+
+	err := c.CheckReplicas(context.TODO())
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+	assert.True(t, c.Replicas[0].IsLagging())
+}
+
+func TestReplica_ReadYourWrites(t *testing.T) {
+	c, masterMock, replicaMock := newReplicaConnection(t)
+	defer assertReplicaMocks(t, c, masterMock, replicaMock)
+
+	replicaMock.ExpectQuery("SELECT a FROM `tableA`").WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1))
+	masterMock.ExpectExec("INSERT INTO tableA").WillReturnResult(sqlmock.NewResult(2, 1))
+	masterMock.ExpectQuery("SELECT a FROM `tableA`").WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(2))
+
+	sess := c.NewSession(WithReadYourWrites())
+	var a int64
+	err := sess.Select("a").From("tableA").LoadValue(&a)
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(1), a)
+	assert.False(t, sess.IsPinned())
+
+	_, err = sess.InsertInto("tableA").Columns("a").Values(2).Exec()
+	assert.NoError(t, err)
+	assert.True(t, sess.IsPinned())
+
+	err = sess.Select("a").From("tableA").LoadValue(&a)
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(2), a, "Pinned Session must read from the master")
+}
@@ -0,0 +1,77 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"database/sql"
+
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// RowScanner loads the rows of a result set without reflection. LoadStructs
+// and LoadStruct use the interface if the destination implements it,
+// otherwise they fall back to reflection. The types generated via
+// codegen/tableToStruct implement RowScanner.
+type RowScanner interface {
+	// RowScanArgs returns the addresses of the fields into which the next
+	// row gets scanned, in the order of the columns. A collection type must
+	// append a new record for each call and return the addresses of the new
+	// record. A nil address discards the column. The implementation should
+	// append to args[:0] to avoid allocations.
+	RowScanArgs(columns []string, args []interface{}) ([]interface{}, error)
+}
+
+// ArgumentAssembler returns the values of a record without reflection.
+// Insert.Record uses the interface if the record implements it, otherwise it
+// falls back to reflection. The types generated via codegen/tableToStruct
+// implement ArgumentAssembler.
+type ArgumentAssembler interface {
+	// AssembleArguments appends the values of the columns to args and
+	// returns the extended slice. An unknown column must return an error.
+	AssembleArguments(columns []string, args []interface{}) ([]interface{}, error)
+}
+
+// scanRowScanner scans one row into the destination of the RowScanner. args
+// gets reused and returned.
+func scanRowScanner(rows *sql.Rows, columns []string, rs RowScanner, args []interface{}) ([]interface{}, error) {
+	args, err := rs.RowScanArgs(columns, args[:0])
+	if err != nil {
+		return args, errors.Wrap(err, "[dbr] RowScanner.RowScanArgs")
+	}
+	if len(args) != len(columns) {
+		return args, errors.NewNotValidf("[dbr] RowScanner.RowScanArgs returned %d arguments for %d columns", len(args), len(columns))
+	}
+	for i, a := range args {
+		if a == nil {
+			args[i] = &destDummy
+		}
+	}
+	return args, errors.Wrap(rows.Scan(args...), "[dbr] RowScanner.Scan")
+}
+
+// loadRowScanner scans all rows into the RowScanner and returns the number of
+// loaded rows.
+func loadRowScanner(rows *sql.Rows, columns []string, rs RowScanner) (int, error) {
+	args := make([]interface{}, 0, len(columns))
+	var n int
+	for rows.Next() {
+		var err error
+		if args, err = scanRowScanner(rows, columns, rs, args); err != nil {
+			return n, errors.Wrap(err, "[dbr] loadRowScanner")
+		}
+		n++
+	}
+	return n, errors.Wrap(rows.Err(), "[dbr] loadRowScanner.rows_err")
+}
@@ -0,0 +1,292 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/corestoreio/csfw/util/null"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ RowScanner = (*scannedPersons)(nil)
+var _ RowScanner = (*scannedPerson)(nil)
+var _ ArgumentAssembler = (*scannedPerson)(nil)
+
+// scannedPerson has the same fields as dbrPerson but implements the
+// interfaces like the code generated via tableToStruct.
+type scannedPerson struct {
+	ID    int64
+	Name  string
+	Email null.String
+	Key   null.String
+}
+
+type scannedPersons []*scannedPerson
+
+func (s *scannedPersons) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	e := new(scannedPerson)
+	*s = append(*s, e)
+	return e.RowScanArgs(columns, args)
+}
+
+func (e *scannedPerson) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	args = args[:0]
+	for _, c := range columns {
+		switch c {
+		case "id":
+			args = append(args, &e.ID)
+		case "name":
+			args = append(args, &e.Name)
+		case "email":
+			args = append(args, &e.Email)
+		case "key":
+			args = append(args, &e.Key)
+		default:
+			args = append(args, nil)
+		}
+	}
+	return args, nil
+}
+
+func (e *scannedPerson) AssembleArguments(columns []string, args []interface{}) ([]interface{}, error) {
+	for _, c := range columns {
+		switch c {
+		case "id":
+			args = append(args, e.ID)
+		case "name":
+			args = append(args, e.Name)
+		case "email":
+			args = append(args, e.Email)
+		case "key":
+			args = append(args, e.Key)
+		default:
+			return nil, errors.NewNotFoundf("[dbr] scannedPerson.AssembleArguments: Unknown column %q", c)
+		}
+	}
+	return args, nil
+}
+
+func newScannerConnection(t *testing.T) (*Connection, sqlmock.Sqlmock) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewConnection(WithDB(db))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return c, dbMock
+}
+
+func TestSelect_LoadStructs_RowScanner(t *testing.T) {
+	c, dbMock := newScannerConnection(t)
+	defer func() {
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	dbMock.ExpectQuery("SELECT id, name, email, created_at FROM `dbr_people`").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "email", "created_at"}).
+			AddRow(1, "Jonathan", "jonathan@uservoice.com", "2016-11-11").
+			AddRow(2, "Dmitri", nil, "2016-11-12"),
+	)
+
+	var people scannedPersons
+	n, err := c.NewSession().Select("id", "name", "email", "created_at").From("dbr_people").LoadStructs(&people)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, 2, n)
+	assert.Exactly(t, scannedPersons{
+		{ID: 1, Name: "Jonathan", Email: null.StringFrom("jonathan@uservoice.com")},
+		{ID: 2, Name: "Dmitri"},
+	}, people)
+}
+
+func TestSelect_LoadStruct_RowScanner(t *testing.T) {
+	c, dbMock := newScannerConnection(t)
+	defer func() {
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	dbMock.ExpectQuery("SELECT id, name FROM `dbr_people` WHERE \\(id = 3\\)").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "Cyrill"),
+	)
+	dbMock.ExpectQuery("SELECT id, name FROM `dbr_people` WHERE \\(id = 4\\)").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}),
+	)
+
+	var p scannedPerson
+	err := c.NewSession().Select("id", "name").From("dbr_people").Where(ConditionRaw("id = ?", 3)).LoadStruct(&p)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, scannedPerson{ID: 3, Name: "Cyrill"}, p)
+
+	err = c.NewSession().Select("id", "name").From("dbr_people").Where(ConditionRaw("id = ?", 4)).LoadStruct(&p)
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+}
+
+func TestInsert_Record_ArgumentAssembler(t *testing.T) {
+	p1 := &scannedPerson{ID: 1, Name: "Jonathan", Email: null.StringFrom("jonathan@uservoice.com")}
+	p2 := &dbrPerson{Id: 2, Name: "Dmitri"}
+	p3 := &scannedPerson{ID: 3, Name: "Cyrill"}
+
+	sqlStr, args, err := createFakeSession().InsertInto("dbr_people").Columns("id", "name", "email").
+		Record(p1).Record(p2).Record(p3).ToSQL()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, "INSERT INTO dbr_people (`id`,`name`,`email`) VALUES (?,?,?),(?,?,?),(?,?,?)", sqlStr)
+	assert.Exactly(t, []interface{}{
+		int64(1), "Jonathan", null.StringFrom("jonathan@uservoice.com"),
+		int64(2), "Dmitri", null.String{},
+		int64(3), "Cyrill", null.String{},
+	}, args)
+
+	_, _, err = createFakeSession().InsertInto("dbr_people").Columns("id", "age").Record(p1).ToSQL()
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+}
+
+// benchDriver returns for each query the same rows without any network
+// roundtrip to measure the allocations of the scanning.
+type benchDriver struct{}
+
+func (benchDriver) Open(_ string) (driver.Conn, error) { return benchConn{}, nil }
+
+type benchConn struct{}
+
+func (benchConn) Prepare(_ string) (driver.Stmt, error) { return benchStmt{}, nil }
+func (benchConn) Close() error                          { return nil }
+func (benchConn) Begin() (driver.Tx, error) {
+	return nil, errors.NewNotImplementedf("[dbr] benchConn.Begin")
+}
+
+type benchStmt struct{}
+
+func (benchStmt) Close() error                                 { return nil }
+func (benchStmt) NumInput() int                                { return -1 }
+func (benchStmt) Exec(_ []driver.Value) (driver.Result, error) { return driver.ResultNoRows, nil }
+func (benchStmt) Query(_ []driver.Value) (driver.Rows, error)  { return &benchRows{}, nil }
+func (benchStmt) ColumnConverter(_ int) driver.ValueConverter {
+	return driver.DefaultParameterConverter
+}
+
+const benchRowCount = 100
+
+type benchRows struct {
+	idx int
+}
+
+func (*benchRows) Columns() []string { return []string{"id", "name", "email", "key"} }
+func (*benchRows) Close() error      { return nil }
+func (r *benchRows) Next(dest []driver.Value) error {
+	if r.idx >= benchRowCount {
+		return io.EOF
+	}
+	r.idx++
+	dest[0] = int64(r.idx)
+	dest[1] = []byte("Jonathan")
+	dest[2] = []byte("jonathan@uservoice.com")
+	dest[3] = nil
+	return nil
+}
+
+func init() {
+	sql.Register("dbrbench", benchDriver{})
+}
+
+func newBenchSession(b *testing.B) *Session {
+	db, err := sql.Open("dbrbench", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	c, err := NewConnection(WithDB(db))
+	if err != nil {
+		b.Fatalf("%+v", err)
+	}
+	return c.NewSession()
+}
+
+func BenchmarkSelectLoadStructsReflection(b *testing.B) {
+	sess := newBenchSession(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var people []*dbrPerson
+		if _, err := sess.Select("id", "name", "email", "key").From("dbr_people").LoadStructs(&people); err != nil {
+			b.Fatalf("%+v", err)
+		}
+	}
+}
+
+func BenchmarkSelectLoadStructsRowScanner(b *testing.B) {
+	sess := newBenchSession(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var people scannedPersons
+		if _, err := sess.Select("id", "name", "email", "key").From("dbr_people").LoadStructs(&people); err != nil {
+			b.Fatalf("%+v", err)
+		}
+	}
+}
+
+func BenchmarkInsertRecordsReflection(b *testing.B) {
+	people := make([]*dbrPerson, benchRowCount)
+	for i := range people {
+		people[i] = &dbrPerson{Id: int64(i), Name: "Jonathan", Email: null.StringFrom("jonathan@uservoice.com")}
+	}
+	sess := createFakeSession()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ins := sess.InsertInto("dbr_people").Columns("id", "name", "email", "key")
+		for _, p := range people {
+			ins.Record(p)
+		}
+		if _, _, err := ins.ToSQL(); err != nil {
+			b.Fatalf("%+v", err)
+		}
+	}
+}
+
+func BenchmarkInsertRecordsArgumentAssembler(b *testing.B) {
+	people := make([]*scannedPerson, benchRowCount)
+	for i := range people {
+		people[i] = &scannedPerson{ID: int64(i), Name: "Jonathan", Email: null.StringFrom("jonathan@uservoice.com")}
+	}
+	sess := createFakeSession()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ins := sess.InsertInto("dbr_people").Columns("id", "name", "email", "key")
+		for _, p := range people {
+			ins.Record(p)
+		}
+		if _, _, err := ins.ToSQL(); err != nil {
+			b.Fatalf("%+v", err)
+		}
+	}
+}
@@ -17,6 +17,8 @@ type Select struct {
 	Querier
 	QueryRower
 	Preparer
+	// Events gets notified after each database operation. Optional.
+	Events EventReceiver
 
 	RawFullSQL   string
 	RawArguments []interface{}
@@ -48,22 +50,26 @@ func NewSelect(from ...string) *Select {
 
 // Select creates a new Select that select that given columns
 func (sess *Session) Select(cols ...string) *Select {
+	db := sess.reader()
 	return &Select{
 		Logger:     sess.Logger,
-		Querier:    sess.cxn.DB,
-		QueryRower: sess.cxn.DB,
-		Preparer:   sess.cxn.DB,
+		Events:     sess.Events,
+		Querier:    db,
+		QueryRower: db,
+		Preparer:   db,
 		Columns:    cols,
 	}
 }
 
 // SelectBySql creates a new Select for the given SQL string and arguments
 func (sess *Session) SelectBySql(sql string, args ...interface{}) *Select {
+	db := sess.reader()
 	return &Select{
 		Logger:       sess.Logger,
-		Querier:      sess.cxn.DB,
-		QueryRower:   sess.cxn.DB,
-		Preparer:     sess.cxn.DB,
+		Events:       sess.Events,
+		Querier:      db,
+		QueryRower:   db,
+		Preparer:     db,
 		RawFullSQL:   sql,
 		RawArguments: args,
 	}
@@ -73,6 +79,7 @@ func (sess *Session) SelectBySql(sql string, args ...interface{}) *Select {
 func (tx *Tx) Select(cols ...string) *Select {
 	return &Select{
 		Logger:     tx.Logger,
+		Events:     tx.Events,
 		QueryRower: tx.Tx,
 		Querier:    tx.Tx,
 		Preparer:   tx.Tx,
@@ -84,6 +91,7 @@ func (tx *Tx) Select(cols ...string) *Select {
 func (tx *Tx) SelectBySql(sql string, args ...interface{}) *Select {
 	return &Select{
 		Logger:       tx.Logger,
+		Events:       tx.Events,
 		QueryRower:   tx.Tx,
 		Querier:      tx.Tx,
 		Preparer:     tx.Tx,
@@ -112,6 +120,13 @@ func (b *Select) From(from ...string) *Select {
 	return b
 }
 
+// FromSelect sets a subquery as a derived table. The alias is required.
+// SELECT ... FROM (SELECT ...) AS `alias`.
+func (b *Select) FromSelect(sub QueryBuilder, alias string) *Select {
+	b.FromTable = MakeAliasDerived(sub, alias)
+	return b
+}
+
 // Where appends a WHERE clause to the statement for the given string and args
 // or map of column/value pairs
 func (b *Select) Where(args ...ConditionArg) *Select {
@@ -180,7 +195,7 @@ func (b *Select) ToSQL() (string, []interface{}, error) {
 		return b.RawFullSQL, b.RawArguments, nil
 	}
 
-	if len(b.FromTable.Expression) == 0 {
+	if b.FromTable.isEmpty() {
 		return "", nil, errors.NewEmptyf(errTableMissing)
 	}
 	if len(b.Columns) == 0 {
@@ -215,22 +230,30 @@ func (b *Select) ToSQL() (string, []interface{}, error) {
 	}
 
 	sql.WriteString(" FROM ")
-	sql.WriteString(b.FromTable.QuoteAs())
+	if err := b.FromTable.writeQuoted(sql, &args); err != nil {
+		return "", nil, errors.Wrap(err, "[dbr] Select.ToSQL.FromTable")
+	}
 
 	if len(b.JoinFragments) > 0 {
 		for _, f := range b.JoinFragments {
 			sql.WriteRune(' ')
 			sql.WriteString(f.JoinType)
 			sql.WriteString(" JOIN ")
-			sql.WriteString(f.Table.QuoteAs())
+			if err := f.Table.writeQuoted(sql, &args); err != nil {
+				return "", nil, errors.Wrap(err, "[dbr] Select.ToSQL.JoinFragments.Table")
+			}
 			sql.WriteString(" ON ")
-			writeWhereFragmentsToSql(f.OnConditions, sql, &args)
+			if err := writeWhereFragmentsToSql(f.OnConditions, sql, &args); err != nil {
+				return "", nil, errors.Wrap(err, "[dbr] Select.ToSQL.JoinFragments.OnConditions")
+			}
 		}
 	}
 
 	if len(b.WhereFragments) > 0 {
 		sql.WriteString(" WHERE ")
-		writeWhereFragmentsToSql(b.WhereFragments, sql, &args)
+		if err := writeWhereFragmentsToSql(b.WhereFragments, sql, &args); err != nil {
+			return "", nil, errors.Wrap(err, "[dbr] Select.ToSQL.WhereFragments")
+		}
 	}
 
 	if len(b.GroupBys) > 0 {
@@ -245,7 +268,9 @@ func (b *Select) ToSQL() (string, []interface{}, error) {
 
 	if len(b.HavingFragments) > 0 {
 		sql.WriteString(" HAVING ")
-		writeWhereFragmentsToSql(b.HavingFragments, sql, &args)
+		if err := writeWhereFragmentsToSql(b.HavingFragments, sql, &args); err != nil {
+			return "", nil, errors.Wrap(err, "[dbr] Select.ToSQL.HavingFragments")
+		}
 	}
 
 	if len(b.OrderBys) > 0 {
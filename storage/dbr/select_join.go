@@ -50,3 +50,25 @@ func (b *Select) LeftJoin(table, columns []string, onConditions ...ConditionArg)
 func (b *Select) RightJoin(table, columns []string, onConditions ...ConditionArg) *Select {
 	return b.join("RIGHT", table, columns, onConditions...)
 }
+
+func (b *Select) joinSelect(j string, sub QueryBuilder, alias string, c []string, on ...ConditionArg) *Select {
+	b.JoinFragments = append(b.JoinFragments, &joinFragment{
+		JoinType:     j,
+		Table:        MakeAliasDerived(sub, alias),
+		Columns:      c,
+		OnConditions: newWhereFragments(on...),
+	})
+	return b
+}
+
+// JoinSelect joins a subquery as a derived table with the required alias.
+// The onConditions get glued together with AND.
+func (b *Select) JoinSelect(sub QueryBuilder, alias string, columns []string, onConditions ...ConditionArg) *Select {
+	return b.joinSelect("INNER", sub, alias, columns, onConditions...)
+}
+
+// LeftJoinSelect joins a subquery as a derived table with the required
+// alias. The onConditions get glued together with AND.
+func (b *Select) LeftJoinSelect(sub QueryBuilder, alias string, columns []string, onConditions ...ConditionArg) *Select {
+	return b.joinSelect("LEFT", sub, alias, columns, onConditions...)
+}
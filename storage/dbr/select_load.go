@@ -1,8 +1,10 @@
 package dbr
 
 import (
+	"context"
 	"database/sql"
 	"reflect"
+	"time"
 
 	"github.com/corestoreio/csfw/log"
 	"github.com/corestoreio/csfw/util/errors"
@@ -10,6 +12,11 @@ import (
 
 // Rows executes a query and returns many rows. Does no interpolation.
 func (b *Select) Rows() (*sql.Rows, error) {
+	return b.RowsContext(context.Background())
+}
+
+// RowsContext same as Rows but applies the context to the database call.
+func (b *Select) RowsContext(ctx context.Context) (*sql.Rows, error) {
 
 	sqlStr, args, err := b.ToSQL()
 	if err != nil {
@@ -21,7 +28,9 @@ func (b *Select) Rows() (*sql.Rows, error) {
 		defer log.WhenDone(b.Logger).Info("dbr.Select.Rows.Timing", log.String("sql", sqlStr))
 	}
 
-	rows, err := b.Querier.Query(sqlStr, args...)
+	start := time.Now()
+	rows, err := querierContext(ctx, b.Querier).Query(sqlStr, args...)
+	emitEvent(ctx, b.Events, EventOpQuery, sqlStr, args, start, -1, err)
 	return rows, errors.Wrap(err, "[store] Select.Rows.QueryContext")
 }
 
@@ -29,23 +38,38 @@ func (b *Select) Rows() (*sql.Rows, error) {
 // row. QueryRow always returns a non-nil value. Errors are deferred
 // until Row's Scan method is called.
 func (b *Select) Row() *sql.Row {
+	return b.RowContext(context.Background())
+}
+
+// RowContext same as Row but applies the context to the database call.
+func (b *Select) RowContext(ctx context.Context) *sql.Row {
 
 	sqlStr, args, err := b.ToSQL()
 	if err != nil {
 		panic(err) // todo remove panic and log error .... ?
 		// return nil, errors.Wrap(err, "[store] Select.Rows.ToSQL")
 	}
-	return b.QueryRower.QueryRow(sqlStr, args...)
+	start := time.Now()
+	row := queryRowerContext(ctx, b.QueryRower).QueryRow(sqlStr, args...)
+	emitEvent(ctx, b.Events, EventOpQueryRow, sqlStr, args, start, -1, nil)
+	return row
 }
 
 // Prepare prepares a SQL statement.
 func (b *Select) Prepare() (*sql.Stmt, error) {
+	return b.PrepareContext(context.Background())
+}
+
+// PrepareContext same as Prepare but applies the context to the database call.
+func (b *Select) PrepareContext(ctx context.Context) (*sql.Stmt, error) {
 
 	sqlStr, _, err := b.ToSQL()
 	if err != nil {
 		return nil, errors.Wrap(err, "[store] Select.Rows.ToSQL")
 	}
-	stmt, err := b.Preparer.Prepare(sqlStr)
+	start := time.Now()
+	stmt, err := preparerContext(ctx, b.Preparer).Prepare(sqlStr)
+	emitEvent(ctx, b.Events, EventOpPrepare, sqlStr, nil, start, -1, err)
 	return stmt, errors.Wrap(err, "[store] Select.Rows.QueryContext")
 }
 
@@ -59,37 +83,25 @@ func (b *Select) Prepare() (*sql.Stmt, error) {
 // LoadStructs executes the Select and loads the resulting data into a
 // slice of structs dest must be a pointer to a slice of pointers to structs.
 // Returns the number of items found (which is not necessarily the # of items
-// set). Slow because of the massive use of reflection.
+// set). Slow because of the massive use of reflection, unless dest implements
+// interface RowScanner.
 func (b *Select) LoadStructs(dest interface{}) (int, error) {
+	return b.LoadStructsContext(context.Background(), dest)
+}
+
+// LoadStructsContext same as LoadStructs but applies the context to the database call.
+func (b *Select) LoadStructsContext(ctx context.Context, dest interface{}) (_ int, err error) {
+	rs, isRowScanner := dest.(RowScanner)
+
 	//
 	// Validate the dest, and extract the reflection values we need.
 	//
-
-	// This must be a pointer to a slice
-	valueOfDest := reflect.ValueOf(dest)
-	kindOfDest := valueOfDest.Kind()
-
-	if kindOfDest != reflect.Ptr {
-		return 0, errors.NewNotValidf("[dbr] invalid type passed to LoadStructs. Need a pointer to a slice")
-	}
-
-	// This must a slice
-	valueOfDest = reflect.Indirect(valueOfDest)
-	kindOfDest = valueOfDest.Kind()
-
-	if kindOfDest != reflect.Slice {
-		return 0, errors.NewNotValidf("[dbr] invalid type passed to LoadStructs. Need a pointer to a slice")
-	}
-
-	// The slice elements must be pointers to structures
-	recordType := valueOfDest.Type().Elem()
-	if recordType.Kind() != reflect.Ptr {
-		return 0, errors.NewNotValidf("[dbr] Elements need to be pointers to structures")
-	}
-
-	recordType = recordType.Elem()
-	if recordType.Kind() != reflect.Struct {
-		return 0, errors.NewNotValidf("[dbr] Elements need to be pointers to structures")
+	var valueOfDest reflect.Value
+	var recordType reflect.Type
+	if !isRowScanner {
+		if valueOfDest, recordType, err = structSliceOf(dest); err != nil {
+			return 0, errors.Wrap(err, "[dbr] Select.LoadStructs")
+		}
 	}
 
 	//
@@ -112,8 +124,13 @@ func (b *Select) LoadStructs(dest interface{}) (int, error) {
 		defer log.WhenDone(b.Logger).Info("dbr.Select.LoadStructs.QueryContext.timing", log.String("sql", tSQL))
 	}
 
+	start := time.Now()
+	defer func() {
+		emitEvent(ctx, b.Events, EventOpQuery, tSQL, tArg, start, int64(numberOfRowsReturned), err)
+	}()
+
 	// Run the query:
-	rows, err := b.Querier.Query(fullSQL)
+	rows, err := querierContext(ctx, b.Querier).Query(fullSQL)
 	if err != nil {
 		return 0, errors.Wrap(err, "[dbr] Select.LoadStructs.query")
 	}
@@ -125,6 +142,11 @@ func (b *Select) LoadStructs(dest interface{}) (int, error) {
 		return numberOfRowsReturned, errors.Wrap(err, "[dbr] Select.load_one.rows.Columns")
 	}
 
+	if isRowScanner {
+		numberOfRowsReturned, err = loadRowScanner(rows, columns, rs)
+		return numberOfRowsReturned, errors.Wrap(err, "[dbr] Select.LoadStructs.RowScanner")
+	}
+
 	// Create a map of this result set to the struct fields
 	fieldMap, err := calculateFieldMap(recordType, columns, false)
 	if err != nil {
@@ -168,23 +190,67 @@ func (b *Select) LoadStructs(dest interface{}) (int, error) {
 	return numberOfRowsReturned, nil
 }
 
+// structSliceOf validates that dest is a pointer to a slice of pointers to
+// structs and returns the slice and the type of the struct.
+func structSliceOf(dest interface{}) (reflect.Value, reflect.Type, error) {
+	// This must be a pointer to a slice
+	valueOfDest := reflect.ValueOf(dest)
+	kindOfDest := valueOfDest.Kind()
+
+	if kindOfDest != reflect.Ptr {
+		return reflect.Value{}, nil, errors.NewNotValidf("[dbr] invalid type passed to LoadStructs. Need a pointer to a slice")
+	}
+
+	// This must a slice
+	valueOfDest = reflect.Indirect(valueOfDest)
+	kindOfDest = valueOfDest.Kind()
+
+	if kindOfDest != reflect.Slice {
+		return reflect.Value{}, nil, errors.NewNotValidf("[dbr] invalid type passed to LoadStructs. Need a pointer to a slice")
+	}
+
+	// The slice elements must be pointers to structures
+	recordType := valueOfDest.Type().Elem()
+	if recordType.Kind() != reflect.Ptr {
+		return reflect.Value{}, nil, errors.NewNotValidf("[dbr] Elements need to be pointers to structures")
+	}
+
+	recordType = recordType.Elem()
+	if recordType.Kind() != reflect.Struct {
+		return reflect.Value{}, nil, errors.NewNotValidf("[dbr] Elements need to be pointers to structures")
+	}
+	return valueOfDest, recordType, nil
+}
+
 // LoadStruct executes the Select and loads the resulting data into a
 // struct dest must be a pointer to a struct Returns ErrNotFound behaviour. Slow
-// because of the massive use of reflection.
+// because of the massive use of reflection, unless dest implements interface
+// RowScanner.
 func (b *Select) LoadStruct(dest interface{}) error {
+	return b.LoadStructContext(context.Background(), dest)
+}
+
+// LoadStructContext same as LoadStruct but applies the context to the database call.
+func (b *Select) LoadStructContext(ctx context.Context, dest interface{}) (err error) {
+	rs, isRowScanner := dest.(RowScanner)
+
 	//
 	// Validate the dest, and extract the reflection values we need.
 	//
-	valueOfDest := reflect.ValueOf(dest)
-	indirectOfDest := reflect.Indirect(valueOfDest)
-	kindOfDest := valueOfDest.Kind()
+	var indirectOfDest reflect.Value
+	var recordType reflect.Type
+	if !isRowScanner {
+		valueOfDest := reflect.ValueOf(dest)
+		indirectOfDest = reflect.Indirect(valueOfDest)
+		kindOfDest := valueOfDest.Kind()
+
+		if kindOfDest != reflect.Ptr || indirectOfDest.Kind() != reflect.Struct {
+			return errors.NewNotValidf("[dbr] you need to pass in the address of a struct")
+		}
 
-	if kindOfDest != reflect.Ptr || indirectOfDest.Kind() != reflect.Struct {
-		return errors.NewNotValidf("[dbr] you need to pass in the address of a struct")
+		recordType = indirectOfDest.Type()
 	}
 
-	recordType := indirectOfDest.Type()
-
 	//
 	// Get full SQL
 	//
@@ -202,8 +268,17 @@ func (b *Select) LoadStruct(dest interface{}) error {
 		defer log.WhenDone(b.Logger).Info("dbr.Select.LoadStruct.ExecContext.timing", log.String("sql", fullSql))
 	}
 
+	start := time.Now()
+	defer func() {
+		var n int64
+		if err == nil {
+			n = 1
+		}
+		emitEvent(ctx, b.Events, EventOpQuery, tSQL, tArg, start, n, err)
+	}()
+
 	// Run the query:
-	rows, err := b.Query(fullSql)
+	rows, err := querierContext(ctx, b.Querier).Query(fullSql)
 	if err != nil {
 		return errors.Wrap(err, "[dbr] Select.load_one.query")
 	}
@@ -215,6 +290,17 @@ func (b *Select) LoadStruct(dest interface{}) error {
 		return errors.Wrap(err, "[dbr] Select.load_one.rows.Columns")
 	}
 
+	if isRowScanner {
+		if rows.Next() {
+			_, err = scanRowScanner(rows, columns, rs, make([]interface{}, 0, len(columns)))
+			return errors.Wrap(err, "[dbr] Select.load_one.RowScanner")
+		}
+		if err := rows.Err(); err != nil {
+			return errors.Wrap(err, "[dbr] Select.load_one.rows_err")
+		}
+		return errors.NewNotFoundf("[dbr] Entry not found")
+	}
+
 	// Create a map of this result set to the struct columns
 	fieldMap, err := calculateFieldMap(recordType, columns, false)
 	if err != nil {
@@ -251,6 +337,11 @@ func (b *Select) LoadStruct(dest interface{}) error {
 // found, and it was therefore not set. Slow because of the massive use of
 // reflection.
 func (b *Select) LoadValues(dest interface{}) (int, error) {
+	return b.LoadValuesContext(context.Background(), dest)
+}
+
+// LoadValuesContext same as LoadValues but applies the context to the database call.
+func (b *Select) LoadValuesContext(ctx context.Context, dest interface{}) (_ int, err error) {
 	// Validate the dest and reflection values we need
 
 	// This must be a pointer to a slice
@@ -295,8 +386,13 @@ func (b *Select) LoadValues(dest interface{}) (int, error) {
 		defer log.WhenDone(b.Logger).Info("dbr.Select.LoadValues.QueryContext.timing", log.String("sql", fullSql))
 	}
 
+	start := time.Now()
+	defer func() {
+		emitEvent(ctx, b.Events, EventOpQuery, tSQL, tArg, start, int64(numberOfRowsReturned), err)
+	}()
+
 	// Run the query:
-	rows, err := b.Query(fullSql)
+	rows, err := querierContext(ctx, b.Querier).Query(fullSql)
 	if err != nil {
 		return numberOfRowsReturned, errors.Wrap(err, "[dbr] Select.LoadValues.query")
 	}
@@ -331,6 +427,11 @@ func (b *Select) LoadValues(dest interface{}) (int, error) {
 // primitive value Returns ErrNotFound if no value was found, and it was
 // therefore not set. Slow because of the massive use of reflection.
 func (b *Select) LoadValue(dest interface{}) error {
+	return b.LoadValueContext(context.Background(), dest)
+}
+
+// LoadValueContext same as LoadValue but applies the context to the database call.
+func (b *Select) LoadValueContext(ctx context.Context, dest interface{}) (err error) {
 	// Validate the dest
 	valueOfDest := reflect.ValueOf(dest)
 	kindOfDest := valueOfDest.Kind()
@@ -356,8 +457,17 @@ func (b *Select) LoadValue(dest interface{}) error {
 		defer log.WhenDone(b.Logger).Info("dbr.Select.LoadValue.QueryContext.timing", log.String("sql", fullSql))
 	}
 
+	start := time.Now()
+	defer func() {
+		var n int64
+		if err == nil {
+			n = 1
+		}
+		emitEvent(ctx, b.Events, EventOpQuery, tSQL, tArg, start, n, err)
+	}()
+
 	// Run the query:
-	rows, err := b.Query(fullSql)
+	rows, err := querierContext(ctx, b.Querier).Query(fullSql)
 	if err != nil {
 		return errors.Wrap(err, "[dbr] Select.LoadValue.Query")
 	}
@@ -1,8 +1,10 @@
 package dbr
 
 import (
+	"context"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/corestoreio/csfw/util/errors"
 	"github.com/stretchr/testify/assert"
 )
@@ -427,3 +429,106 @@ func TestSelect_AddAtomicHooks(t *testing.T) {
 	assert.Nil(t, args)
 	assert.Exactly(t, "SELECT a, b FROM `tableA` AS `tA` ORDER BY col2, col1 DESC", sql)
 }
+
+func TestSelectSubSelect(t *testing.T) {
+	s := createFakeSession()
+
+	sub := s.Select("entity_id").From("catalog_product_entity_int").Where(ConditionRaw("attribute_id = ?", 99))
+
+	t.Run("IN", func(t *testing.T) {
+		sql, args, err := s.Select("sku").From("catalog_product_entity").
+			Where(ConditionRaw("type_id = ?", "simple")).
+			Where(ConditionSubSelect("`entity_id` IN", sub)).
+			Where(ConditionRaw("has_options = ?", 1)).
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "SELECT sku FROM `catalog_product_entity` WHERE (type_id = ?) AND (`entity_id` IN (SELECT entity_id FROM `catalog_product_entity_int` WHERE (attribute_id = ?))) AND (has_options = ?)", sql)
+		assert.Exactly(t, []interface{}{"simple", 99, 1}, args)
+	})
+
+	t.Run("EXISTS", func(t *testing.T) {
+		sql, args, err := s.Select("sku").From("catalog_product_entity").
+			Where(ConditionExists(sub), ConditionNotExists(sub)).
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "SELECT sku FROM `catalog_product_entity` WHERE (EXISTS (SELECT entity_id FROM `catalog_product_entity_int` WHERE (attribute_id = ?))) AND (NOT EXISTS (SELECT entity_id FROM `catalog_product_entity_int` WHERE (attribute_id = ?)))", sql)
+		assert.Exactly(t, []interface{}{99, 99}, args)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, _, err := s.Select("sku").From("catalog_product_entity").
+			Where(ConditionExists(s.Select("a"))).
+			ToSQL()
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+	})
+}
+
+func TestSelectDerivedTable(t *testing.T) {
+	s := createFakeSession()
+
+	t.Run("From", func(t *testing.T) {
+		sql, args, err := s.Select("t.a").
+			FromSelect(s.Select("a").From("b").Where(ConditionRaw("c = ?", 1)), "t").
+			Where(ConditionRaw("t.a > ?", 2)).
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "SELECT t.a FROM (SELECT a FROM `b` WHERE (c = ?)) AS `t` WHERE (t.a > ?)", sql)
+		assert.Exactly(t, []interface{}{1, 2}, args)
+	})
+
+	t.Run("Join", func(t *testing.T) {
+		sql, args, err := s.Select("p.sku").From("catalog_product_entity", "p").
+			LeftJoinSelect(
+				s.Select("entity_id", "MIN(price) AS min_price").From("catalog_product_index_price").
+					Where(ConditionRaw("website_id = ?", 1)).GroupBy("entity_id"),
+				"pi",
+				JoinColumns("pi.min_price"),
+				ConditionRaw("pi.entity_id = p.entity_id"),
+			).
+			Where(ConditionRaw("p.type_id = ?", "simple")).
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "SELECT p.sku, pi.min_price FROM `catalog_product_entity` AS `p` LEFT JOIN (SELECT entity_id, MIN(price) AS min_price FROM `catalog_product_index_price` WHERE (website_id = ?) GROUP BY entity_id) AS `pi` ON (pi.entity_id = p.entity_id) WHERE (p.type_id = ?)", sql)
+		assert.Exactly(t, []interface{}{1, "simple"}, args)
+	})
+
+	t.Run("Alias missing", func(t *testing.T) {
+		_, _, err := s.Select("a").FromSelect(s.Select("a").From("b"), "").ToSQL()
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+	})
+}
+
+func TestSelect_Context(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	cxn, err := NewConnection(WithDB(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := cxn.NewSession()
+
+	dbMock.ExpectQuery("SELECT a FROM `b` WHERE \\(c = 1\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(11).AddRow(22))
+
+	var vals []int64
+	n, err := sess.Select("a").From("b").Where(ConditionRaw("c = ?", 1)).LoadValuesContext(context.Background(), &vals)
+	assert.NoError(t, err)
+	assert.Exactly(t, 2, n)
+	assert.Exactly(t, []int64{11, 22}, vals)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n, err = sess.Select("a").From("b").LoadValuesContext(ctx, &vals)
+	assert.Exactly(t, 0, n)
+	assert.Exactly(t, context.Canceled, errors.Cause(err), "%+v", err)
+
+	_, err = sess.Union(sess.Select("a").From("b")).RowsContext(ctx)
+	assert.Exactly(t, context.Canceled, errors.Cause(err), "%+v", err)
+
+	_, err = sess.InsertInto("b").Columns("a").Values(1).ExecContext(ctx)
+	assert.Exactly(t, context.Canceled, errors.Cause(err), "%+v", err)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
@@ -1,7 +1,9 @@
 package dbr
 
 import (
+	"context"
 	"database/sql"
+	"time"
 
 	"github.com/corestoreio/csfw/log"
 	"github.com/corestoreio/csfw/util/errors"
@@ -11,11 +13,30 @@ import (
 type Tx struct {
 	log.Logger
 	*sql.Tx
+	// Events gets notified after each database operation. Optional.
+	Events EventReceiver
+	// ctx has been used to start the transaction.
+	ctx context.Context
 }
 
 // Begin creates a transaction for the given session
 func (sess *Session) Begin() (*Tx, error) {
-	tx, err := sess.cxn.DB.Begin()
+	return sess.BeginTx(context.Background(), nil)
+}
+
+// BeginTx starts a transaction. The provided context is used until the
+// transaction is committed or rolled back. If the context is canceled, the
+// sql package will roll back the transaction. The TxOptions can be nil or
+// define the isolation level and the read-only mode:
+//		tx, err := sess.BeginTx(ctx, &sql.TxOptions{
+//			Isolation: sql.LevelRepeatableRead,
+//			ReadOnly:  true,
+//		})
+func (sess *Session) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	sess.pin()
+	start := time.Now()
+	tx, err := sess.cxn.DB.BeginTx(ctx, opts)
+	emitEvent(ctx, sess.Events, EventOpBegin, "", nil, start, -1, err)
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] transaction.begin.error")
 	}
@@ -23,17 +44,33 @@ func (sess *Session) Begin() (*Tx, error) {
 	return &Tx{
 		Logger: sess.Logger,
 		Tx:     tx,
+		Events: sess.Events,
+		ctx:    ctx,
 	}, nil
 }
 
+// context returns the context of BeginTx, if any.
+func (tx *Tx) context() context.Context {
+	if tx.ctx == nil {
+		return context.Background()
+	}
+	return tx.ctx
+}
+
 // Commit finishes the transaction
 func (tx *Tx) Commit() error {
-	return errors.Wrap(tx.Tx.Commit(), "[dbr] transaction.commit.error")
+	start := time.Now()
+	err := tx.Tx.Commit()
+	emitEvent(tx.context(), tx.Events, EventOpCommit, "", nil, start, -1, err)
+	return errors.Wrap(err, "[dbr] transaction.commit.error")
 }
 
 // Rollback cancels the transaction
 func (tx *Tx) Rollback() error {
-	return errors.Wrap(tx.Tx.Rollback(), "[dbr] transaction.rollback.error")
+	start := time.Now()
+	err := tx.Tx.Rollback()
+	emitEvent(tx.context(), tx.Events, EventOpRollback, "", nil, start, -1, err)
+	return errors.Wrap(err, "[dbr] transaction.rollback.error")
 }
 
 // RollbackUnlessCommitted rolls back the transaction unless it has already been
@@ -41,11 +78,13 @@ func (tx *Tx) Rollback() error {
 // you don't have to handle N failure cases Keep in mind the only way to detect
 // an error on the rollback is via the event log.
 func (tx *Tx) RollbackUnlessCommitted() {
+	start := time.Now()
 	err := tx.Tx.Rollback()
 	if err == sql.ErrTxDone {
-		// ok
-	} else if err != nil {
-		//tx.EventErr("dbr.rollback_unless_committed", err)
+		return // ok
+	}
+	emitEvent(tx.context(), tx.Events, EventOpRollback, "", nil, start, -1, err)
+	if err != nil {
 		panic(err) // todo remove panic
 	}
 }
@@ -1,9 +1,12 @@
 package dbr
 
 import (
-	// "database/sql"
+	"context"
+	"database/sql"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/util/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -52,3 +55,35 @@ func TestTransactionRollbackReal(t *testing.T) {
 	err = tx.Rollback()
 	assert.NoError(t, err)
 }
+
+func TestSession_BeginTx(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	cxn, err := NewConnection(WithDB(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := cxn.NewSession()
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("UPDATE `a` SET `b` = 1").WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	tx, err := sess.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tx.Update("a").Set("b", 1).ExecContext(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tx, err = sess.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	assert.Nil(t, tx)
+	assert.Exactly(t, context.Canceled, errors.Cause(err), "%+v", err)
+}
@@ -0,0 +1,253 @@
+package dbr
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/corestoreio/csfw/log"
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// Union represents a UNION SQL statement. With UNION you can combine the
+// results of several SELECT statements, for example to load the EAV
+// attribute values from the _int, _decimal and _varchar tables at once. Each
+// Select gets wrapped in parentheses and its arguments get merged in the
+// correct order.
+//		(SELECT ...) UNION ALL (SELECT ...) ORDER BY ... LIMIT ...
+type Union struct {
+	log.Logger // optional
+	// The next three fields depend on which method receiver you would like to
+	// execute. Leaving them empty results in a panic.
+	Querier
+	QueryRower
+	Preparer
+	// Events gets notified after each database operation. Optional.
+	Events EventReceiver
+
+	Selects     []*Select
+	IsAll       bool
+	OrderBys    []string
+	LimitCount  uint64
+	LimitValid  bool
+	OffsetCount uint64
+	OffsetValid bool
+}
+
+// NewUnion creates a new Union with a black hole logger.
+func NewUnion(selects ...*Select) *Union {
+	return &Union{
+		Logger:  log.BlackHole{},
+		Selects: selects,
+	}
+}
+
+// Union creates a new Union which combines the given Selects.
+func (sess *Session) Union(selects ...*Select) *Union {
+	db := sess.reader()
+	return &Union{
+		Logger:     sess.Logger,
+		Events:     sess.Events,
+		Querier:    db,
+		QueryRower: db,
+		Preparer:   db,
+		Selects:    selects,
+	}
+}
+
+// Union creates a new Union which combines the given Selects bound to the
+// transaction.
+func (tx *Tx) Union(selects ...*Select) *Union {
+	return &Union{
+		Logger:     tx.Logger,
+		Events:     tx.Events,
+		Querier:    tx.Tx,
+		QueryRower: tx.Tx,
+		Preparer:   tx.Tx,
+		Selects:    selects,
+	}
+}
+
+// Append adds more Selects to the Union.
+func (u *Union) Append(selects ...*Select) *Union {
+	u.Selects = append(u.Selects, selects...)
+	return u
+}
+
+// All returns all rows. The default behavior for UNION is that duplicate rows
+// are removed from the result.
+func (u *Union) All() *Union {
+	u.IsAll = true
+	return u
+}
+
+// OrderBy appends a column to ORDER the whole result set by.
+func (u *Union) OrderBy(ord string) *Union {
+	u.OrderBys = append(u.OrderBys, ord)
+	return u
+}
+
+// OrderDir appends a column to ORDER the whole result set by with a given
+// direction.
+func (u *Union) OrderDir(ord string, isAsc bool) *Union {
+	if isAsc {
+		u.OrderBys = append(u.OrderBys, ord+" ASC")
+	} else {
+		u.OrderBys = append(u.OrderBys, ord+" DESC")
+	}
+	return u
+}
+
+// Limit sets a limit for the whole result set; overrides any existing LIMIT
+func (u *Union) Limit(limit uint64) *Union {
+	u.LimitCount = limit
+	u.LimitValid = true
+	return u
+}
+
+// Offset sets an offset for the whole result set; overrides any existing
+// OFFSET
+func (u *Union) Offset(offset uint64) *Union {
+	u.OffsetCount = offset
+	u.OffsetValid = true
+	return u
+}
+
+// ToSQL serialized the Union to a SQL string. It returns the string with
+// placeholders and a slice of query arguments. The arguments of all Selects
+// get merged in the order of the Selects.
+func (u *Union) ToSQL() (string, []interface{}, error) {
+	if len(u.Selects) == 0 {
+		return "", nil, errors.NewEmptyf("[dbr] Union: Selects are empty")
+	}
+
+	var buf = bufferpool.Get()
+	defer bufferpool.Put(buf)
+
+	var args []interface{}
+
+	for i, s := range u.Selects {
+		if i > 0 {
+			if u.IsAll {
+				buf.WriteString(" UNION ALL ")
+			} else {
+				buf.WriteString(" UNION ")
+			}
+		}
+		sqlStr, selArgs, err := s.ToSQL()
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "[dbr] Union.ToSQL.Select Index %d", i)
+		}
+		buf.WriteRune('(')
+		buf.WriteString(sqlStr)
+		buf.WriteRune(')')
+		args = append(args, selArgs...)
+	}
+
+	if len(u.OrderBys) > 0 {
+		buf.WriteString(" ORDER BY ")
+		for i, s := range u.OrderBys {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(s)
+		}
+	}
+
+	if u.LimitValid {
+		buf.WriteString(" LIMIT ")
+		buf.WriteString(strconv.FormatUint(u.LimitCount, 10))
+	}
+
+	if u.OffsetValid {
+		buf.WriteString(" OFFSET ")
+		buf.WriteString(strconv.FormatUint(u.OffsetCount, 10))
+	}
+	return buf.String(), args, nil
+}
+
+// String returns a string representing a preprocessed, interpolated, query.
+// On error, the error gets printed. Fulfills interface fmt.Stringer.
+func (u *Union) String() string {
+	return makeSql(u)
+}
+
+// rawSelect converts the Union into a raw Select to reuse the load
+// functions.
+func (u *Union) rawSelect() (*Select, error) {
+	sqlStr, args, err := u.ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] Union.ToSQL")
+	}
+	return &Select{
+		Logger:       u.Logger,
+		Querier:      u.Querier,
+		QueryRower:   u.QueryRower,
+		Preparer:     u.Preparer,
+		Events:       u.Events,
+		RawFullSQL:   sqlStr,
+		RawArguments: args,
+	}, nil
+}
+
+// Rows executes the Union and returns many rows. Does no interpolation.
+func (u *Union) Rows() (*sql.Rows, error) {
+	return u.RowsContext(context.Background())
+}
+
+// RowsContext same as Rows but applies the context to the database call.
+func (u *Union) RowsContext(ctx context.Context) (*sql.Rows, error) {
+	s, err := u.rawSelect()
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] Union.Rows")
+	}
+	return s.RowsContext(ctx)
+}
+
+// LoadStructs executes the Union and loads the resulting data into a slice
+// of structs. See Select.LoadStructs.
+func (u *Union) LoadStructs(dest interface{}) (int, error) {
+	return u.LoadStructsContext(context.Background(), dest)
+}
+
+// LoadStructsContext same as LoadStructs but applies the context to the
+// database call.
+func (u *Union) LoadStructsContext(ctx context.Context, dest interface{}) (int, error) {
+	s, err := u.rawSelect()
+	if err != nil {
+		return 0, errors.Wrap(err, "[dbr] Union.LoadStructs")
+	}
+	return s.LoadStructsContext(ctx, dest)
+}
+
+// LoadValues executes the Union and loads the resulting data into a slice of
+// primitive values. See Select.LoadValues.
+func (u *Union) LoadValues(dest interface{}) (int, error) {
+	return u.LoadValuesContext(context.Background(), dest)
+}
+
+// LoadValuesContext same as LoadValues but applies the context to the
+// database call.
+func (u *Union) LoadValuesContext(ctx context.Context, dest interface{}) (int, error) {
+	s, err := u.rawSelect()
+	if err != nil {
+		return 0, errors.Wrap(err, "[dbr] Union.LoadValues")
+	}
+	return s.LoadValuesContext(ctx, dest)
+}
+
+// Prepare prepares the Union statement.
+func (u *Union) Prepare() (*sql.Stmt, error) {
+	return u.PrepareContext(context.Background())
+}
+
+// PrepareContext same as Prepare but applies the context to the database
+// call.
+func (u *Union) PrepareContext(ctx context.Context) (*sql.Stmt, error) {
+	s, err := u.rawSelect()
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] Union.Prepare")
+	}
+	return s.PrepareContext(ctx)
+}
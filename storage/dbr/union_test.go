@@ -0,0 +1,55 @@
+package dbr
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnion_ToSQL(t *testing.T) {
+	s := createFakeSession()
+
+	newSel := func(table string, attrID int) *Select {
+		return s.Select("entity_id", "value").From(table).Where(ConditionRaw("attribute_id = ?", attrID))
+	}
+
+	t.Run("UNION", func(t *testing.T) {
+		u := s.Union(newSel("catalog_product_entity_int", 1), newSel("catalog_product_entity_varchar", 2))
+		sql, args, err := u.ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "(SELECT entity_id, value FROM `catalog_product_entity_int` WHERE (attribute_id = ?)) UNION (SELECT entity_id, value FROM `catalog_product_entity_varchar` WHERE (attribute_id = ?))", sql)
+		assert.Exactly(t, []interface{}{1, 2}, args)
+	})
+
+	t.Run("UNION ALL with ORDER BY and LIMIT", func(t *testing.T) {
+		u := NewUnion(newSel("catalog_product_entity_int", 1)).
+			Append(newSel("catalog_product_entity_decimal", 3).OrderBy("value").Limit(5)).
+			All().
+			OrderDir("entity_id", false).
+			Limit(10).Offset(20)
+		sql, args, err := u.ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "(SELECT entity_id, value FROM `catalog_product_entity_int` WHERE (attribute_id = ?)) UNION ALL (SELECT entity_id, value FROM `catalog_product_entity_decimal` WHERE (attribute_id = ?) ORDER BY value LIMIT 5) ORDER BY entity_id DESC LIMIT 10 OFFSET 20", sql)
+		assert.Exactly(t, []interface{}{1, 3}, args)
+		assert.Exactly(t, "(SELECT entity_id, value FROM `catalog_product_entity_int` WHERE (attribute_id = 1)) UNION ALL (SELECT entity_id, value FROM `catalog_product_entity_decimal` WHERE (attribute_id = 3) ORDER BY value LIMIT 5) ORDER BY entity_id DESC LIMIT 10 OFFSET 20", u.String())
+	})
+
+	t.Run("as sub select", func(t *testing.T) {
+		u := NewUnion(newSel("catalog_product_entity_int", 1), newSel("catalog_product_entity_varchar", 2)).All()
+		sql, args, err := s.Select("u.entity_id").FromSelect(u, "u").
+			Where(ConditionSubSelect("u.entity_id IN", s.Select("entity_id").From("catalog_product_website").Where(ConditionRaw("website_id = ?", 4)))).
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "SELECT u.entity_id FROM ((SELECT entity_id, value FROM `catalog_product_entity_int` WHERE (attribute_id = ?)) UNION ALL (SELECT entity_id, value FROM `catalog_product_entity_varchar` WHERE (attribute_id = ?))) AS `u` WHERE (u.entity_id IN (SELECT entity_id FROM `catalog_product_website` WHERE (website_id = ?)))", sql)
+		assert.Exactly(t, []interface{}{1, 2, 4}, args)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, _, err := NewUnion().ToSQL()
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+
+		_, _, err = NewUnion(newSel("a", 1), s.Select("a")).ToSQL()
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+	})
+}
@@ -1,10 +1,12 @@
 package dbr
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 
 	"strconv"
+	"time"
 
 	"github.com/corestoreio/csfw/log"
 	"github.com/corestoreio/csfw/util/bufferpool"
@@ -26,6 +28,8 @@ type Update struct {
 	log.Logger
 	Execer
 	Preparer
+	// Events gets notified after each database operation. Optional.
+	Events EventReceiver
 
 	RawFullSQL   string
 	RawArguments []interface{}
@@ -47,22 +51,26 @@ type setClause struct {
 
 // Update creates a new Update for the given table
 func (sess *Session) Update(table ...string) *Update {
+	db := sess.writer()
 	return &Update{
 		Logger: sess.Logger,
-		Execer: sess.cxn.DB,
+		Events: sess.Events,
+		Execer: db,
 		Table:  MakeAlias(table...),
 	}
 }
 
 // UpdateBySql creates a new Update for the given SQL string and arguments
 func (sess *Session) UpdateBySql(sql string, args ...interface{}) *Update {
+	db := sess.writer()
 	if err := argsValuer(&args); err != nil {
 		//sess.EventErrKv("dbr.insertbuilder.values", err, kvs{"args": fmt.Sprint(args)})
 		panic(err) // todo remove panic
 	}
 	return &Update{
 		Logger:       sess.Logger,
-		Execer:       sess.cxn.DB,
+		Events:       sess.Events,
+		Execer:       db,
 		RawFullSQL:   sql,
 		RawArguments: args,
 	}
@@ -72,6 +80,7 @@ func (sess *Session) UpdateBySql(sql string, args ...interface{}) *Update {
 func (tx *Tx) Update(table ...string) *Update {
 	return &Update{
 		Logger: tx.Logger,
+		Events: tx.Events,
 		Execer: tx.Tx,
 		Table:  MakeAlias(table...),
 	}
@@ -85,6 +94,7 @@ func (tx *Tx) UpdateBySql(sql string, args ...interface{}) *Update {
 	}
 	return &Update{
 		Logger:       tx.Logger,
+		Events:       tx.Events,
 		Execer:       tx.Tx,
 		RawFullSQL:   sql,
 		RawArguments: args,
@@ -190,7 +200,9 @@ func (b *Update) ToSQL() (string, []interface{}, error) {
 	// Write WHERE clause if we have any fragments
 	if len(b.WhereFragments) > 0 {
 		buf.WriteString(" WHERE ")
-		writeWhereFragmentsToSql(b.WhereFragments, buf, &args)
+		if err := writeWhereFragmentsToSql(b.WhereFragments, buf, &args); err != nil {
+			return "", nil, errors.Wrap(err, "[dbr] Update.ToSQL.writeWhereFragmentsToSql")
+		}
 	}
 
 	// Ordering and limiting
@@ -220,6 +232,11 @@ func (b *Update) ToSQL() (string, []interface{}, error) {
 // Exec executes the statement represented by the Update
 // It returns the raw database/sql Result and an error if there was one
 func (b *Update) Exec() (sql.Result, error) {
+	return b.ExecContext(context.Background())
+}
+
+// ExecContext same as Exec but applies the context to the database call.
+func (b *Update) ExecContext(ctx context.Context) (sql.Result, error) {
 	rawSQL, args, err := b.ToSQL()
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Update.Exec.ToSQL")
@@ -234,7 +251,9 @@ func (b *Update) Exec() (sql.Result, error) {
 		defer log.WhenDone(b.Logger).Info("dbr.Update.Exec.Timing", log.String("sql", fullSql))
 	}
 
-	result, err := b.Execer.Exec(fullSql)
+	start := time.Now()
+	result, err := execerContext(ctx, b.Execer).Exec(fullSql)
+	emitEvent(ctx, b.Events, EventOpExec, rawSQL, args, start, resultRows(result), err)
 	if err != nil {
 		return result, errors.Wrap(err, "[dbr] Update.Exec.Exec")
 	}
@@ -245,6 +264,11 @@ func (b *Update) Exec() (sql.Result, error) {
 // Exec executes the statement represented by the Update
 // It returns the raw database/sql Result and an error if there was one
 func (b *Update) Prepare() (*sql.Stmt, error) {
+	return b.PrepareContext(context.Background())
+}
+
+// PrepareContext same as Prepare but applies the context to the database call.
+func (b *Update) PrepareContext(ctx context.Context) (*sql.Stmt, error) {
 	rawSQL, _, err := b.ToSQL() // TODO create a ToSQL version without any arguments
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Update.Prepare.ToSQL")
@@ -254,6 +278,8 @@ func (b *Update) Prepare() (*sql.Stmt, error) {
 		defer log.WhenDone(b.Logger).Info("dbr.Update.Prepare.Timing", log.String("sql", rawSQL))
 	}
 
-	stmt, err := b.Preparer.Prepare(rawSQL)
+	start := time.Now()
+	stmt, err := preparerContext(ctx, b.Preparer).Prepare(rawSQL)
+	emitEvent(ctx, b.Events, EventOpPrepare, rawSQL, nil, start, -1, err)
 	return stmt, errors.Wrap(err, "[dbr] Update.Prepare.Prepare")
 }
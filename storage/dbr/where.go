@@ -2,6 +2,8 @@ package dbr
 
 import (
 	"reflect"
+
+	"github.com/corestoreio/csfw/util/errors"
 )
 
 // todo for maybe later the sync.Pool code
@@ -32,6 +34,9 @@ type whereFragment struct {
 	Condition   string
 	Values      []interface{}
 	EqualityMap map[string]interface{}
+	// Sub contains a subquery which gets appended in parentheses to the
+	// Condition.
+	Sub QueryBuilder
 }
 
 // WhereFragments provides a list where clauses
@@ -58,6 +63,28 @@ func ConditionMap(eq Eq) ConditionArg {
 	}
 }
 
+// ConditionSubSelect adds a condition with a subquery. The subquery gets
+// appended in parentheses to the raw condition and its arguments get merged
+// into the arguments of the outer query. The subquery can be a *Select or a
+// *Union.
+//		ConditionSubSelect("`entity_id` IN", sub) => (`entity_id` IN (SELECT ...))
+func ConditionSubSelect(raw string, sub QueryBuilder) ConditionArg {
+	return func(wf *whereFragment) {
+		wf.Condition = raw
+		wf.Sub = sub
+	}
+}
+
+// ConditionExists adds an EXISTS (SELECT ...) condition.
+func ConditionExists(sub QueryBuilder) ConditionArg {
+	return ConditionSubSelect("EXISTS", sub)
+}
+
+// ConditionNotExists adds a NOT EXISTS (SELECT ...) condition.
+func ConditionNotExists(sub QueryBuilder) ConditionArg {
+	return ConditionSubSelect("NOT EXISTS", sub)
+}
+
 func newWhereFragments(wargs ...ConditionArg) WhereFragments {
 	ret := make(WhereFragments, len(wargs))
 	for i, warg := range wargs {
@@ -68,7 +95,7 @@ func newWhereFragments(wargs ...ConditionArg) WhereFragments {
 }
 
 // Invariant: only called when len(fragments) > 0
-func writeWhereFragmentsToSql(fragments WhereFragments, sql QueryWriter, args *[]interface{}) {
+func writeWhereFragmentsToSql(fragments WhereFragments, sql QueryWriter, args *[]interface{}) error {
 	anyConditions := false
 	for _, f := range fragments {
 		if f.Condition != "" {
@@ -79,6 +106,16 @@ func writeWhereFragmentsToSql(fragments WhereFragments, sql QueryWriter, args *[
 				anyConditions = true
 			}
 			_, _ = sql.WriteString(f.Condition)
+			if f.Sub != nil {
+				subSQL, subArgs, err := f.Sub.ToSQL()
+				if err != nil {
+					return errors.Wrapf(err, "[dbr] writeWhereFragmentsToSql.Sub.ToSQL Condition %q", f.Condition)
+				}
+				_, _ = sql.WriteString(" (")
+				_, _ = sql.WriteString(subSQL)
+				_, _ = sql.WriteRune(')')
+				*args = append(*args, subArgs...)
+			}
 			_, _ = sql.WriteRune(')')
 			if len(f.Values) > 0 {
 				*args = append(*args, f.Values...)
@@ -87,6 +124,7 @@ func writeWhereFragmentsToSql(fragments WhereFragments, sql QueryWriter, args *[
 			anyConditions = writeEqualityMapToSql(f.EqualityMap, sql, args, anyConditions)
 		}
 	}
+	return nil
 }
 
 func writeEqualityMapToSql(eq map[string]interface{}, sql QueryWriter, args *[]interface{}, anyConditions bool) bool {
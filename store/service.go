@@ -63,6 +63,12 @@ type Service struct {
 	// value is optional.
 	BackendSingleStore cfgmodel.Bool
 
+	// Publisher optional, gets notified with the route RouteChanged bound to
+	// the website or store scope whenever a website, group or store has been
+	// created, changed or deleted. Mostly the Publisher is the config.Service
+	// with an enabled pub/sub, so that scoped services can clear their caches.
+	Publisher config.Publisher
+
 	// backend communicates with the database in rw mode and creates
 	// new store, group and website pointers. If nil, panics.
 	backend *factory
@@ -70,6 +76,9 @@ type Service struct {
 	// package.
 	defaultStoreID int64
 
+	// applyMu serializes the calls to ApplyChanges and the functions which
+	// create, update or delete websites, groups and stores.
+	applyMu sync.Mutex
 	// mu protects the following fields
 	mu sync.RWMutex
 	// in general these caches can be optimized
@@ -173,6 +182,8 @@ func (s *Service) loadFromOptions(cfg config.Getter, opts ...Option) error {
 // its default group and its default stores.
 func (s *Service) IsAllowedStoreID(runMode scope.TypeID, storeID int64) (isAllowed bool, storeCode string, _ error) {
 	scp, scpID := runMode.Unpack()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
 	switch scp {
 	case scope.Store:
@@ -253,7 +264,7 @@ func (s *Service) DefaultStoreID(runMode scope.TypeID) (storeID, websiteID int64
 		}
 	} else {
 		var err error
-		w, err = s.websites.Default()
+		w, err = s.Websites().Default()
 		if err != nil {
 			return 0, 0, errors.Wrapf(err, "[store] DefaultStoreID.Website.Default Scope %s ID %d", scp, id)
 		}
@@ -324,6 +335,8 @@ func (s *Service) StoreIDbyCode(runMode scope.TypeID, storeCode string) (storeID
 // callee.
 func (s *Service) AllowedStores(runMode scope.TypeID) (StoreSlice, error) {
 	scp, scpID := runMode.Unpack()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
 	switch scp {
 	case scope.Store:
@@ -462,15 +475,17 @@ func (s *Service) Stores() StoreSlice {
 
 // DefaultStoreView returns the overall default store view.
 func (s *Service) DefaultStoreView() (Store, error) {
-	if s.defaultStoreID >= 0 {
-		s.mu.RLock()
-		defer s.mu.RUnlock() // bug
-		if cs, ok := s.cacheStore[atomic.LoadInt64(&s.defaultStoreID)]; ok {
+	if id := atomic.LoadInt64(&s.defaultStoreID); id >= 0 {
+		if cs, err := s.Store(id); err == nil {
 			return cs, nil
 		}
 	}
 
-	id, err := s.backend.DefaultStoreID()
+	be, err := s.currentBackend()
+	if err != nil {
+		return Store{}, errors.Wrap(err, "[store] Service.DefaultStoreView.currentBackend")
+	}
+	id, err := be.DefaultStoreID()
 	if err != nil {
 		return Store{}, errors.Wrap(err, "[store] Service.storage.DefaultStoreView")
 	}
@@ -482,17 +497,21 @@ func (s *Service) DefaultStoreView() (Store, error) {
 // After reloading internal cache will be cleared if there are no errors.
 func (s *Service) LoadFromDB(dbrSess dbr.SessionRunner, cbs ...dbr.SelectCb) error {
 
-	if err := s.backend.LoadFromDB(dbrSess, cbs...); err != nil {
+	be, err := s.currentBackend()
+	if err != nil {
+		return errors.Wrap(err, "[store] LoadFromDB.currentBackend")
+	}
+	if err := be.LoadFromDB(dbrSess, cbs...); err != nil {
 		return errors.Wrap(err, "[store] LoadFromDB.Backend")
 	}
 
 	s.ClearCache()
 
-	err := s.loadFromOptions(
-		s.backend.rootConfig,
-		WithTableWebsites(s.backend.websites...),
-		WithTableGroups(s.backend.groups...),
-		WithTableStores(s.backend.stores...),
+	err = s.loadFromOptions(
+		be.rootConfig,
+		WithTableWebsites(be.websites...),
+		WithTableGroups(be.groups...),
+		WithTableStores(be.stores...),
 	)
 	return errors.Wrap(err, "[store] LoadFromDB.ApplyStorage")
 }
@@ -519,7 +538,7 @@ func (s *Service) ClearCache() {
 		}
 	}
 	s.cacheSingleStore = make(map[scope.TypeID]bool)
-	s.defaultStoreID = -1
+	atomic.StoreInt64(&s.defaultStoreID, -1)
 	s.websites = nil
 	s.groups = nil
 	s.stores = nil
@@ -527,6 +546,8 @@ func (s *Service) ClearCache() {
 
 // IsCacheEmpty returns true if the internal cache is empty.
 func (s *Service) IsCacheEmpty() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return len(s.cacheWebsite) == 0 && len(s.cacheGroup) == 0 && len(s.cacheStore) == 0 &&
-		s.defaultStoreID == -1
+		atomic.LoadInt64(&s.defaultStoreID) == -1
 }
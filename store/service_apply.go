@@ -0,0 +1,204 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"sync/atomic"
+
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// Changes contains the changed rows of the database tables store_website,
+// store_group and store. Rows get inserted or, if the ID already exists,
+// updated. Deleting a website also deletes its groups and stores and deleting
+// a group also deletes its stores. See function Service.ApplyChanges.
+type Changes struct {
+	Websites TableWebsiteSlice
+	Groups   TableGroupSlice
+	Stores   TableStoreSlice
+
+	DeletedWebsiteIDs []int64
+	DeletedGroupIDs   []int64
+	DeletedStoreIDs   []int64
+}
+
+// IsEmpty returns true if there are no changes.
+func (c Changes) IsEmpty() bool {
+	return len(c.Websites) == 0 && len(c.Groups) == 0 && len(c.Stores) == 0 &&
+		len(c.DeletedWebsiteIDs) == 0 && len(c.DeletedGroupIDs) == 0 && len(c.DeletedStoreIDs) == 0
+}
+
+// ApplyChanges inserts, updates and deletes websites, groups and stores in the
+// internal caches without querying the database. The new caches get created
+// from the current table data and the changes and replace the old caches in
+// one step, so concurrent readers see either the old or the new data. If the
+// changed data breaks the integrity, for example a store references a deleted
+// group, an error gets returned and the old data stays active. The field
+// Publisher gets notified about the changed scopes.
+func (s *Service) ApplyChanges(c Changes) error {
+	if c.IsEmpty() {
+		return nil
+	}
+	s.applyMu.Lock() // serializes concurrent changes
+	defer s.applyMu.Unlock()
+
+	be, err := s.currentBackend()
+	if err != nil {
+		return errors.Wrap(err, "[store] ApplyChanges")
+	}
+	ns, err := newServiceWithChanges(be, c)
+	if err != nil {
+		return errors.Wrap(err, "[store] ApplyChanges")
+	}
+	s.replaceBackend(ns)
+	s.publishChanges(be, c)
+	return nil
+}
+
+// currentBackend returns the currently active factory.
+func (s *Service) currentBackend() (*factory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.backend == nil {
+		return nil, errors.NewNotValidf("[store] Service not initialized")
+	}
+	return s.backend, nil
+}
+
+// newServiceWithChanges creates a new Service from the table data of the
+// factory and the changes. The factory does not get modified.
+func newServiceWithChanges(be *factory, c Changes) (*Service, error) {
+	be.mu.RLock()
+	ws := mergeWebsites(be.websites, c.Websites, c.DeletedWebsiteIDs)
+	gs := mergeGroups(be.groups, c.Groups, c.DeletedGroupIDs)
+	ss := mergeStores(be.stores, c.Stores, c.DeletedStoreIDs)
+	be.mu.RUnlock()
+	gs, ss = cascadeDeletes(gs, ss, c)
+
+	ns := newService()
+	if err := ns.loadFromOptions(be.rootConfig, WithTableWebsites(ws...), WithTableGroups(gs...), WithTableStores(ss...)); err != nil {
+		return nil, errors.Wrap(err, "[store] newServiceWithChanges.loadFromOptions")
+	}
+	return ns, nil
+}
+
+// replaceBackend swaps the factory and the caches with the ones from ns.
+func (s *Service) replaceBackend(ns *Service) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backend = ns.backend
+	s.websites, s.groups, s.stores = ns.websites, ns.groups, ns.stores
+	s.cacheWebsite, s.cacheGroup, s.cacheStore = ns.cacheWebsite, ns.cacheGroup, ns.cacheStore
+	s.cacheSingleStore = make(map[scope.TypeID]bool)
+	atomic.StoreInt64(&s.defaultStoreID, -1)
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// cascadeDeletes removes the groups of the deleted websites and the stores of
+// the deleted websites and groups. InnoDB deletes those rows via ON DELETE
+// CASCADE and the row based binary log does not contain them. gs and ss get
+// filtered in place.
+func cascadeDeletes(gs TableGroupSlice, ss TableStoreSlice, c Changes) (TableGroupSlice, TableStoreSlice) {
+	if len(c.DeletedWebsiteIDs) == 0 && len(c.DeletedGroupIDs) == 0 {
+		return gs, ss
+	}
+	groupIDs := append([]int64(nil), c.DeletedGroupIDs...)
+	retG := gs[:0]
+	for _, g := range gs {
+		if containsID(c.DeletedWebsiteIDs, g.WebsiteID) {
+			groupIDs = append(groupIDs, g.GroupID)
+			continue
+		}
+		retG = append(retG, g)
+	}
+	retS := ss[:0]
+	for _, st := range ss {
+		if containsID(c.DeletedWebsiteIDs, st.WebsiteID) || containsID(groupIDs, st.GroupID) {
+			continue
+		}
+		retS = append(retS, st)
+	}
+	return retG, retS
+}
+
+// mergeWebsites returns a new slice with the updated, appended and without
+// the deleted websites. The old slice does not get modified.
+func mergeWebsites(old, changed TableWebsiteSlice, deleted []int64) TableWebsiteSlice {
+	ret := make(TableWebsiteSlice, 0, len(old)+len(changed))
+	for _, w := range old {
+		if containsID(deleted, w.WebsiteID) {
+			continue
+		}
+		if cw, ok := changed.FindByWebsiteID(w.WebsiteID); ok {
+			w = cw
+		}
+		ret = append(ret, w)
+	}
+	for _, cw := range changed {
+		if _, ok := old.FindByWebsiteID(cw.WebsiteID); !ok && !containsID(deleted, cw.WebsiteID) {
+			ret = append(ret, cw)
+		}
+	}
+	return ret
+}
+
+// mergeGroups same as mergeWebsites but for groups.
+func mergeGroups(old, changed TableGroupSlice, deleted []int64) TableGroupSlice {
+	ret := make(TableGroupSlice, 0, len(old)+len(changed))
+	for _, g := range old {
+		if containsID(deleted, g.GroupID) {
+			continue
+		}
+		if cg, ok := changed.FindByGroupID(g.GroupID); ok {
+			g = cg
+		}
+		ret = append(ret, g)
+	}
+	for _, cg := range changed {
+		if _, ok := old.FindByGroupID(cg.GroupID); !ok && !containsID(deleted, cg.GroupID) {
+			ret = append(ret, cg)
+		}
+	}
+	return ret
+}
+
+// mergeStores same as mergeWebsites but for stores.
+func mergeStores(old, changed TableStoreSlice, deleted []int64) TableStoreSlice {
+	ret := make(TableStoreSlice, 0, len(old)+len(changed))
+	for _, st := range old {
+		if containsID(deleted, st.StoreID) {
+			continue
+		}
+		if cs, ok := changed.FindByStoreID(st.StoreID); ok {
+			st = cs
+		}
+		ret = append(ret, st)
+	}
+	for _, cs := range changed {
+		if _, ok := old.FindByStoreID(cs.StoreID); !ok && !containsID(deleted, cs.StoreID) {
+			ret = append(ret, cs)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,162 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/corestoreio/csfw/util/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_ApplyChanges(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+
+	assert.NoError(t, srv.ApplyChanges(store.Changes{}))
+
+	err := srv.ApplyChanges(store.Changes{
+		Stores: store.TableStoreSlice{
+			// rename
+			&store.TableStore{StoreID: 2, Code: null.StringFrom("at"), WebsiteID: 1, GroupID: 1, Name: "Austria", SortOrder: 20, IsActive: true},
+			// disable
+			&store.TableStore{StoreID: 4, Code: null.StringFrom("uk"), WebsiteID: 1, GroupID: 2, Name: "UK", SortOrder: 10, IsActive: false},
+			// insert
+			&store.TableStore{StoreID: 7, Code: null.StringFrom("fr"), WebsiteID: 1, GroupID: 1, Name: "France", SortOrder: 40, IsActive: true},
+		},
+		DeletedStoreIDs: []int64{6},
+	})
+	assert.NoError(t, err)
+
+	st, err := srv.Store(2)
+	assert.NoError(t, err)
+	assert.Exactly(t, "Austria", st.Name())
+
+	st, err = srv.Store(4)
+	assert.NoError(t, err)
+	assert.False(t, st.IsActive())
+	isAllowed, _, err := srv.IsAllowedStoreID(scope.Website.Pack(1), 4)
+	assert.NoError(t, err)
+	assert.False(t, isAllowed)
+
+	st, err = srv.Store(7)
+	assert.NoError(t, err)
+	assert.Exactly(t, "fr", st.Code())
+	id, _, err := srv.StoreIDbyCode(scope.Store.Pack(1), "fr")
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(7), id)
+
+	_, err = srv.Store(6)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	assert.Exactly(t, []int64{0, 5, 1, 4, 2, 3, 7}, srv.Stores().IDs())
+
+	// the group contains the new store
+	g, err := srv.Group(1)
+	assert.NoError(t, err)
+	assert.Exactly(t, []int64{1, 2, 3, 7}, g.Stores.IDs())
+
+	// update a website and a group
+	err = srv.ApplyChanges(store.Changes{
+		Websites: store.TableWebsiteSlice{
+			&store.TableWebsite{WebsiteID: 2, Code: null.StringFrom("oz"), Name: null.StringFrom("Oceania"), SortOrder: 20, DefaultGroupID: 3, IsDefault: null.BoolFrom(false)},
+		},
+		Groups: store.TableGroupSlice{
+			&store.TableGroup{GroupID: 3, WebsiteID: 2, Name: "Down Under", RootCategoryID: 2, DefaultStoreID: 5},
+		},
+	})
+	assert.NoError(t, err)
+	w, err := srv.Website(2)
+	assert.NoError(t, err)
+	assert.Exactly(t, "Oceania", w.Name())
+	g, err = srv.Group(3)
+	assert.NoError(t, err)
+	assert.Exactly(t, "Down Under", g.Name())
+	st, err = srv.Store(5)
+	assert.NoError(t, err)
+	assert.Exactly(t, "Down Under", st.Group.Name())
+}
+
+func TestService_ApplyChanges_Integrity(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+
+	// the store references a non existing group
+	err := srv.ApplyChanges(store.Changes{
+		Stores: store.TableStoreSlice{
+			&store.TableStore{StoreID: 2, Code: null.StringFrom("at"), WebsiteID: 1, GroupID: 99, Name: "Austria", IsActive: true},
+		},
+	})
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+
+	// a second default website
+	err = srv.ApplyChanges(store.Changes{
+		Websites: store.TableWebsiteSlice{
+			&store.TableWebsite{WebsiteID: 2, Code: null.StringFrom("oz"), DefaultGroupID: 3, IsDefault: null.BoolFrom(true)},
+		},
+	})
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+
+	// old data stays active
+	st, err := srv.Store(2)
+	assert.NoError(t, err)
+	assert.Exactly(t, "Österreich", st.Name())
+	assert.Exactly(t, int64(1), st.GroupID())
+	w, err := srv.Website(2)
+	assert.NoError(t, err)
+	assert.Exactly(t, "OZ", w.Name())
+}
+
+func TestService_ApplyChanges_Concurrent(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			err := srv.ApplyChanges(store.Changes{
+				Stores: store.TableStoreSlice{
+					&store.TableStore{StoreID: 1, Code: null.StringFrom("de"), WebsiteID: 1, GroupID: 1, Name: "Germany", SortOrder: 10, IsActive: i%2 == 0},
+				},
+			})
+			assert.NoError(t, err)
+		}
+	}()
+
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				_, _, err := srv.IsAllowedStoreID(scope.Website.Pack(1), 1)
+				assert.NoError(t, err)
+				_, _, err = srv.IsAllowedStoreID(0, 1)
+				assert.NoError(t, err)
+				_, _, err = srv.DefaultStoreID(0)
+				assert.NoError(t, err)
+				_, err = srv.AllowedStores(scope.Group.Pack(1))
+				assert.NoError(t, err)
+				_, err = srv.DefaultStoreView()
+				assert.NoError(t, err)
+				srv.HasSingleStore()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,693 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/corestoreio/csfw/util/null"
+)
+
+// RouteChanged gets published to the Service.Publisher after a website, group
+// or store has been changed. The path gets bound to the website scope for
+// websites and groups and to the store scope for stores.
+var RouteChanged = cfgpath.NewRoute("store/service/changed")
+
+// TxBeginner starts a new database transaction. Implemented by *dbr.Session.
+type TxBeginner interface {
+	Begin() (*dbr.Tx, error)
+}
+
+// tableData contains a snapshot of the raw table data of a factory.
+type tableData struct {
+	websites TableWebsiteSlice
+	groups   TableGroupSlice
+	stores   TableStoreSlice
+}
+
+func (td tableData) isWebsiteCodeUsed(code string, exceptID int64) bool {
+	w, ok := td.websites.FindByCode(code)
+	return ok && w.WebsiteID != exceptID
+}
+
+func (td tableData) isStoreCodeUsed(code string, exceptID int64) bool {
+	st, ok := td.stores.FindByCode(code)
+	return ok && st.StoreID != exceptID
+}
+
+func (td tableData) website(id int64) (*TableWebsite, error) {
+	if w, ok := td.websites.FindByWebsiteID(id); ok {
+		return w, nil
+	}
+	return nil, errors.NewNotFoundf("[store] Website %d not found", id)
+}
+
+func (td tableData) group(id int64) (*TableGroup, error) {
+	if g, ok := td.groups.FindByGroupID(id); ok {
+		return g, nil
+	}
+	return nil, errors.NewNotFoundf("[store] Group %d not found", id)
+}
+
+func (td tableData) store(id int64) (*TableStore, error) {
+	if st, ok := td.stores.FindByStoreID(id); ok {
+		return st, nil
+	}
+	return nil, errors.NewNotFoundf("[store] Store %d not found", id)
+}
+
+// tableName returns the name of the table from the TableCollection.
+func tableName(idx int) (string, error) {
+	if TableCollection == nil {
+		return "", errors.NewNotFoundf("[store] TableCollection not initialized")
+	}
+	n := TableCollection.Name(idx)
+	if n == "" {
+		return "", errors.NewNotFoundf("[store] Table index %d not found in TableCollection", idx)
+	}
+	return n, nil
+}
+
+// modify runs the function fn within a database transaction. fn executes the
+// SQL statements and returns the changes. The changes get checked against the
+// current data before the transaction gets committed. After the commit the
+// internal caches get replaced and the Publisher notified.
+func (s *Service) modify(db TxBeginner, fn func(*dbr.Tx, tableData) (Changes, error)) error {
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+
+	be, err := s.currentBackend()
+	if err != nil {
+		return errors.Wrap(err, "[store] modify")
+	}
+	be.mu.RLock()
+	td := tableData{
+		websites: be.websites,
+		groups:   be.groups,
+		stores:   be.stores,
+	}
+	be.mu.RUnlock()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "[store] modify.Begin")
+	}
+	c, err := fn(tx, td)
+	if err != nil {
+		return errors.Wrap(rollback(tx, err), "[store] modify")
+	}
+	ns, err := newServiceWithChanges(be, c)
+	if err != nil {
+		return errors.Wrap(rollback(tx, err), "[store] modify.newServiceWithChanges")
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "[store] modify.Commit")
+	}
+	s.replaceBackend(ns)
+	s.publishChanges(be, c)
+	return nil
+}
+
+// rollback rolls the transaction back and returns the original error.
+func rollback(tx *dbr.Tx, err error) error {
+	if rbErr := tx.Rollback(); rbErr != nil {
+		return errors.Wrapf(err, "[store] Rollback failed: %s", rbErr)
+	}
+	return err
+}
+
+// publishChanges sends the scopes of the changes to the Publisher. The old
+// factory provides the website IDs of the deleted groups.
+func (s *Service) publishChanges(old *factory, c Changes) {
+	if s.Publisher == nil {
+		return
+	}
+	var ids []scope.TypeID
+	add := func(id scope.TypeID) {
+		for _, i := range ids {
+			if i == id {
+				return
+			}
+		}
+		ids = append(ids, id)
+	}
+	for _, w := range c.Websites {
+		add(scope.MakeTypeID(scope.Website, w.WebsiteID))
+	}
+	for _, id := range c.DeletedWebsiteIDs {
+		add(scope.MakeTypeID(scope.Website, id))
+	}
+	for _, g := range c.Groups {
+		add(scope.MakeTypeID(scope.Website, g.WebsiteID))
+	}
+	for _, id := range c.DeletedGroupIDs {
+		if g, ok := old.group(id); ok {
+			add(scope.MakeTypeID(scope.Website, g.WebsiteID))
+		}
+	}
+	for _, st := range c.Stores {
+		add(scope.MakeTypeID(scope.Store, st.StoreID))
+	}
+	for _, id := range c.DeletedStoreIDs {
+		add(scope.MakeTypeID(scope.Store, id))
+	}
+	for _, id := range ids {
+		s.Publisher.Publish(cfgpath.Path{Route: RouteChanged, ScopeID: id})
+	}
+}
+
+// insertID executes the insert statement and returns the ID of the new row.
+// If id is greater than zero, the ID column gets written and id returned.
+func insertID(ins *dbr.Insert, idCol string, id int64) (int64, error) {
+	if id > 0 {
+		ins.Cols = append([]string{idCol}, ins.Cols...)
+		for i := range ins.Vals {
+			ins.Vals[i] = append([]interface{}{id}, ins.Vals[i]...)
+		}
+	}
+	res, err := ins.Exec()
+	if err != nil {
+		return 0, errors.Wrap(err, "[store] Insert.Exec")
+	}
+	if id > 0 {
+		return id, nil
+	}
+	id, err = res.LastInsertId()
+	if err != nil {
+		return 0, errors.NewFatalf("[store] LastInsertId: %s", err)
+	}
+	return id, nil
+}
+
+// unsetDefaultWebsites resets the is_default flag of all other websites.
+func unsetDefaultWebsites(tx *dbr.Tx, td tableData, tbl string, exceptID int64, c *Changes) error {
+	for _, w := range td.websites {
+		if w.WebsiteID == exceptID || !w.IsDefault.Bool {
+			continue
+		}
+		if _, err := tx.Update(tbl).Set("is_default", 0).Where(dbr.ConditionRaw("website_id = ?", w.WebsiteID)).Exec(); err != nil {
+			return errors.Wrapf(err, "[store] Update Website %d", w.WebsiteID)
+		}
+		nw := *w
+		nw.IsDefault.Bool = false
+		c.Websites = append(c.Websites, &nw)
+	}
+	return nil
+}
+
+// CreateWebsite inserts a new website. A WebsiteID of zero lets the database
+// assign the ID, which gets written back into tw after the commit. The code
+// must be valid and unique. The DefaultGroupID must be zero because the first
+// group created for this website becomes the default group. A new website
+// cannot be the default website because it has no default store. Error
+// behaviour: NotValid, AlreadyExists, NotFound or Fatal.
+func (s *Service) CreateWebsite(db TxBeginner, tw *TableWebsite) error {
+	// rec gets copied back into tw after the commit
+	rec := *tw
+	err := s.modify(db, func(tx *dbr.Tx, td tableData) (c Changes, err error) {
+		if err = CodeIsValid(rec.Code.String); err != nil {
+			return c, err
+		}
+		if td.isWebsiteCodeUsed(rec.Code.String, -1) {
+			return c, errors.NewAlreadyExistsf("[store] Website code %q already exists", rec.Code.String)
+		}
+		if _, ok := td.websites.FindByWebsiteID(rec.WebsiteID); ok && rec.WebsiteID > 0 {
+			return c, errors.NewAlreadyExistsf("[store] Website %d already exists", rec.WebsiteID)
+		}
+		if rec.DefaultGroupID != 0 {
+			return c, errors.NewNotValidf("[store] A new Website cannot have a DefaultGroupID %d", rec.DefaultGroupID)
+		}
+		if rec.IsDefault.Bool {
+			return c, errors.NewNotValidf("[store] A new Website cannot be the default Website")
+		}
+		tbl, err := tableName(TableIndexWebsite)
+		if err != nil {
+			return c, err
+		}
+		ins := tx.InsertInto(tbl).Columns("code", "name", "sort_order", "default_group_id", "is_default").
+			Values(rec.Code, rec.Name, rec.SortOrder, rec.DefaultGroupID, false)
+		id, err := insertID(ins, "website_id", rec.WebsiteID)
+		if err != nil {
+			return c, err
+		}
+		rec.WebsiteID = id
+		rec.IsDefault = null.BoolFrom(false)
+		// the cache must not share the record with the caller
+		cp := rec
+		c.Websites = append(c.Websites, &cp)
+		return c, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "[store] CreateWebsite")
+	}
+	*tw = rec
+	return nil
+}
+
+// UpdateWebsite changes an existing website. The admin website 0 cannot be
+// changed. The code must be valid and unique. If the website has groups, the
+// DefaultGroupID must point to one of them. Setting IsDefault removes the flag
+// from all other websites and requires a default group with a default store.
+// Removing the flag from the default website is not possible. Error
+// behaviour: NotValid, AlreadyExists, NotFound or Fatal.
+func (s *Service) UpdateWebsite(db TxBeginner, tw *TableWebsite) error {
+	return errors.Wrap(s.modify(db, func(tx *dbr.Tx, td tableData) (c Changes, err error) {
+		if tw.WebsiteID == 0 {
+			return c, errors.NewNotValidf("[store] The admin Website cannot be changed")
+		}
+		old, err := td.website(tw.WebsiteID)
+		if err != nil {
+			return c, err
+		}
+		if err = CodeIsValid(tw.Code.String); err != nil {
+			return c, err
+		}
+		if td.isWebsiteCodeUsed(tw.Code.String, tw.WebsiteID) {
+			return c, errors.NewAlreadyExistsf("[store] Website code %q already exists", tw.Code.String)
+		}
+		if gs := td.groups.Filter(func(g *TableGroup) bool { return g.WebsiteID == tw.WebsiteID }); len(gs) > 0 {
+			if _, ok := gs.FindByGroupID(tw.DefaultGroupID); !ok {
+				return c, errors.NewNotValidf("[store] DefaultGroupID %d does not belong to Website %d", tw.DefaultGroupID, tw.WebsiteID)
+			}
+		} else if tw.DefaultGroupID != 0 {
+			return c, errors.NewNotValidf("[store] Website %d has no groups, DefaultGroupID must be 0", tw.WebsiteID)
+		}
+		if tw.IsDefault.Bool {
+			if g, err := td.group(tw.DefaultGroupID); err != nil || g.WebsiteID != tw.WebsiteID || g.DefaultStoreID == 0 {
+				return c, errors.NewNotValidf("[store] Website %d requires a default Group with a default Store to become the default Website", tw.WebsiteID)
+			}
+		}
+		if old.IsDefault.Bool && !tw.IsDefault.Bool {
+			return c, errors.NewNotValidf("[store] Website %d is the default Website. Set another Website as default", tw.WebsiteID)
+		}
+		tbl, err := tableName(TableIndexWebsite)
+		if err != nil {
+			return c, err
+		}
+		if _, err = tx.Update(tbl).
+			Set("code", tw.Code).
+			Set("name", tw.Name).
+			Set("sort_order", tw.SortOrder).
+			Set("default_group_id", tw.DefaultGroupID).
+			Set("is_default", tw.IsDefault.Bool).
+			Where(dbr.ConditionRaw("website_id = ?", tw.WebsiteID)).Exec(); err != nil {
+			return c, errors.Wrapf(err, "[store] Update Website %d", tw.WebsiteID)
+		}
+		cp := *tw
+		c.Websites = append(c.Websites, &cp)
+		if tw.IsDefault.Bool {
+			err = unsetDefaultWebsites(tx, td, tbl, tw.WebsiteID, &c)
+		}
+		return c, err
+	}), "[store] UpdateWebsite")
+}
+
+// DeleteWebsite removes a website with all its groups and stores. The admin
+// website 0 and the default website cannot be deleted. Error behaviour:
+// NotValid, NotFound or Fatal.
+func (s *Service) DeleteWebsite(db TxBeginner, websiteID int64) error {
+	return errors.Wrap(s.modify(db, func(tx *dbr.Tx, td tableData) (c Changes, err error) {
+		if websiteID == 0 {
+			return c, errors.NewNotValidf("[store] The admin Website cannot be deleted")
+		}
+		w, err := td.website(websiteID)
+		if err != nil {
+			return c, err
+		}
+		if w.IsDefault.Bool {
+			return c, errors.NewNotValidf("[store] The default Website %d cannot be deleted", websiteID)
+		}
+		for _, idx := range [...]int{TableIndexStore, TableIndexGroup, TableIndexWebsite} {
+			tbl, err := tableName(idx)
+			if err != nil {
+				return c, err
+			}
+			if _, err := tx.DeleteFrom(tbl).Where(dbr.ConditionRaw("website_id = ?", websiteID)).Exec(); err != nil {
+				return c, errors.Wrapf(err, "[store] Delete from %q Website %d", tbl, websiteID)
+			}
+		}
+		td.stores.Each(func(st *TableStore) {
+			if st.WebsiteID == websiteID {
+				c.DeletedStoreIDs = append(c.DeletedStoreIDs, st.StoreID)
+			}
+		})
+		td.groups.Each(func(g *TableGroup) {
+			if g.WebsiteID == websiteID {
+				c.DeletedGroupIDs = append(c.DeletedGroupIDs, g.GroupID)
+			}
+		})
+		c.DeletedWebsiteIDs = append(c.DeletedWebsiteIDs, websiteID)
+		return c, nil
+	}), "[store] DeleteWebsite")
+}
+
+// CreateGroup inserts a new group into an existing website. A GroupID of zero
+// lets the database assign the ID, which gets written back into tg after the
+// commit. The DefaultStoreID must be zero because the first store created in
+// this group becomes the default store. If the website has no default group,
+// the new group becomes the default group. Error behaviour: NotValid,
+// AlreadyExists, NotFound or Fatal.
+func (s *Service) CreateGroup(db TxBeginner, tg *TableGroup) error {
+	// rec gets copied back into tg after the commit
+	rec := *tg
+	err := s.modify(db, func(tx *dbr.Tx, td tableData) (c Changes, err error) {
+		if rec.WebsiteID == 0 {
+			return c, errors.NewNotValidf("[store] A Group cannot be added to the admin Website")
+		}
+		w, err := td.website(rec.WebsiteID)
+		if err != nil {
+			return c, err
+		}
+		if _, ok := td.groups.FindByGroupID(rec.GroupID); ok && rec.GroupID > 0 {
+			return c, errors.NewAlreadyExistsf("[store] Group %d already exists", rec.GroupID)
+		}
+		if rec.DefaultStoreID != 0 {
+			return c, errors.NewNotValidf("[store] A new Group cannot have a DefaultStoreID %d", rec.DefaultStoreID)
+		}
+		tbl, err := tableName(TableIndexGroup)
+		if err != nil {
+			return c, err
+		}
+		ins := tx.InsertInto(tbl).Columns("website_id", "name", "root_category_id", "default_store_id").
+			Values(rec.WebsiteID, rec.Name, rec.RootCategoryID, rec.DefaultStoreID)
+		id, err := insertID(ins, "group_id", rec.GroupID)
+		if err != nil {
+			return c, err
+		}
+		rec.GroupID = id
+		cp := rec
+		c.Groups = append(c.Groups, &cp)
+
+		if w.DefaultGroupID == 0 {
+			wTbl, err := tableName(TableIndexWebsite)
+			if err != nil {
+				return c, err
+			}
+			if _, err := tx.Update(wTbl).Set("default_group_id", rec.GroupID).Where(dbr.ConditionRaw("website_id = ?", w.WebsiteID)).Exec(); err != nil {
+				return c, errors.Wrapf(err, "[store] Update Website %d", w.WebsiteID)
+			}
+			nw := *w
+			nw.DefaultGroupID = rec.GroupID
+			c.Websites = append(c.Websites, &nw)
+		}
+		return c, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "[store] CreateGroup")
+	}
+	*tg = rec
+	return nil
+}
+
+// UpdateGroup changes an existing group. The admin group 0 cannot be changed
+// and a group cannot be moved to another website. If the group has stores,
+// the DefaultStoreID must point to one of its active stores. Error behaviour:
+// NotValid, NotFound or Fatal.
+func (s *Service) UpdateGroup(db TxBeginner, tg *TableGroup) error {
+	return errors.Wrap(s.modify(db, func(tx *dbr.Tx, td tableData) (c Changes, err error) {
+		if tg.GroupID == 0 {
+			return c, errors.NewNotValidf("[store] The admin Group cannot be changed")
+		}
+		old, err := td.group(tg.GroupID)
+		if err != nil {
+			return c, err
+		}
+		if old.WebsiteID != tg.WebsiteID {
+			return c, errors.NewNotValidf("[store] Group %d cannot be moved from Website %d to %d", tg.GroupID, old.WebsiteID, tg.WebsiteID)
+		}
+		if ss := td.stores.Filter(func(st *TableStore) bool { return st.GroupID == tg.GroupID }); len(ss) > 0 {
+			st, ok := ss.FindByStoreID(tg.DefaultStoreID)
+			if !ok {
+				return c, errors.NewNotValidf("[store] DefaultStoreID %d does not belong to Group %d", tg.DefaultStoreID, tg.GroupID)
+			}
+			if !st.IsActive {
+				return c, errors.NewNotValidf("[store] DefaultStoreID %d of Group %d is not active", tg.DefaultStoreID, tg.GroupID)
+			}
+		} else if tg.DefaultStoreID != 0 {
+			return c, errors.NewNotValidf("[store] Group %d has no stores, DefaultStoreID must be 0", tg.GroupID)
+		}
+		tbl, err := tableName(TableIndexGroup)
+		if err != nil {
+			return c, err
+		}
+		if _, err = tx.Update(tbl).
+			Set("name", tg.Name).
+			Set("root_category_id", tg.RootCategoryID).
+			Set("default_store_id", tg.DefaultStoreID).
+			Where(dbr.ConditionRaw("group_id = ?", tg.GroupID)).Exec(); err != nil {
+			return c, errors.Wrapf(err, "[store] Update Group %d", tg.GroupID)
+		}
+		cp := *tg
+		c.Groups = append(c.Groups, &cp)
+		return c, nil
+	}), "[store] UpdateGroup")
+}
+
+// DeleteGroup removes a group with all its stores. The admin group 0 and the
+// default group of a website cannot be deleted. Error behaviour: NotValid,
+// NotFound or Fatal.
+func (s *Service) DeleteGroup(db TxBeginner, groupID int64) error {
+	return errors.Wrap(s.modify(db, func(tx *dbr.Tx, td tableData) (c Changes, err error) {
+		if groupID == 0 {
+			return c, errors.NewNotValidf("[store] The admin Group cannot be deleted")
+		}
+		g, err := td.group(groupID)
+		if err != nil {
+			return c, err
+		}
+		if w, err := td.website(g.WebsiteID); err == nil && w.DefaultGroupID == groupID {
+			return c, errors.NewNotValidf("[store] Group %d is the default Group of Website %d", groupID, w.WebsiteID)
+		}
+		for _, idx := range [...]int{TableIndexStore, TableIndexGroup} {
+			tbl, err := tableName(idx)
+			if err != nil {
+				return c, err
+			}
+			if _, err := tx.DeleteFrom(tbl).Where(dbr.ConditionRaw("group_id = ?", groupID)).Exec(); err != nil {
+				return c, errors.Wrapf(err, "[store] Delete from %q Group %d", tbl, groupID)
+			}
+		}
+		td.stores.Each(func(st *TableStore) {
+			if st.GroupID == groupID {
+				c.DeletedStoreIDs = append(c.DeletedStoreIDs, st.StoreID)
+			}
+		})
+		c.DeletedGroupIDs = append(c.DeletedGroupIDs, groupID)
+		return c, nil
+	}), "[store] DeleteGroup")
+}
+
+// CreateStore inserts a new store into an existing group. A StoreID of zero
+// lets the database assign the ID, which gets written back into ts after the
+// commit. The code must be valid and unique. The WebsiteID gets taken from
+// the group. If the group has no default store, the new store becomes the
+// default store and must therefore be active. Error behaviour: NotValid,
+// AlreadyExists, NotFound or Fatal.
+func (s *Service) CreateStore(db TxBeginner, ts *TableStore) error {
+	// rec gets copied back into ts after the commit
+	rec := *ts
+	err := s.modify(db, func(tx *dbr.Tx, td tableData) (c Changes, err error) {
+		if err = CodeIsValid(rec.Code.String); err != nil {
+			return c, err
+		}
+		if td.isStoreCodeUsed(rec.Code.String, -1) {
+			return c, errors.NewAlreadyExistsf("[store] Store code %q already exists", rec.Code.String)
+		}
+		if _, ok := td.stores.FindByStoreID(rec.StoreID); ok && rec.StoreID > 0 {
+			return c, errors.NewAlreadyExistsf("[store] Store %d already exists", rec.StoreID)
+		}
+		if rec.GroupID == 0 {
+			return c, errors.NewNotValidf("[store] A Store cannot be added to the admin Group")
+		}
+		g, err := td.group(rec.GroupID)
+		if err != nil {
+			return c, err
+		}
+		if rec.WebsiteID != 0 && rec.WebsiteID != g.WebsiteID {
+			return c, errors.NewNotValidf("[store] Store WebsiteID %d does not match the WebsiteID %d of Group %d", rec.WebsiteID, g.WebsiteID, g.GroupID)
+		}
+		rec.WebsiteID = g.WebsiteID
+		if g.DefaultStoreID == 0 && !rec.IsActive {
+			return c, errors.NewNotValidf("[store] Store %q becomes the default Store of Group %d and must be active", rec.Code.String, g.GroupID)
+		}
+		tbl, err := tableName(TableIndexStore)
+		if err != nil {
+			return c, err
+		}
+		ins := tx.InsertInto(tbl).Columns("code", "website_id", "group_id", "name", "sort_order", "is_active").
+			Values(rec.Code, rec.WebsiteID, rec.GroupID, rec.Name, rec.SortOrder, rec.IsActive)
+		id, err := insertID(ins, "store_id", rec.StoreID)
+		if err != nil {
+			return c, err
+		}
+		rec.StoreID = id
+		cp := rec
+		c.Stores = append(c.Stores, &cp)
+
+		if g.DefaultStoreID == 0 {
+			ng, err := setGroupDefaultStore(tx, g, rec.StoreID)
+			if err != nil {
+				return c, err
+			}
+			c.Groups = append(c.Groups, ng)
+		}
+		return c, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "[store] CreateStore")
+	}
+	*ts = rec
+	return nil
+}
+
+// setGroupDefaultStore updates the default_store_id of the group and returns
+// a changed copy of the group.
+func setGroupDefaultStore(tx *dbr.Tx, g *TableGroup, storeID int64) (*TableGroup, error) {
+	tbl, err := tableName(TableIndexGroup)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Update(tbl).Set("default_store_id", storeID).Where(dbr.ConditionRaw("group_id = ?", g.GroupID)).Exec(); err != nil {
+		return nil, errors.Wrapf(err, "[store] Update Group %d", g.GroupID)
+	}
+	ng := *g
+	ng.DefaultStoreID = storeID
+	return &ng, nil
+}
+
+// UpdateStore changes an existing store. The admin store 0 cannot be changed.
+// The code must be valid and unique. The group and website cannot be changed,
+// use MoveStore instead. The default store of a group cannot be deactivated.
+// Error behaviour: NotValid, AlreadyExists, NotFound or Fatal.
+func (s *Service) UpdateStore(db TxBeginner, ts *TableStore) error {
+	return errors.Wrap(s.modify(db, func(tx *dbr.Tx, td tableData) (c Changes, err error) {
+		if ts.StoreID == 0 {
+			return c, errors.NewNotValidf("[store] The admin Store cannot be changed")
+		}
+		old, err := td.store(ts.StoreID)
+		if err != nil {
+			return c, err
+		}
+		if err = CodeIsValid(ts.Code.String); err != nil {
+			return c, err
+		}
+		if td.isStoreCodeUsed(ts.Code.String, ts.StoreID) {
+			return c, errors.NewAlreadyExistsf("[store] Store code %q already exists", ts.Code.String)
+		}
+		if old.GroupID != ts.GroupID || old.WebsiteID != ts.WebsiteID {
+			return c, errors.NewNotValidf("[store] Store %d: Group or Website cannot be changed, use MoveStore", ts.StoreID)
+		}
+		if g, err := td.group(ts.GroupID); err == nil && g.DefaultStoreID == ts.StoreID && !ts.IsActive {
+			return c, errors.NewNotValidf("[store] Store %d is the default Store of Group %d and cannot be deactivated", ts.StoreID, g.GroupID)
+		}
+		tbl, err := tableName(TableIndexStore)
+		if err != nil {
+			return c, err
+		}
+		if _, err = tx.Update(tbl).
+			Set("code", ts.Code).
+			Set("name", ts.Name).
+			Set("sort_order", ts.SortOrder).
+			Set("is_active", ts.IsActive).
+			Where(dbr.ConditionRaw("store_id = ?", ts.StoreID)).Exec(); err != nil {
+			return c, errors.Wrapf(err, "[store] Update Store %d", ts.StoreID)
+		}
+		cp := *ts
+		c.Stores = append(c.Stores, &cp)
+		return c, nil
+	}), "[store] UpdateStore")
+}
+
+// MoveStore moves a store into another group, which might belong to another
+// website. The admin store 0 and the default store of a group cannot be
+// moved. If the new group has no default store, the moved store becomes the
+// default store. Error behaviour: NotValid, NotFound or Fatal.
+func (s *Service) MoveStore(db TxBeginner, storeID, groupID int64) error {
+	return errors.Wrap(s.modify(db, func(tx *dbr.Tx, td tableData) (c Changes, err error) {
+		if storeID == 0 {
+			return c, errors.NewNotValidf("[store] The admin Store cannot be moved")
+		}
+		if groupID == 0 {
+			return c, errors.NewNotValidf("[store] A Store cannot be moved into the admin Group")
+		}
+		st, err := td.store(storeID)
+		if err != nil {
+			return c, err
+		}
+		g, err := td.group(groupID)
+		if err != nil {
+			return c, err
+		}
+		if st.GroupID == groupID {
+			return c, nil
+		}
+		if og, err := td.group(st.GroupID); err == nil && og.DefaultStoreID == storeID {
+			return c, errors.NewNotValidf("[store] Store %d is the default Store of Group %d and cannot be moved", storeID, og.GroupID)
+		}
+		if g.DefaultStoreID == 0 && !st.IsActive {
+			return c, errors.NewNotValidf("[store] Store %d becomes the default Store of Group %d and must be active", storeID, groupID)
+		}
+		tbl, err := tableName(TableIndexStore)
+		if err != nil {
+			return c, err
+		}
+		if _, err = tx.Update(tbl).
+			Set("group_id", g.GroupID).
+			Set("website_id", g.WebsiteID).
+			Where(dbr.ConditionRaw("store_id = ?", storeID)).Exec(); err != nil {
+			return c, errors.Wrapf(err, "[store] Update Store %d", storeID)
+		}
+		nst := *st
+		nst.GroupID, nst.WebsiteID = g.GroupID, g.WebsiteID
+		c.Stores = append(c.Stores, &nst)
+
+		if g.DefaultStoreID == 0 {
+			ng, err := setGroupDefaultStore(tx, g, storeID)
+			if err != nil {
+				return c, err
+			}
+			c.Groups = append(c.Groups, ng)
+		}
+		return c, nil
+	}), "[store] MoveStore")
+}
+
+// DeleteStore removes a store. The admin store 0 and the default store of a
+// group cannot be deleted. Error behaviour: NotValid, NotFound or Fatal.
+func (s *Service) DeleteStore(db TxBeginner, storeID int64) error {
+	return errors.Wrap(s.modify(db, func(tx *dbr.Tx, td tableData) (c Changes, err error) {
+		if storeID == 0 {
+			return c, errors.NewNotValidf("[store] The admin Store cannot be deleted")
+		}
+		st, err := td.store(storeID)
+		if err != nil {
+			return c, err
+		}
+		if g, err := td.group(st.GroupID); err == nil && g.DefaultStoreID == storeID {
+			return c, errors.NewNotValidf("[store] Store %d is the default Store of Group %d and cannot be deleted", storeID, g.GroupID)
+		}
+		tbl, err := tableName(TableIndexStore)
+		if err != nil {
+			return c, err
+		}
+		if _, err := tx.DeleteFrom(tbl).Where(dbr.ConditionRaw("store_id = ?", storeID)).Exec(); err != nil {
+			return c, errors.Wrapf(err, "[store] Delete Store %d", storeID)
+		}
+		c.DeletedStoreIDs = append(c.DeletedStoreIDs, storeID)
+		return c, nil
+	}), "[store] DeleteStore")
+}
@@ -0,0 +1,305 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/corestoreio/csfw/util/null"
+	"github.com/stretchr/testify/assert"
+)
+
+type publisherMock struct {
+	paths []string
+}
+
+func (pm *publisherMock) Publish(p cfgpath.Path) {
+	pm.paths = append(pm.paths, p.String())
+}
+
+func newCRUDService(t *testing.T) (*store.Service, *dbr.Session, sqlmock.Sqlmock, func()) {
+	dbc, dbMock := cstesting.MockDB(t)
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+	return srv, dbc.NewSession(), dbMock, func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}
+}
+
+func TestService_CreateStore(t *testing.T) {
+	srv, sess, dbMock, closer := newCRUDService(t)
+	defer closer()
+	pm := new(publisherMock)
+	srv.Publisher = pm
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("INSERT INTO store \\(`code`,`website_id`,`group_id`,`name`,`sort_order`,`is_active`\\) VALUES \\('fr',1,1,'France',40,1\\)").
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	dbMock.ExpectCommit()
+
+	ts := &store.TableStore{Code: null.StringFrom("fr"), GroupID: 1, Name: "France", SortOrder: 40, IsActive: true}
+	assert.NoError(t, srv.CreateStore(sess, ts))
+	assert.Exactly(t, int64(7), ts.StoreID)
+	assert.Exactly(t, int64(1), ts.WebsiteID)
+
+	// the cache must not share the record with the caller
+	ts.Name = "Changed"
+	st, err := srv.Store(7)
+	assert.NoError(t, err)
+	assert.Exactly(t, "fr", st.Code())
+	assert.Exactly(t, "France", st.Name())
+	assert.Exactly(t, []string{"stores/7/store/service/changed"}, pm.paths)
+}
+
+func TestService_CreateStore_CommitError(t *testing.T) {
+	srv, sess, dbMock, closer := newCRUDService(t)
+	defer closer()
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("INSERT INTO store").WillReturnResult(sqlmock.NewResult(7, 1))
+	dbMock.ExpectCommit().WillReturnError(errors.NewFatalf("Connection lost"))
+
+	// the caller's record stays untouched when the transaction fails
+	ts := &store.TableStore{Code: null.StringFrom("fr"), GroupID: 1, Name: "France", SortOrder: 40, IsActive: true}
+	err := srv.CreateStore(sess, ts)
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+	assert.Exactly(t, int64(0), ts.StoreID)
+	assert.Exactly(t, int64(0), ts.WebsiteID)
+
+	_, err = srv.Store(7)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+}
+
+func TestService_CreateWebsite_Group_Store(t *testing.T) {
+	srv, sess, dbMock, closer := newCRUDService(t)
+	defer closer()
+
+	// a new website has no default store and cannot be the default website
+	dbMock.ExpectBegin()
+	dbMock.ExpectRollback()
+	err := srv.CreateWebsite(sess, &store.TableWebsite{Code: null.StringFrom("us"), IsDefault: null.BoolFrom(true)})
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("INSERT INTO store_website \\(`code`,`name`,`sort_order`,`default_group_id`,`is_default`\\) VALUES \\('us','USA',30,0,0\\)").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	dbMock.ExpectCommit()
+	tw := &store.TableWebsite{Code: null.StringFrom("us"), Name: null.StringFrom("USA"), SortOrder: 30}
+	assert.NoError(t, srv.CreateWebsite(sess, tw))
+	assert.Exactly(t, int64(3), tw.WebsiteID)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("INSERT INTO store_group \\(`website_id`,`name`,`root_category_id`,`default_store_id`\\) VALUES \\(3,'US',2,0\\)").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	dbMock.ExpectExec("UPDATE `store_website` SET `default_group_id` = 4 WHERE \\(website_id = 3\\)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+	tg := &store.TableGroup{WebsiteID: 3, Name: "US", RootCategoryID: 2}
+	assert.NoError(t, srv.CreateGroup(sess, tg))
+	assert.Exactly(t, int64(4), tg.GroupID)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("INSERT INTO store").WillReturnResult(sqlmock.NewResult(8, 1))
+	dbMock.ExpectExec("UPDATE `store_group` SET `default_store_id` = 8 WHERE \\(group_id = 4\\)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+	ts := &store.TableStore{Code: null.StringFrom("us_en"), GroupID: 4, Name: "English", IsActive: true}
+	assert.NoError(t, srv.CreateStore(sess, ts))
+
+	w, err := srv.Website(3)
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(4), w.DefaultGroupID())
+	id, err := w.DefaultStoreID()
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(8), id)
+	assert.Exactly(t, []int64{8}, w.Stores.IDs())
+}
+
+func TestService_UpdateStore_MoveStore_DeleteStore(t *testing.T) {
+	srv, sess, dbMock, closer := newCRUDService(t)
+	defer closer()
+	pm := new(publisherMock)
+	srv.Publisher = pm
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("UPDATE `store` SET `code` = 'de', `name` = 'Deutschland', `sort_order` = 10, `is_active` = 0 WHERE \\(store_id = 1\\)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+	assert.NoError(t, srv.UpdateStore(sess, &store.TableStore{StoreID: 1, Code: null.StringFrom("de"), WebsiteID: 1, GroupID: 1, Name: "Deutschland", SortOrder: 10}))
+	st, err := srv.Store(1)
+	assert.NoError(t, err)
+	assert.Exactly(t, "Deutschland", st.Name())
+	assert.False(t, st.IsActive())
+
+	// move UK store into the Australia group of website oz
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("UPDATE `store` SET `group_id` = 3, `website_id` = 2 WHERE \\(store_id = 3\\)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+	assert.NoError(t, srv.MoveStore(sess, 3, 3))
+	st, err = srv.Store(3)
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(2), st.WebsiteID())
+	assert.Exactly(t, int64(3), st.GroupID())
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("DELETE FROM `store` WHERE \\(store_id = 6\\)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+	assert.NoError(t, srv.DeleteStore(sess, 6))
+	_, err = srv.Store(6)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+
+	assert.Exactly(t, []string{
+		"stores/1/store/service/changed",
+		"stores/3/store/service/changed",
+		"stores/6/store/service/changed",
+	}, pm.paths)
+}
+
+func TestService_DeleteWebsite_DeleteGroup(t *testing.T) {
+	srv, sess, dbMock, closer := newCRUDService(t)
+	defer closer()
+	pm := new(publisherMock)
+	srv.Publisher = pm
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("DELETE FROM `store` WHERE \\(group_id = 2\\)").WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("DELETE FROM `store_group` WHERE \\(group_id = 2\\)").WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+	assert.NoError(t, srv.DeleteGroup(sess, 2))
+	_, err := srv.Store(4)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("DELETE FROM `store` WHERE \\(website_id = 2\\)").WillReturnResult(sqlmock.NewResult(0, 2))
+	dbMock.ExpectExec("DELETE FROM `store_group` WHERE \\(website_id = 2\\)").WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("DELETE FROM `store_website` WHERE \\(website_id = 2\\)").WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+	assert.NoError(t, srv.DeleteWebsite(sess, 2))
+	_, err = srv.Website(2)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	_, err = srv.Group(3)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	assert.Exactly(t, []int64{0, 1, 2, 3}, srv.Stores().IDs())
+
+	assert.Exactly(t, []string{
+		"websites/1/store/service/changed",
+		"stores/4/store/service/changed",
+		"websites/2/store/service/changed",
+		"stores/5/store/service/changed",
+		"stores/6/store/service/changed",
+	}, pm.paths)
+}
+
+func TestService_CRUD_Invariants(t *testing.T) {
+	srv, sess, dbMock, closer := newCRUDService(t)
+	defer closer()
+	storeIDs := srv.Stores().IDs()
+
+	tests := []struct {
+		fn         func() error
+		wantErrBhf errors.BehaviourFunc
+	}{
+		{func() error {
+			return srv.UpdateStore(sess, &store.TableStore{StoreID: 0, Code: null.StringFrom("admin")})
+		}, errors.IsNotValid},
+		{func() error { return srv.DeleteStore(sess, 0) }, errors.IsNotValid},
+		{func() error { return srv.MoveStore(sess, 0, 1) }, errors.IsNotValid},
+		{func() error { return srv.DeleteGroup(sess, 0) }, errors.IsNotValid},
+		{func() error { return srv.DeleteWebsite(sess, 0) }, errors.IsNotValid},
+		// default website, group and store
+		{func() error { return srv.DeleteWebsite(sess, 1) }, errors.IsNotValid},
+		{func() error { return srv.DeleteGroup(sess, 1) }, errors.IsNotValid},
+		{func() error { return srv.DeleteStore(sess, 2) }, errors.IsNotValid},
+		{func() error { return srv.MoveStore(sess, 2, 2) }, errors.IsNotValid},
+		{func() error {
+			return srv.UpdateStore(sess, &store.TableStore{StoreID: 2, Code: null.StringFrom("at"), WebsiteID: 1, GroupID: 1})
+		}, errors.IsNotValid},
+		// codes
+		{func() error {
+			return srv.CreateStore(sess, &store.TableStore{Code: null.StringFrom("1fr"), GroupID: 1, IsActive: true})
+		}, errors.IsNotValid},
+		{func() error {
+			return srv.CreateStore(sess, &store.TableStore{Code: null.StringFrom("de"), GroupID: 1, IsActive: true})
+		}, errors.IsAlreadyExists},
+		{func() error {
+			return srv.UpdateStore(sess, &store.TableStore{StoreID: 1, Code: null.StringFrom("at"), WebsiteID: 1, GroupID: 1, IsActive: true})
+		}, errors.IsAlreadyExists},
+		{func() error { return srv.CreateWebsite(sess, &store.TableWebsite{Code: null.StringFrom("oz")}) }, errors.IsAlreadyExists},
+		// references
+		{func() error {
+			return srv.CreateStore(sess, &store.TableStore{Code: null.StringFrom("fr"), GroupID: 99, IsActive: true})
+		}, errors.IsNotFound},
+		{func() error {
+			return srv.CreateStore(sess, &store.TableStore{Code: null.StringFrom("fr"), WebsiteID: 2, GroupID: 1, IsActive: true})
+		}, errors.IsNotValid},
+		{func() error {
+			return srv.UpdateStore(sess, &store.TableStore{StoreID: 1, Code: null.StringFrom("de"), WebsiteID: 1, GroupID: 2, IsActive: true})
+		}, errors.IsNotValid},
+		{func() error { return srv.CreateGroup(sess, &store.TableGroup{WebsiteID: 99}) }, errors.IsNotFound},
+		{func() error { return srv.CreateGroup(sess, &store.TableGroup{WebsiteID: 1, DefaultStoreID: 1}) }, errors.IsNotValid},
+		{func() error {
+			return srv.UpdateGroup(sess, &store.TableGroup{GroupID: 1, WebsiteID: 1, DefaultStoreID: 4})
+		}, errors.IsNotValid},
+		{func() error {
+			return srv.UpdateGroup(sess, &store.TableGroup{GroupID: 1, WebsiteID: 1, DefaultStoreID: 3}) // inactive
+		}, errors.IsNotValid},
+		{func() error {
+			return srv.UpdateWebsite(sess, &store.TableWebsite{WebsiteID: 1, Code: null.StringFrom("euro"), DefaultGroupID: 3, IsDefault: null.BoolFrom(true)})
+		}, errors.IsNotValid},
+		{func() error {
+			return srv.UpdateWebsite(sess, &store.TableWebsite{WebsiteID: 1, Code: null.StringFrom("euro"), DefaultGroupID: 1})
+		}, errors.IsNotValid},
+		{func() error { return srv.MoveStore(sess, 4, 99) }, errors.IsNotFound},
+	}
+	for i, test := range tests {
+		dbMock.ExpectBegin()
+		dbMock.ExpectRollback()
+		err := test.fn()
+		assert.True(t, test.wantErrBhf(err), "Index %d => %s", i, err)
+	}
+	// nothing changed
+	assert.Exactly(t, storeIDs, srv.Stores().IDs())
+}
+
+func TestService_CRUD_Rollback(t *testing.T) {
+	srv, sess, dbMock, closer := newCRUDService(t)
+	defer closer()
+	pm := new(publisherMock)
+	srv.Publisher = pm
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("UPDATE `store`").WillReturnError(errors.NewFatalf("Connection lost"))
+	dbMock.ExpectRollback()
+	err := srv.UpdateStore(sess, &store.TableStore{StoreID: 1, Code: null.StringFrom("de"), WebsiteID: 1, GroupID: 1, Name: "Deutschland", IsActive: true})
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+
+	st, err := srv.Store(1)
+	assert.NoError(t, err)
+	assert.Exactly(t, "Germany", st.Name())
+	assert.Nil(t, pm.paths)
+}
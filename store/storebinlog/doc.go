@@ -0,0 +1,25 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storebinlog applies MySQL binlog row events of the tables store,
+// store_group and store_website to a store.Service without a full reload.
+//
+// Register the Handler with a binlogsync.Canal:
+//		c, err := binlogsync.NewCanal(dsn, binlogsync.WithMySQL())
+//		c.RegisterRowsEventHandler(storebinlog.New(storeSrv))
+//
+// Magento 1 uses the table names core_store, core_store_group and
+// core_website, which are supported too. A table prefix can be set in the
+// field TablePrefix.
+package storebinlog
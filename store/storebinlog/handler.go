@@ -0,0 +1,268 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storebinlog
+
+import (
+	"context"
+	"strings"
+
+	"github.com/corestoreio/csfw/log"
+	"github.com/corestoreio/csfw/storage/binlogsync"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/corestoreio/csfw/util/null"
+)
+
+// Table names of Magento 2 and Magento 1.
+const (
+	TableStore        = "store"
+	TableStoreGroup   = "store_group"
+	TableStoreWebsite = "store_website"
+
+	TableStoreM1        = "core_store"
+	TableStoreGroupM1   = "core_store_group"
+	TableStoreWebsiteM1 = "core_website"
+)
+
+// Applier gets implemented by store.Service.
+type Applier interface {
+	ApplyChanges(store.Changes) error
+}
+
+// Handler implements the binlogsync.RowsEventHandler interface and applies
+// the changed rows of the store tables to the Applier. Events of all other
+// tables get ignored.
+type Handler struct {
+	// TablePrefix optional prefix of the table names.
+	TablePrefix string
+	// Log defaults to log.BlackHole.
+	Log log.Logger
+
+	app Applier
+}
+
+// New creates a new Handler for the store.Service.
+func New(app Applier) *Handler {
+	return &Handler{
+		Log: log.BlackHole{},
+		app: app,
+	}
+}
+
+// String returns the name of the handler.
+func (h *Handler) String() string {
+	return "storebinlog"
+}
+
+// Complete does nothing because all changes get applied immediately.
+func (h *Handler) Complete(_ context.Context) error {
+	return nil
+}
+
+// Do converts the rows of the store tables into store.Changes and applies
+// them. An update event contains the before and after image of each row,
+// only the after image gets used. A delete event removes the rows by their
+// primary key. Error behaviour: NotValid, NotSupported or the error of the
+// Applier.
+func (h *Handler) Do(_ context.Context, action string, t csdb.Table, rows [][]interface{}) error {
+	tbl := strings.TrimPrefix(t.Name, h.TablePrefix)
+	switch tbl {
+	case TableStore, TableStoreM1, TableStoreGroup, TableStoreGroupM1, TableStoreWebsite, TableStoreWebsiteM1:
+	default:
+		return nil
+	}
+
+	switch action {
+	case binlogsync.InsertAction, binlogsync.DeleteAction:
+	case binlogsync.UpdateAction:
+		if len(rows)%2 != 0 {
+			return errors.NewNotValidf("[storebinlog] Update event of table %q requires an even number of rows, have %d", t.Name, len(rows))
+		}
+		after := make([][]interface{}, 0, len(rows)/2)
+		for i := 1; i < len(rows); i += 2 {
+			after = append(after, rows[i])
+		}
+		rows = after
+	default:
+		return errors.NewNotSupportedf("[storebinlog] Action %q not supported", action)
+	}
+
+	var c store.Changes
+	for _, row := range rows {
+		r := rowMap(t.Columns, row)
+		var err error
+		switch tbl {
+		case TableStore, TableStoreM1:
+			var ts *store.TableStore
+			if ts, err = r.store(); err == nil {
+				if action == binlogsync.DeleteAction {
+					c.DeletedStoreIDs = append(c.DeletedStoreIDs, ts.StoreID)
+				} else {
+					c.Stores = append(c.Stores, ts)
+				}
+			}
+		case TableStoreGroup, TableStoreGroupM1:
+			var tg *store.TableGroup
+			if tg, err = r.group(); err == nil {
+				if action == binlogsync.DeleteAction {
+					c.DeletedGroupIDs = append(c.DeletedGroupIDs, tg.GroupID)
+				} else {
+					c.Groups = append(c.Groups, tg)
+				}
+			}
+		default:
+			var tw *store.TableWebsite
+			if tw, err = r.website(); err == nil {
+				if action == binlogsync.DeleteAction {
+					c.DeletedWebsiteIDs = append(c.DeletedWebsiteIDs, tw.WebsiteID)
+				} else {
+					c.Websites = append(c.Websites, tw)
+				}
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "[storebinlog] Table %q Action %q", t.Name, action)
+		}
+	}
+
+	if h.Log.IsDebug() {
+		h.Log.Debug("storebinlog.Handler.Do", log.String("table", t.Name), log.String("action", action), log.Int("rows", len(rows)))
+	}
+	return errors.Wrapf(h.app.ApplyChanges(c), "[storebinlog] ApplyChanges Table %q Action %q", t.Name, action)
+}
+
+// row maps the column names to the values of a binlog row.
+type row map[string]interface{}
+
+func rowMap(cols csdb.Columns, values []interface{}) row {
+	r := make(row, len(cols))
+	for i, c := range cols {
+		if i < len(values) {
+			r[c.Field] = values[i]
+		}
+	}
+	return r
+}
+
+func (r row) int64(col string) (int64, error) {
+	switch v := r[col].(type) {
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return int64(v), nil
+	case []byte:
+		return conv.ToInt64E(string(v))
+	}
+	return conv.ToInt64E(r[col])
+}
+
+func (r row) string(col string) (string, error) {
+	return conv.ToStringE(r[col])
+}
+
+func (r row) nullString(col string) (null.String, error) {
+	if r[col] == nil {
+		return null.String{}, nil
+	}
+	s, err := r.string(col)
+	return null.StringFrom(s), err
+}
+
+func (r row) bool(col string) (bool, error) {
+	i, err := r.int64(col)
+	return i != 0, err
+}
+
+func (r row) store() (*store.TableStore, error) {
+	var ts store.TableStore
+	var err error
+	if ts.StoreID, err = r.int64("store_id"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column store_id")
+	}
+	if ts.Code, err = r.nullString("code"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column code")
+	}
+	if ts.WebsiteID, err = r.int64("website_id"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column website_id")
+	}
+	if ts.GroupID, err = r.int64("group_id"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column group_id")
+	}
+	if ts.Name, err = r.string("name"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column name")
+	}
+	if ts.SortOrder, err = r.int64("sort_order"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column sort_order")
+	}
+	if ts.IsActive, err = r.bool("is_active"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column is_active")
+	}
+	return &ts, nil
+}
+
+func (r row) group() (*store.TableGroup, error) {
+	var tg store.TableGroup
+	var err error
+	if tg.GroupID, err = r.int64("group_id"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column group_id")
+	}
+	if tg.WebsiteID, err = r.int64("website_id"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column website_id")
+	}
+	if tg.Name, err = r.string("name"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column name")
+	}
+	if tg.RootCategoryID, err = r.int64("root_category_id"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column root_category_id")
+	}
+	if tg.DefaultStoreID, err = r.int64("default_store_id"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column default_store_id")
+	}
+	return &tg, nil
+}
+
+func (r row) website() (*store.TableWebsite, error) {
+	var tw store.TableWebsite
+	var err error
+	if tw.WebsiteID, err = r.int64("website_id"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column website_id")
+	}
+	if tw.Code, err = r.nullString("code"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column code")
+	}
+	if tw.Name, err = r.nullString("name"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column name")
+	}
+	if tw.SortOrder, err = r.int64("sort_order"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column sort_order")
+	}
+	if tw.DefaultGroupID, err = r.int64("default_group_id"); err != nil {
+		return nil, errors.Wrap(err, "[storebinlog] Column default_group_id")
+	}
+	if r["is_default"] != nil {
+		var b bool
+		if b, err = r.bool("is_default"); err != nil {
+			return nil, errors.Wrap(err, "[storebinlog] Column is_default")
+		}
+		tw.IsDefault = null.BoolFrom(b)
+	}
+	return &tw, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storebinlog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/storage/binlogsync"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/storebinlog"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ binlogsync.RowsEventHandler = (*storebinlog.Handler)(nil)
+
+func newTable(name string, cols ...string) csdb.Table {
+	t := csdb.Table{Name: name}
+	for i, c := range cols {
+		t.Columns = append(t.Columns, &csdb.Column{Field: c, Pos: int64(i + 1)})
+	}
+	return t
+}
+
+var (
+	tblStore   = newTable("store", "store_id", "code", "website_id", "group_id", "name", "sort_order", "is_active")
+	tblGroup   = newTable("store_group", "group_id", "website_id", "name", "root_category_id", "default_store_id")
+	tblWebsite = newTable("store_website", "website_id", "code", "name", "sort_order", "default_group_id", "is_default")
+)
+
+func TestHandler_Do(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+	h := storebinlog.New(srv)
+	ctx := context.Background()
+
+	// insert
+	assert.NoError(t, h.Do(ctx, binlogsync.InsertAction, tblStore, [][]interface{}{
+		{uint16(7), []byte("fr"), int16(1), int16(1), "France", int16(40), int8(1)},
+	}))
+	st, err := srv.Store(7)
+	assert.NoError(t, err)
+	assert.Exactly(t, "fr", st.Code())
+	assert.Exactly(t, "France", st.Name())
+	assert.True(t, st.IsActive())
+
+	// update uses the after image
+	assert.NoError(t, h.Do(ctx, binlogsync.UpdateAction, tblStore, [][]interface{}{
+		{int16(2), "at", int16(1), int16(1), "Österreich", int16(20), int8(1)},
+		{int16(2), "at", int16(1), int16(1), "Austria", int16(20), int8(0)},
+	}))
+	st, err = srv.Store(2)
+	assert.NoError(t, err)
+	assert.Exactly(t, "Austria", st.Name())
+	assert.False(t, st.IsActive())
+
+	assert.NoError(t, h.Do(ctx, binlogsync.UpdateAction, tblGroup, [][]interface{}{
+		{int16(3), int16(2), "Australia", int32(2), int16(5)},
+		{int16(3), int16(2), "Down Under", int32(2), int16(5)},
+	}))
+	g, err := srv.Group(3)
+	assert.NoError(t, err)
+	assert.Exactly(t, "Down Under", g.Name())
+
+	assert.NoError(t, h.Do(ctx, binlogsync.UpdateAction, tblWebsite, [][]interface{}{
+		{int16(2), "oz", "OZ", int16(20), int16(3), int16(0)},
+		{int16(2), "oz", "Oceania", int16(20), int16(3), int16(0)},
+	}))
+	w, err := srv.Website(2)
+	assert.NoError(t, err)
+	assert.Exactly(t, "Oceania", w.Name())
+
+	// delete
+	assert.NoError(t, h.Do(ctx, binlogsync.DeleteAction, tblStore, [][]interface{}{
+		{int16(6), "nz", int16(2), int16(3), "Kiwi", int16(30), int8(1)},
+	}))
+	_, err = srv.Store(6)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+
+	// other tables get ignored
+	assert.NoError(t, h.Do(ctx, binlogsync.DeleteAction, newTable("catalog_product_entity", "entity_id"), [][]interface{}{{1}}))
+}
+
+func TestHandler_Do_DeleteCascade(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+	h := storebinlog.New(srv)
+	ctx := context.Background()
+
+	// InnoDB deletes the groups and stores via ON DELETE CASCADE without
+	// writing them into the binary log.
+	assert.NoError(t, h.Do(ctx, binlogsync.DeleteAction, tblWebsite, [][]interface{}{
+		{int16(2), "oz", "OZ", int16(20), int16(3), int16(0)},
+	}))
+	_, err := srv.Website(2)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	_, err = srv.Group(3)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	for _, id := range []int64{5, 6} {
+		_, err = srv.Store(id)
+		assert.True(t, errors.IsNotFound(err), "Store %d Error: %s", id, err)
+	}
+
+	assert.NoError(t, h.Do(ctx, binlogsync.DeleteAction, tblGroup, [][]interface{}{
+		{int16(2), int16(1), "UK Group", int32(2), int16(4)},
+	}))
+	_, err = srv.Group(2)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	_, err = srv.Store(4)
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+
+	st, err := srv.Store(1)
+	assert.NoError(t, err)
+	assert.Exactly(t, "de", st.Code())
+}
+
+func TestHandler_Do_M1Prefix(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+	h := storebinlog.New(srv)
+	h.TablePrefix = "mage_"
+
+	tbl := tblStore
+	tbl.Name = "mage_core_store"
+	assert.NoError(t, h.Do(context.Background(), binlogsync.InsertAction, tbl, [][]interface{}{
+		{int16(7), "fr", int16(1), int16(1), "France", int16(40), int8(1)},
+	}))
+	_, err := srv.Store(7)
+	assert.NoError(t, err)
+}
+
+type applierMock struct {
+	c store.Changes
+}
+
+func (am *applierMock) ApplyChanges(c store.Changes) error {
+	am.c = c
+	return nil
+}
+
+func TestHandler_Do_Errors(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+	h := storebinlog.New(srv)
+	ctx := context.Background()
+
+	err := h.Do(ctx, binlogsync.UpdateAction, tblStore, [][]interface{}{
+		{int16(2), "at", int16(1), int16(1), "Austria", int16(20), int8(0)},
+	})
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+
+	err = h.Do(ctx, "truncate", tblStore, nil)
+	assert.True(t, errors.IsNotSupported(err), "Error: %s", err)
+
+	err = h.Do(ctx, binlogsync.InsertAction, tblStore, [][]interface{}{
+		{"x", "fr", int16(1), int16(1), "France", int16(40), int8(1)},
+	})
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+
+	// integrity error of the store.Service, group 99 does not exists
+	err = h.Do(ctx, binlogsync.InsertAction, tblStore, [][]interface{}{
+		{int16(7), "fr", int16(1), int16(99), "France", int16(40), int8(1)},
+	})
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+
+	am := new(applierMock)
+	h = storebinlog.New(am)
+	assert.NoError(t, h.Do(ctx, binlogsync.DeleteAction, tblGroup, [][]interface{}{
+		{int16(2), int16(1), "UK", int32(2), int16(4)},
+	}))
+	assert.Exactly(t, []int64{2}, am.c.DeletedGroupIDs)
+	assert.NoError(t, h.Complete(ctx))
+	assert.Exactly(t, "storebinlog", h.String())
+}
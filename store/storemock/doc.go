@@ -22,5 +22,14 @@ Please import this package as:
 	)
 
 To avoid confusion with other mock packages.
+
+Besides the hard coded NewEurozzyService a Fixture file in JSON or YAML
+describes any tree of websites, groups and stores including their
+configuration values:
+
+	srv, cfg := storemock.MustNewFixtureService("testdata/stores.yaml")
+
+ExportFixture creates such a file from a store.Service loaded from a live
+database.
 */
 package storemock
@@ -0,0 +1,290 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storemock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgfile"
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/corestoreio/csfw/util/null"
+	"gopkg.in/yaml.v2"
+)
+
+// Fixture describes a tree of websites, groups and stores together with their
+// configuration values. It can be decoded from and encoded to JSON or YAML:
+//		websites:
+//		- id: 1
+//		  code: euro
+//		  name: Europe
+//		  is_default: true
+//		  default_group_id: 1
+//		  groups:
+//		  - id: 1
+//		    name: DACH Group
+//		    default_store_id: 2
+//		    stores:
+//		    - {id: 1, code: de, name: Germany, is_active: true}
+//		    - {id: 2, code: at, name: Österreich, is_active: true}
+//		config:
+//		  stores:
+//		    de:
+//		      web/unsecure/base_url: http://de.example.com/
+// The admin website, group and store with ID 0 must be part of the fixture
+// like in the database. The config section uses the codes of websites and
+// stores, see package cfgfile.
+type Fixture struct {
+	Websites []FixtureWebsite `json:"websites" yaml:"websites"`
+	Config   *cfgfile.Tree    `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// FixtureWebsite a website with its groups.
+type FixtureWebsite struct {
+	ID             int64          `json:"id" yaml:"id"`
+	Code           string         `json:"code" yaml:"code"`
+	Name           string         `json:"name,omitempty" yaml:"name,omitempty"`
+	SortOrder      int64          `json:"sort_order,omitempty" yaml:"sort_order,omitempty"`
+	DefaultGroupID int64          `json:"default_group_id,omitempty" yaml:"default_group_id,omitempty"`
+	IsDefault      bool           `json:"is_default,omitempty" yaml:"is_default,omitempty"`
+	Groups         []FixtureGroup `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// FixtureGroup a group with its stores. The website ID gets set by the parent.
+type FixtureGroup struct {
+	ID             int64          `json:"id" yaml:"id"`
+	Name           string         `json:"name" yaml:"name"`
+	RootCategoryID int64          `json:"root_category_id,omitempty" yaml:"root_category_id,omitempty"`
+	DefaultStoreID int64          `json:"default_store_id,omitempty" yaml:"default_store_id,omitempty"`
+	Stores         []FixtureStore `json:"stores,omitempty" yaml:"stores,omitempty"`
+}
+
+// FixtureStore a store. The website and group IDs get set by the parents.
+type FixtureStore struct {
+	ID        int64  `json:"id" yaml:"id"`
+	Code      string `json:"code" yaml:"code"`
+	Name      string `json:"name" yaml:"name"`
+	SortOrder int64  `json:"sort_order,omitempty" yaml:"sort_order,omitempty"`
+	IsActive  bool   `json:"is_active,omitempty" yaml:"is_active,omitempty"`
+}
+
+// DecodeFixture reads a Fixture in the provided format. Error behaviour:
+// NotValid or NotSupported.
+func DecodeFixture(r io.Reader, f cfgfile.Format) (*Fixture, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "[storemock] DecodeFixture.ReadAll")
+	}
+	fx := new(Fixture)
+	switch f {
+	case cfgfile.YAML:
+		err = yaml.Unmarshal(data, fx)
+	case cfgfile.JSON:
+		err = json.Unmarshal(data, fx)
+	default:
+		return nil, errors.NewNotSupportedf("[storemock] Unknown Format %d", f)
+	}
+	if err != nil {
+		return nil, errors.NewNotValidf("[storemock] DecodeFixture: %s", err)
+	}
+	return fx, nil
+}
+
+// LoadFixture reads a Fixture from a file. The format gets detected by the
+// file extension.
+func LoadFixture(name string) (*Fixture, error) {
+	f, err := cfgfile.FormatByFilename(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "[storemock] LoadFixture")
+	}
+	fh, err := os.Open(name)
+	if err != nil {
+		return nil, errors.NewNotFoundf("[storemock] LoadFixture: %s", err)
+	}
+	defer fh.Close()
+	fx, err := DecodeFixture(fh, f)
+	return fx, errors.Wrapf(err, "[storemock] LoadFixture %q", name)
+}
+
+// Encode writes the Fixture in the provided format. Error behaviour:
+// NotSupported.
+func (fx *Fixture) Encode(w io.Writer, f cfgfile.Format) error {
+	var data []byte
+	var err error
+	switch f {
+	case cfgfile.YAML:
+		data, err = yaml.Marshal(fx)
+	case cfgfile.JSON:
+		data, err = json.MarshalIndent(fx, "", "  ")
+	default:
+		return errors.NewNotSupportedf("[storemock] Unknown Format %d", f)
+	}
+	if err != nil {
+		return errors.Wrap(err, "[storemock] Fixture.Encode")
+	}
+	_, err = w.Write(data)
+	return errors.Wrap(err, "[storemock] Fixture.Encode.Write")
+}
+
+// Tables converts the Fixture into the table rows.
+func (fx *Fixture) Tables() (store.TableWebsiteSlice, store.TableGroupSlice, store.TableStoreSlice) {
+	var tws store.TableWebsiteSlice
+	var tgs store.TableGroupSlice
+	var tss store.TableStoreSlice
+	for _, w := range fx.Websites {
+		tws = append(tws, &store.TableWebsite{
+			WebsiteID:      w.ID,
+			Code:           null.StringFrom(w.Code),
+			Name:           null.StringFrom(w.Name),
+			SortOrder:      w.SortOrder,
+			DefaultGroupID: w.DefaultGroupID,
+			IsDefault:      null.BoolFrom(w.IsDefault),
+		})
+		for _, g := range w.Groups {
+			tgs = append(tgs, &store.TableGroup{
+				GroupID:        g.ID,
+				WebsiteID:      w.ID,
+				Name:           g.Name,
+				RootCategoryID: g.RootCategoryID,
+				DefaultStoreID: g.DefaultStoreID,
+			})
+			for _, s := range g.Stores {
+				tss = append(tss, &store.TableStore{
+					StoreID:   s.ID,
+					Code:      null.StringFrom(s.Code),
+					WebsiteID: w.ID,
+					GroupID:   g.ID,
+					Name:      s.Name,
+					SortOrder: s.SortOrder,
+					IsActive:  s.IsActive,
+				})
+			}
+		}
+	}
+	return tws, tgs, tss
+}
+
+// Options returns the options to load the websites, groups and stores into
+// a store.Service.
+func (fx *Fixture) Options() []store.Option {
+	tws, tgs, tss := fx.Tables()
+	return []store.Option{
+		store.WithTableWebsites(tws...),
+		store.WithTableGroups(tgs...),
+		store.WithTableStores(tss...),
+	}
+}
+
+// storageWriter writes into a config.Storager.
+type storageWriter struct {
+	config.Storager
+}
+
+func (sw storageWriter) Write(p cfgpath.Path, v interface{}) error {
+	return sw.Set(p, v)
+}
+
+// NewService creates a store.Service and a cfgmock.Service which contains the
+// configuration values of the Fixture. Additional options get applied after
+// the options of the Fixture.
+func (fx *Fixture) NewService(opts ...store.Option) (*store.Service, *cfgmock.Service, error) {
+	cfg := cfgmock.NewService()
+	srv, err := store.NewService(cfg, append(fx.Options(), opts...)...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "[storemock] Fixture.NewService")
+	}
+	if fx.Config != nil {
+		if _, err := cfgfile.Apply(storageWriter{cfg.Storage}, fx.Config, cfgfile.NewStoreResolver(srv)); err != nil {
+			return nil, nil, errors.Wrap(err, "[storemock] Fixture.NewService.Apply")
+		}
+	}
+	return srv, cfg, nil
+}
+
+// MustNewFixtureService loads the Fixture file and creates the services. Panics
+// on error. Mostly used in tests.
+func MustNewFixtureService(name string, opts ...store.Option) (*store.Service, *cfgmock.Service) {
+	fx, err := LoadFixture(name)
+	if err != nil {
+		panic(fmt.Sprintf("%+v", err))
+	}
+	srv, cfg, err := fx.NewService(opts...)
+	if err != nil {
+		panic(fmt.Sprintf("%+v", err))
+	}
+	return srv, cfg
+}
+
+// ExportFixture creates a Fixture from the websites, groups and stores of the
+// store.Service. The argument cfg can be nil, otherwise all its values get
+// exported into the config section. To export a live database, load the
+// store.Service with LoadFromDB and use ccd.NewDBStorage as cfg:
+//		srv, _ := store.NewService(cfgSrv)
+//		_ = srv.LoadFromDB(dbc.NewSession())
+//		fx, _ := storemock.ExportFixture(srv, ccd.MustNewDBStorage(dbc.DB))
+//		_ = fx.Encode(os.Stdout, cfgfile.YAML)
+// The websites, groups and stores are sorted by their IDs.
+func ExportFixture(srv *store.Service, cfg config.Storager) (*Fixture, error) {
+	fx := new(Fixture)
+	for _, w := range srv.Websites() {
+		fw := FixtureWebsite{
+			ID:             w.Data.WebsiteID,
+			Code:           w.Data.Code.String,
+			Name:           w.Data.Name.String,
+			SortOrder:      w.Data.SortOrder,
+			DefaultGroupID: w.Data.DefaultGroupID,
+			IsDefault:      w.Data.IsDefault.Bool,
+		}
+		for _, g := range srv.Groups().Filter(func(g store.Group) bool { return g.WebsiteID() == fw.ID }) {
+			fg := FixtureGroup{
+				ID:             g.Data.GroupID,
+				Name:           g.Data.Name,
+				RootCategoryID: g.Data.RootCategoryID,
+				DefaultStoreID: g.Data.DefaultStoreID,
+			}
+			for _, s := range srv.Stores().Filter(func(s store.Store) bool { return s.GroupID() == fg.ID }) {
+				fg.Stores = append(fg.Stores, FixtureStore{
+					ID:        s.Data.StoreID,
+					Code:      s.Data.Code.String,
+					Name:      s.Data.Name,
+					SortOrder: s.Data.SortOrder,
+					IsActive:  s.Data.IsActive,
+				})
+			}
+			sort.Slice(fg.Stores, func(i, j int) bool { return fg.Stores[i].ID < fg.Stores[j].ID })
+			fw.Groups = append(fw.Groups, fg)
+		}
+		sort.Slice(fw.Groups, func(i, j int) bool { return fw.Groups[i].ID < fw.Groups[j].ID })
+		fx.Websites = append(fx.Websites, fw)
+	}
+	sort.Slice(fx.Websites, func(i, j int) bool { return fx.Websites[i].ID < fx.Websites[j].ID })
+
+	if cfg != nil {
+		t, err := cfgfile.Export(cfg, cfgfile.NewStoreResolver(srv))
+		if err != nil {
+			return nil, errors.Wrap(err, "[storemock] ExportFixture")
+		}
+		fx.Config = t
+	}
+	return fx, nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storemock_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgfile"
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMustNewFixtureService(t *testing.T) {
+	srv, cfg := storemock.MustNewFixtureService("testdata/eurozzy.yaml")
+	want := storemock.NewEurozzyService(cfgmock.NewService())
+
+	assert.Exactly(t, len(want.Websites()), len(srv.Websites()))
+	assert.Exactly(t, len(want.Groups()), len(srv.Groups()))
+	for _, ws := range want.Stores() {
+		s, err := srv.Store(ws.ID())
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, ws.Data, s.Data, "Store %d", ws.ID())
+		assert.Exactly(t, ws.Website.Data, s.Website.Data, "Store %d", ws.ID())
+		assert.Exactly(t, ws.Group.Data, s.Group.Data, "Store %d", ws.ID())
+	}
+
+	ds, err := srv.DefaultStoreView()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, "at", ds.Code())
+
+	// configuration values with resolved codes
+	baseURL := cfgpath.NewRoute("web/unsecure/base_url")
+	for i, test := range []struct {
+		storeID int64
+		want    string
+	}{
+		{1, "http://de.example.com/"},
+		{2, "http://at.example.com/"},
+		{5, "http://www.example.com/"},
+	} {
+		s, err := srv.Store(test.storeID)
+		if err != nil {
+			t.Fatalf("Index %d: %+v", i, err)
+		}
+		have, err := s.Config.String(baseURL)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+	locale, err := cfg.String(cfgpath.MustNewByParts("general/locale/code").BindWebsite(2))
+	assert.NoError(t, err)
+	assert.Exactly(t, "en_AU", locale)
+}
+
+func TestFixture_ExportEncodeDecode(t *testing.T) {
+	srv, cfg := storemock.MustNewFixtureService("testdata/eurozzy.yaml")
+	fx, err := storemock.ExportFixture(srv, cfg.Storage)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	for _, f := range []cfgfile.Format{cfgfile.YAML, cfgfile.JSON} {
+		var buf bytes.Buffer
+		assert.NoError(t, fx.Encode(&buf, f), "Format %d", f)
+
+		fx2, err := storemock.DecodeFixture(&buf, f)
+		if err != nil {
+			t.Fatalf("Format %d: %+v", f, err)
+		}
+		assert.Exactly(t, fx.Websites, fx2.Websites, "Format %d", f)
+		assert.Exactly(t, "http://de.example.com/", fx2.Config.Stores["de"]["web/unsecure/base_url"], "Format %d", f)
+
+		srv2, _, err := fx2.NewService()
+		if err != nil {
+			t.Fatalf("Format %d: %+v", f, err)
+		}
+		assert.Exactly(t, srv.Stores().IDs(), srv2.Stores().IDs(), "Format %d", f)
+	}
+
+	// without configuration
+	fx, err = storemock.ExportFixture(srv, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, fx.Config)
+	assert.Len(t, fx.Websites, 3)
+	assert.Exactly(t, int64(1), fx.Websites[1].Groups[0].ID)
+	assert.Len(t, fx.Websites[1].Groups[0].Stores, 3)
+}
+
+func TestFixture_Errors(t *testing.T) {
+	_, err := storemock.LoadFixture("testdata/eurozzy.xml")
+	assert.True(t, errors.IsNotSupported(err), "Error: %s", err)
+	_, err = storemock.LoadFixture("testdata/not_existent.yaml")
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	_, err = storemock.DecodeFixture(strings.NewReader("{websites: [}"), cfgfile.YAML)
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+	assert.True(t, errors.IsNotSupported(new(storemock.Fixture).Encode(nil, 0)))
+
+	// unknown store code in the configuration
+	fx, err := storemock.LoadFixture("testdata/eurozzy.yaml")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	fx.Config = &cfgfile.Tree{Stores: map[string]cfgfile.Values{"xx": {"aa/bb/cc": 1}}}
+	_, _, err = fx.NewService()
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+
+	// two default websites
+	fx.Config = nil
+	fx.Websites[2].IsDefault = true
+	_, _, err = fx.NewService()
+	assert.True(t, errors.IsNotValid(err), "Error: %s", err)
+}
@@ -24,6 +24,7 @@ import (
 	"sort"
 
 	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/errors"
 	"github.com/corestoreio/csfw/util/null"
 )
 
@@ -64,6 +65,70 @@ type TableStore struct {
 	IsActive  bool        `db:"is_active" json:",omitempty"`  // is_active smallint(5) unsigned NOT NULL MUL DEFAULT '0'
 }
 
+// RowScanArgs appends a new *TableStore and returns the addresses of
+// its fields for the columns. Implements interface dbr.RowScanner.
+// Generated via tableToStruct.
+func (s *TableStoreSlice) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	e := new(TableStore)
+	*s = append(*s, e)
+	return e.RowScanArgs(columns, args)
+}
+
+// RowScanArgs returns the addresses of the fields for the columns. Unknown
+// columns get discarded. Implements interface dbr.RowScanner.
+// Generated via tableToStruct.
+func (e *TableStore) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	args = args[:0]
+	for _, c := range columns {
+		switch c {
+		case "store_id":
+			args = append(args, &e.StoreID)
+		case "code":
+			args = append(args, &e.Code)
+		case "website_id":
+			args = append(args, &e.WebsiteID)
+		case "group_id":
+			args = append(args, &e.GroupID)
+		case "name":
+			args = append(args, &e.Name)
+		case "sort_order":
+			args = append(args, &e.SortOrder)
+		case "is_active":
+			args = append(args, &e.IsActive)
+		default:
+			args = append(args, nil)
+		}
+	}
+	return args, nil
+}
+
+// AssembleArguments appends the values of the fields for the columns.
+// Implements interface dbr.ArgumentAssembler.
+// Generated via tableToStruct.
+func (e *TableStore) AssembleArguments(columns []string, args []interface{}) ([]interface{}, error) {
+	for _, c := range columns {
+		switch c {
+		case "store_id":
+			args = append(args, e.StoreID)
+		case "code":
+			args = append(args, e.Code)
+		case "website_id":
+			args = append(args, e.WebsiteID)
+		case "group_id":
+			args = append(args, e.GroupID)
+		case "name":
+			args = append(args, e.Name)
+		case "sort_order":
+			args = append(args, e.SortOrder)
+		case "is_active":
+			args = append(args, e.IsActive)
+		default:
+			return nil, errors.NewNotFoundf("[store] TableStore.AssembleArguments: Unknown column %q", c)
+		}
+	}
+	return args, nil
+}
+
 // parentSQLSelect fills this slice with data from the database.
 // Generated via tableToStruct.
 func (s *TableStoreSlice) parentSQLSelect(dbrSess dbr.SessionRunner, cbs ...dbr.SelectCb) (int, error) {
@@ -325,6 +390,62 @@ type TableGroup struct {
 	DefaultStoreID int64  `db:"default_store_id" json:",omitempty"` // default_store_id smallint(5) unsigned NOT NULL MUL DEFAULT '0'
 }
 
+// RowScanArgs appends a new *TableGroup and returns the addresses of
+// its fields for the columns. Implements interface dbr.RowScanner.
+// Generated via tableToStruct.
+func (s *TableGroupSlice) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	e := new(TableGroup)
+	*s = append(*s, e)
+	return e.RowScanArgs(columns, args)
+}
+
+// RowScanArgs returns the addresses of the fields for the columns. Unknown
+// columns get discarded. Implements interface dbr.RowScanner.
+// Generated via tableToStruct.
+func (e *TableGroup) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	args = args[:0]
+	for _, c := range columns {
+		switch c {
+		case "group_id":
+			args = append(args, &e.GroupID)
+		case "website_id":
+			args = append(args, &e.WebsiteID)
+		case "name":
+			args = append(args, &e.Name)
+		case "root_category_id":
+			args = append(args, &e.RootCategoryID)
+		case "default_store_id":
+			args = append(args, &e.DefaultStoreID)
+		default:
+			args = append(args, nil)
+		}
+	}
+	return args, nil
+}
+
+// AssembleArguments appends the values of the fields for the columns.
+// Implements interface dbr.ArgumentAssembler.
+// Generated via tableToStruct.
+func (e *TableGroup) AssembleArguments(columns []string, args []interface{}) ([]interface{}, error) {
+	for _, c := range columns {
+		switch c {
+		case "group_id":
+			args = append(args, e.GroupID)
+		case "website_id":
+			args = append(args, e.WebsiteID)
+		case "name":
+			args = append(args, e.Name)
+		case "root_category_id":
+			args = append(args, e.RootCategoryID)
+		case "default_store_id":
+			args = append(args, e.DefaultStoreID)
+		default:
+			return nil, errors.NewNotFoundf("[store] TableGroup.AssembleArguments: Unknown column %q", c)
+		}
+	}
+	return args, nil
+}
+
 // parentSQLSelect fills this slice with data from the database.
 // Generated via tableToStruct.
 func (s *TableGroupSlice) parentSQLSelect(dbrSess dbr.SessionRunner, cbs ...dbr.SelectCb) (int, error) {
@@ -557,6 +678,66 @@ type TableWebsite struct {
 	IsDefault      null.Bool   `db:"is_default" json:",omitempty"`       // is_default smallint(5) unsigned NULL  DEFAULT '0'
 }
 
+// RowScanArgs appends a new *TableWebsite and returns the addresses of
+// its fields for the columns. Implements interface dbr.RowScanner.
+// Generated via tableToStruct.
+func (s *TableWebsiteSlice) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	e := new(TableWebsite)
+	*s = append(*s, e)
+	return e.RowScanArgs(columns, args)
+}
+
+// RowScanArgs returns the addresses of the fields for the columns. Unknown
+// columns get discarded. Implements interface dbr.RowScanner.
+// Generated via tableToStruct.
+func (e *TableWebsite) RowScanArgs(columns []string, args []interface{}) ([]interface{}, error) {
+	args = args[:0]
+	for _, c := range columns {
+		switch c {
+		case "website_id":
+			args = append(args, &e.WebsiteID)
+		case "code":
+			args = append(args, &e.Code)
+		case "name":
+			args = append(args, &e.Name)
+		case "sort_order":
+			args = append(args, &e.SortOrder)
+		case "default_group_id":
+			args = append(args, &e.DefaultGroupID)
+		case "is_default":
+			args = append(args, &e.IsDefault)
+		default:
+			args = append(args, nil)
+		}
+	}
+	return args, nil
+}
+
+// AssembleArguments appends the values of the fields for the columns.
+// Implements interface dbr.ArgumentAssembler.
+// Generated via tableToStruct.
+func (e *TableWebsite) AssembleArguments(columns []string, args []interface{}) ([]interface{}, error) {
+	for _, c := range columns {
+		switch c {
+		case "website_id":
+			args = append(args, e.WebsiteID)
+		case "code":
+			args = append(args, e.Code)
+		case "name":
+			args = append(args, e.Name)
+		case "sort_order":
+			args = append(args, e.SortOrder)
+		case "default_group_id":
+			args = append(args, e.DefaultGroupID)
+		case "is_default":
+			args = append(args, e.IsDefault)
+		default:
+			return nil, errors.NewNotFoundf("[store] TableWebsite.AssembleArguments: Unknown column %q", c)
+		}
+	}
+	return args, nil
+}
+
 // parentSQLSelect fills this slice with data from the database.
 // Generated via tableToStruct.
 func (s *TableWebsiteSlice) parentSQLSelect(dbrSess dbr.SessionRunner, cbs ...dbr.SelectCb) (int, error) {
@@ -0,0 +1,40 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tenant serves several Magento databases from one process.
+//
+// Each Tenant contains its own database connection, config.Service and
+// store.Service plus optional additional services, for example the scoped
+// net services like cors or ratelimit. The Registry creates a Tenant lazily
+// on its first request by calling the Factory and closes it after an idle
+// time. The middleware WithTenant finds the tenant ID by the host name or an
+// HTTP header and puts the Tenant into the request context:
+//
+//		reg := tenant.NewRegistry(func(ctx context.Context, id string) (*tenant.Tenant, error) {
+//			dbc, err := dbr.NewConnection(dbr.WithDSN(dsnByTenant[id]))
+//			// ... create config.Service and store.Service and call LoadFromDB
+//			return &tenant.Tenant{DB: dbc, Config: cfgSrv, Store: storeSrv}, nil
+//		})
+//		reg.IdleTimeout = 30 * time.Minute
+//		go reg.RunEviction(ctx, time.Minute)
+//		http.Handle("/", reg.WithTenant(tenant.IDByHost)(myHandler))
+//
+//		func myHandler(w http.ResponseWriter, r *http.Request) {
+//			t, ok := tenant.FromContext(r.Context())
+//			// t.Store.DefaultStoreView() ...
+//		}
+//
+// The table definitions in store.TableCollection stay global and must be the
+// same for all tenants.
+package tenant
@@ -0,0 +1,404 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenant
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/log"
+	loghttp "github.com/corestoreio/csfw/log/http"
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/util/errors"
+)
+
+// Tenant contains all services of one merchant. All fields are optional.
+type Tenant struct {
+	// ID gets set by the Registry.
+	ID string
+	// DB connection to the database of the tenant.
+	DB *dbr.Connection
+	// Config configuration service of the tenant.
+	Config *config.Service
+	// Store handles the websites, groups and stores of the tenant.
+	Store *store.Service
+	// Services contains additional services, for example the scoped net
+	// services. Services implementing io.Closer get closed together with the
+	// Tenant.
+	Services map[string]interface{}
+
+	// lastUsed unix nano time of the last access, handled via atomic.
+	lastUsed int64
+	// active number of running requests, handled via atomic.
+	active int32
+	// evicted gets set to 1 when the Registry removes the Tenant while it
+	// still serves requests and to 2 when the Tenant got closed. Handled via
+	// atomic.
+	evicted int32
+}
+
+// Service returns the additional service by its name.
+func (t *Tenant) Service(name string) (interface{}, bool) {
+	s, ok := t.Services[name]
+	return s, ok
+}
+
+// Close closes the additional services, the config.Service and the database
+// connection. Returns the first error.
+func (t *Tenant) Close() error {
+	var firstErr error
+	for name, s := range t.Services {
+		if c, ok := s.(io.Closer); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = errors.Wrapf(err, "[tenant] Close Service %q", name)
+			}
+		}
+	}
+	if t.Config != nil {
+		if err := t.Config.Close(); err != nil && firstErr == nil && !errors.IsAlreadyClosed(err) {
+			firstErr = errors.Wrap(err, "[tenant] Close Config")
+		}
+	}
+	if t.DB != nil {
+		if err := t.DB.Close(); err != nil && firstErr == nil {
+			firstErr = errors.Wrap(err, "[tenant] Close DB")
+		}
+	}
+	return firstErr
+}
+
+func (t *Tenant) touch() {
+	atomic.StoreInt64(&t.lastUsed, time.Now().UnixNano())
+}
+
+// closeEvicted closes the removed Tenant if it does not serve any requests.
+// Otherwise the last running request closes the Tenant via release.
+func (t *Tenant) closeEvicted() error {
+	atomic.StoreInt32(&t.evicted, 1)
+	if atomic.LoadInt32(&t.active) > 0 || !atomic.CompareAndSwapInt32(&t.evicted, 1, 2) {
+		return nil
+	}
+	return t.Close()
+}
+
+// release ends a running request and closes the Tenant if it got evicted
+// meanwhile and this has been the last running request.
+func (t *Tenant) release() error {
+	t.touch()
+	if atomic.AddInt32(&t.active, -1) > 0 || !atomic.CompareAndSwapInt32(&t.evicted, 1, 2) {
+		return nil
+	}
+	return t.Close()
+}
+
+// Factory creates a new Tenant for the ID. It gets called only once per ID
+// until the Tenant gets evicted. Return an error with behaviour NotFound for
+// unknown IDs.
+type Factory func(ctx context.Context, id string) (*Tenant, error)
+
+// IDFunc extracts the tenant ID from a request. An empty ID means the tenant
+// cannot be found.
+type IDFunc func(*http.Request) string
+
+// IDByHost uses the lower case host name without the port as tenant ID.
+func IDByHost(r *http.Request) string {
+	h, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		h = r.Host
+	}
+	return strings.ToLower(h)
+}
+
+// IDByHeader uses the value of the HTTP header as tenant ID.
+func IDByHeader(name string) IDFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// entry contains a Tenant which might still get initialized. The fields
+// tenant and err get written while holding Registry.mu before ready gets
+// closed.
+type entry struct {
+	ready  chan struct{}
+	tenant *Tenant
+	err    error
+}
+
+// Registry creates, caches and evicts the tenants. Safe for concurrent use.
+type Registry struct {
+	// IdleTimeout defines the duration after which an unused Tenant gets
+	// closed and removed. Zero disables the eviction.
+	IdleTimeout time.Duration
+	// ErrorHandler optional, gets called when the Factory returns an error.
+	// Defaults to status code 500.
+	ErrorHandler mw.ErrorHandler
+	// NotFoundHandler optional, gets called when the tenant ID is empty or the
+	// Factory returns an error with behaviour NotFound. Defaults to status
+	// code 404.
+	NotFoundHandler mw.ErrorHandler
+	// Log defaults to log.BlackHole.
+	Log log.Logger
+
+	factory Factory
+	mu      sync.Mutex
+	tenants map[string]*entry
+}
+
+// NewRegistry creates a new Registry which uses the Factory to create the
+// tenants.
+func NewRegistry(f Factory) *Registry {
+	return &Registry{
+		ErrorHandler:    mw.ErrorWithStatusCode(http.StatusInternalServerError),
+		NotFoundHandler: mw.ErrorWithStatusCode(http.StatusNotFound),
+		Log:             log.BlackHole{},
+		factory:         f,
+		tenants:         make(map[string]*entry),
+	}
+}
+
+// Get returns the Tenant for the ID. The Tenant gets created on the first
+// call. Concurrent calls for the same ID wait for the first call to finish. A
+// failed creation gets retried on the next call. Error behaviour: Empty,
+// AlreadyClosed or the errors of the Factory.
+func (r *Registry) Get(ctx context.Context, id string) (*Tenant, error) {
+	if id == "" {
+		return nil, errors.NewEmptyf("[tenant] Empty tenant ID")
+	}
+
+	r.mu.Lock()
+	e, ok := r.tenants[id]
+	if !ok {
+		e = &entry{ready: make(chan struct{})}
+		r.tenants[id] = e
+	}
+	r.mu.Unlock()
+
+	if ok {
+		select {
+		case <-e.ready:
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "[tenant] Get waiting for initialization")
+		}
+		if e.err != nil {
+			return nil, e.err
+		}
+		e.tenant.touch()
+		return e.tenant, nil
+	}
+
+	t, err := r.factory(ctx, id)
+	if err == nil && t == nil {
+		err = errors.NewNotFoundf("[tenant] Factory returned nil for tenant %q", id)
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "[tenant] Factory for tenant %q", id)
+		r.mu.Lock()
+		e.err = err
+		delete(r.tenants, id)
+		r.mu.Unlock()
+		close(e.ready)
+		return nil, err
+	}
+	t.ID = id
+	t.touch()
+
+	r.mu.Lock()
+	closed := r.tenants[id] != e
+	if closed {
+		e.err = errors.NewAlreadyClosedf("[tenant] Registry closed while creating tenant %q", id)
+	} else {
+		e.tenant = t
+	}
+	r.mu.Unlock()
+	close(e.ready)
+	if closed {
+		if cErr := t.Close(); cErr != nil && r.Log.IsInfo() {
+			r.Log.Info("tenant.Registry.Get.Close", log.Err(cErr), log.String("tenant", id))
+		}
+		return nil, e.err
+	}
+
+	if r.Log.IsDebug() {
+		r.Log.Debug("tenant.Registry.Get.Created", log.String("tenant", id))
+	}
+	return t, nil
+}
+
+// Len returns the number of created tenants.
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.tenants)
+}
+
+// acquire starts a running request of the Tenant. Returns false if the
+// Tenant has been evicted after Get returned it.
+func (r *Registry) acquire(id string, t *Tenant) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.tenants[id]; !ok || e.tenant != t {
+		return false
+	}
+	atomic.AddInt32(&t.active, 1)
+	return true
+}
+
+// Evict removes the Tenant and closes it. A Tenant which still serves
+// requests gets closed after the last request has finished. Does nothing if
+// the tenant does not exist or is still getting created.
+func (r *Registry) Evict(id string) error {
+	r.mu.Lock()
+	e, ok := r.tenants[id]
+	var t *Tenant
+	if ok && e.tenant != nil {
+		t = e.tenant
+		delete(r.tenants, id)
+	}
+	r.mu.Unlock()
+	if t == nil {
+		return nil
+	}
+	return errors.Wrapf(t.closeEvicted(), "[tenant] Evict %q", id)
+}
+
+// EvictIdle closes and removes all tenants which have not been used within
+// the IdleTimeout and which have no running requests. Returns the number of
+// evicted tenants.
+func (r *Registry) EvictIdle() int {
+	if r.IdleTimeout <= 0 {
+		return 0
+	}
+	deadline := time.Now().Add(-r.IdleTimeout).UnixNano()
+
+	var idle []*Tenant
+	r.mu.Lock()
+	for id, e := range r.tenants {
+		t := e.tenant
+		if t == nil || atomic.LoadInt32(&t.active) > 0 || atomic.LoadInt64(&t.lastUsed) > deadline {
+			continue
+		}
+		delete(r.tenants, id)
+		idle = append(idle, t)
+	}
+	r.mu.Unlock()
+
+	for _, t := range idle {
+		if err := t.Close(); err != nil && r.Log.IsInfo() {
+			r.Log.Info("tenant.Registry.EvictIdle.Close", log.Err(err), log.String("tenant", t.ID))
+		}
+		if r.Log.IsDebug() {
+			r.Log.Debug("tenant.Registry.EvictIdle", log.String("tenant", t.ID))
+		}
+	}
+	return len(idle)
+}
+
+// RunEviction calls EvictIdle in the interval until the context gets
+// cancelled. Blocking.
+func (r *Registry) RunEviction(ctx context.Context, interval time.Duration) {
+	tkr := time.NewTicker(interval)
+	defer tkr.Stop()
+	for {
+		select {
+		case <-tkr.C:
+			r.EvictIdle()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close removes and closes all tenants. Tenants which still serve requests
+// get closed after their last request has finished and tenants which are
+// still getting created get closed after their creation. Returns the first
+// error.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	ts := make([]*Tenant, 0, len(r.tenants))
+	for _, e := range r.tenants {
+		if e.tenant != nil {
+			ts = append(ts, e.tenant)
+		}
+	}
+	r.tenants = make(map[string]*entry)
+	r.mu.Unlock()
+
+	var firstErr error
+	for _, t := range ts {
+		if err := t.closeEvicted(); err != nil && firstErr == nil {
+			firstErr = errors.Wrapf(err, "[tenant] Close %q", t.ID)
+		}
+	}
+	return firstErr
+}
+
+type ctxTenantKey struct{}
+
+// WithContext adds the Tenant to the context.
+func WithContext(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, ctxTenantKey{}, t)
+}
+
+// FromContext returns the Tenant from the context.
+func FromContext(ctx context.Context) (*Tenant, bool) {
+	t, ok := ctx.Value(ctxTenantKey{}).(*Tenant)
+	return t, ok && t != nil
+}
+
+// WithTenant is a middleware which finds the Tenant for the ID returned by
+// idFn and puts it into the request context. A Tenant does not get evicted
+// while it serves a request.
+func (r *Registry) WithTenant(idFn IDFunc) mw.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id := idFn(req)
+			var t *Tenant
+			var err error
+			// retry if the Tenant gets evicted between Get and acquire
+			for {
+				if t, err = r.Get(req.Context(), id); err != nil || r.acquire(id, t) {
+					break
+				}
+			}
+			if err != nil {
+				if r.Log.IsDebug() {
+					r.Log.Debug("tenant.Registry.WithTenant.Get", log.Err(err), log.String("tenant", id), loghttp.Request("request", req))
+				}
+				if errors.IsEmpty(err) || errors.IsNotFound(err) {
+					r.NotFoundHandler(err).ServeHTTP(w, req)
+					return
+				}
+				r.ErrorHandler(err).ServeHTTP(w, req)
+				return
+			}
+			defer func() {
+				if err := t.release(); err != nil && r.Log.IsInfo() {
+					r.Log.Info("tenant.Registry.WithTenant.Close", log.Err(err), log.String("tenant", id))
+				}
+			}()
+			next.ServeHTTP(w, req.WithContext(WithContext(req.Context(), t)))
+		})
+	}
+}
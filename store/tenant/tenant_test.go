@@ -0,0 +1,283 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenant_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/store/tenant"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/csfw/util/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type closerMock struct {
+	closed int32
+}
+
+func (c *closerMock) Close() error {
+	atomic.AddInt32(&c.closed, 1)
+	return nil
+}
+
+func (c *closerMock) isClosed() bool { return atomic.LoadInt32(&c.closed) > 0 }
+
+// newFactory creates a factory which knows the tenants "a.com" and "b.com"
+// and counts the calls.
+func newFactory(calls *int32) tenant.Factory {
+	return func(_ context.Context, id string) (*tenant.Tenant, error) {
+		atomic.AddInt32(calls, 1)
+		switch id {
+		case "a.com", "b.com":
+			return &tenant.Tenant{
+				Services: map[string]interface{}{"closer": &closerMock{}},
+			}, nil
+		case "broken.com":
+			return nil, errors.NewFatalf("Database gone")
+		}
+		return nil, errors.NewNotFoundf("Tenant %q not found", id)
+	}
+}
+
+func TestRegistry_Get(t *testing.T) {
+	var calls int32
+	reg := tenant.NewRegistry(newFactory(&calls))
+
+	t1, err := reg.Get(context.Background(), "a.com")
+	assert.NoError(t, err)
+	assert.Exactly(t, "a.com", t1.ID)
+	t2, err := reg.Get(context.Background(), "a.com")
+	assert.NoError(t, err)
+	assert.True(t, t1 == t2, "Tenant should be the same pointer")
+	assert.Exactly(t, int32(1), atomic.LoadInt32(&calls))
+
+	_, err = reg.Get(context.Background(), "")
+	assert.True(t, errors.IsEmpty(err), "Error: %s", err)
+
+	_, err = reg.Get(context.Background(), "c.com")
+	assert.True(t, errors.IsNotFound(err), "Error: %s", err)
+	_, err = reg.Get(context.Background(), "broken.com")
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+	// failed tenants get retried
+	_, err = reg.Get(context.Background(), "broken.com")
+	assert.True(t, errors.IsFatal(err), "Error: %s", err)
+	assert.Exactly(t, int32(4), atomic.LoadInt32(&calls))
+	assert.Exactly(t, 1, reg.Len())
+}
+
+func TestRegistry_Get_Concurrent(t *testing.T) {
+	var calls int32
+	reg := tenant.NewRegistry(newFactory(&calls))
+
+	var wg sync.WaitGroup
+	var tenants [20]*tenant.Tenant
+	for i := 0; i < len(tenants); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := "a.com"
+			if i%2 == 0 {
+				id = "b.com"
+			}
+			tn, err := reg.Get(context.Background(), id)
+			assert.NoError(t, err, "Index %d", i)
+			tenants[i] = tn
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Exactly(t, int32(2), atomic.LoadInt32(&calls))
+	for i := 2; i < len(tenants); i++ {
+		assert.True(t, tenants[i] == tenants[i%2], "Index %d", i)
+	}
+}
+
+func TestRegistry_EvictIdle(t *testing.T) {
+	var calls int32
+	reg := tenant.NewRegistry(newFactory(&calls))
+	assert.Exactly(t, 0, reg.EvictIdle(), "IdleTimeout zero disables the eviction")
+	reg.IdleTimeout = 20 * time.Millisecond
+
+	ta, err := reg.Get(context.Background(), "a.com")
+	assert.NoError(t, err)
+
+	// tenant b serves a long running request and must not get evicted
+	started, finish := make(chan struct{}), make(chan struct{})
+	h := reg.WithTenant(tenant.IDByHost)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+	}))
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://b.com/", nil))
+	<-started
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Exactly(t, 1, reg.EvictIdle())
+	assert.True(t, ta.Services["closer"].(*closerMock).isClosed())
+	assert.Exactly(t, 1, reg.Len())
+
+	tb, err := reg.Get(context.Background(), "b.com")
+	assert.NoError(t, err)
+	close(finish)
+	time.Sleep(50 * time.Millisecond)
+	assert.Exactly(t, 1, reg.EvictIdle())
+	assert.True(t, tb.Services["closer"].(*closerMock).isClosed())
+	assert.Exactly(t, 0, reg.Len())
+
+	// gets created again
+	ta2, err := reg.Get(context.Background(), "a.com")
+	assert.NoError(t, err)
+	assert.False(t, ta == ta2, "Tenant should be a new pointer")
+	assert.Exactly(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestRegistry_Evict_Close(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	dbMock.ExpectClose()
+
+	var calls int32
+	fa := newFactory(&calls)
+	reg := tenant.NewRegistry(func(ctx context.Context, id string) (*tenant.Tenant, error) {
+		if id == "db.com" {
+			return &tenant.Tenant{DB: dbc}, nil
+		}
+		return fa(ctx, id)
+	})
+
+	_, err := reg.Get(context.Background(), "db.com")
+	assert.NoError(t, err)
+	ta, err := reg.Get(context.Background(), "a.com")
+	assert.NoError(t, err)
+
+	assert.NoError(t, reg.Evict("db.com"))
+	assert.NoError(t, reg.Evict("unknown.com"))
+	assert.Exactly(t, 1, reg.Len())
+	if err := dbMock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	assert.NoError(t, reg.Close())
+	assert.True(t, ta.Services["closer"].(*closerMock).isClosed())
+	assert.Exactly(t, 0, reg.Len())
+}
+
+func TestRegistry_Get_EvictIdle_Concurrent(t *testing.T) {
+	var calls int32
+	reg := tenant.NewRegistry(newFactory(&calls))
+	reg.IdleTimeout = time.Nanosecond
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			id := "a.com"
+			if i%2 == 0 {
+				id = "b.com"
+			}
+			for j := 0; j < 50; j++ {
+				_, err := reg.Get(context.Background(), id)
+				assert.NoError(t, err, "Index %d", i)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				reg.EvictIdle()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, reg.Close())
+}
+
+func TestRegistry_Evict_Active(t *testing.T) {
+	var calls int32
+	reg := tenant.NewRegistry(newFactory(&calls))
+
+	started, finish, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	h := reg.WithTenant(tenant.IDByHost)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+	}))
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://a.com/", nil))
+		close(done)
+	}()
+	<-started
+
+	ta, err := reg.Get(context.Background(), "a.com")
+	assert.NoError(t, err)
+	assert.NoError(t, reg.Evict("a.com"))
+	assert.Exactly(t, 0, reg.Len())
+	assert.False(t, ta.Services["closer"].(*closerMock).isClosed(), "Active tenant must not get closed")
+
+	close(finish)
+	<-done
+	assert.True(t, ta.Services["closer"].(*closerMock).isClosed(), "Last request must close the evicted tenant")
+}
+
+func TestRegistry_WithTenant(t *testing.T) {
+	var calls int32
+	reg := tenant.NewRegistry(newFactory(&calls))
+
+	finalH := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tn, ok := tenant.FromContext(r.Context())
+		if !ok {
+			t.Fatal("Tenant not found in context")
+		}
+		_, _ = w.Write([]byte(tn.ID))
+	})
+
+	tests := []struct {
+		idFn       tenant.IDFunc
+		host       string
+		header     string
+		wantCode   int
+		wantTenant string
+	}{
+		{tenant.IDByHost, "a.com", "", http.StatusOK, "a.com"},
+		{tenant.IDByHost, "B.com:8080", "", http.StatusOK, "b.com"},
+		{tenant.IDByHost, "c.com", "", http.StatusNotFound, ""},
+		{tenant.IDByHost, "broken.com", "", http.StatusInternalServerError, ""},
+		{tenant.IDByHeader("X-Tenant"), "c.com", "a.com", http.StatusOK, "a.com"},
+		{tenant.IDByHeader("X-Tenant"), "a.com", "", http.StatusNotFound, ""},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest("GET", "http://"+test.host+"/", nil)
+		if test.header != "" {
+			req.Header.Set("X-Tenant", test.header)
+		}
+		rec := httptest.NewRecorder()
+		reg.WithTenant(test.idFn)(finalH).ServeHTTP(rec, req)
+		assert.Exactly(t, test.wantCode, rec.Code, "Index %d", i)
+		if test.wantTenant != "" {
+			assert.Exactly(t, test.wantTenant, rec.Body.String(), "Index %d", i)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := tenant.FromContext(context.Background())
+	assert.False(t, ok)
+	tn, ok := tenant.FromContext(tenant.WithContext(context.Background(), &tenant.Tenant{ID: "x"}))
+	assert.True(t, ok)
+	assert.Exactly(t, "x", tn.ID)
+}
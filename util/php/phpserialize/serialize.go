@@ -3,6 +3,7 @@ package phpserialize
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strconv"
 
 	"github.com/corestoreio/csfw/util/bufferpool"
@@ -160,31 +161,20 @@ func (s *Serializer) encodeArray(buf *bytes.Buffer, v PhpValue, isFinal bool) {
 	}
 
 	switch v.(type) {
-	case PhpArray:
-		arrVal, _ := v.(PhpArray)
-		arrLen = len(arrVal)
-
-		buf.WriteString(s.prepareLen(arrLen))
-		buf.WriteRune(DelimiterObjectLeft)
-
-		for k, v := range arrVal {
-			str, _ = s.Encode(k)
-			buf.WriteString(str)
-			str, _ = s.Encode(v)
-			buf.WriteString(str)
+	case PhpArray, map[PhpValue]PhpValue:
+		arrVal, ok := v.(PhpArray)
+		if !ok {
+			arrVal = PhpArray(v.(map[PhpValue]PhpValue))
 		}
-
-	case map[PhpValue]PhpValue:
-		arrVal, _ := v.(map[PhpValue]PhpValue)
 		arrLen = len(arrVal)
 
 		buf.WriteString(s.prepareLen(arrLen))
 		buf.WriteRune(DelimiterObjectLeft)
 
-		for k, v := range arrVal {
+		for _, k := range sortedKeys(arrVal) {
 			str, _ = s.Encode(k)
 			buf.WriteString(str)
-			str, _ = s.Encode(v)
+			str, _ = s.Encode(arrVal[k])
 			buf.WriteString(str)
 		}
 	case PhpSlice:
@@ -205,6 +195,28 @@ func (s *Serializer) encodeArray(buf *bytes.Buffer, v PhpValue, isFinal bool) {
 	buf.WriteRune(DelimiterObjectRight)
 }
 
+// sortedKeys returns the keys of the array in a stable order: integer keys
+// ascending followed by all other keys sorted by their string representation.
+// PHP keeps the insertion order of an array but a Go map has no order.
+func sortedKeys(arr PhpArray) []PhpValue {
+	keys := make([]PhpValue, 0, len(arr))
+	for k := range arr {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ki, iIsInt := keys[i].(int)
+		kj, jIsInt := keys[j].(int)
+		switch {
+		case iIsInt && jIsInt:
+			return ki < kj
+		case iIsInt != jIsInt:
+			return iIsInt
+		}
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
 func (s *Serializer) encodeObject(buf *bytes.Buffer, v PhpValue) {
 	obj, _ := v.(*PhpObject)
 	buf.WriteRune(TokenObject)
@@ -225,6 +225,23 @@ func TestEncodeArrayMap(t *testing.T) {
 	}
 }
 
+func TestEncodeArraySortedKeys(t *testing.T) {
+	source := PhpArray{
+		"foo": 4,
+		2:     "b",
+		"bar": 2,
+		0:     "a",
+	}
+	const want = `a:4:{i:0;s:1:"a";i:2;s:1:"b";s:3:"bar";i:2;s:3:"foo";i:4;}`
+	for i := 0; i < 10; i++ {
+		if val, err := Serialize(source); err != nil {
+			t.Errorf("Error while encoding array value: %v\n", err)
+		} else if val != want {
+			t.Errorf("Array keys not sorted, expected %q but have got %q\n", want, val)
+		}
+	}
+}
+
 func TestEncodeArrayArray(t *testing.T) {
 	var (
 		source PhpValue